@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "fmt"
+
+// adviceHeadroom is the fraction of the observed peak usage an advice adds on top of it.
+const adviceHeadroom = 0.25
+
+// Advice is a sizing recommendation for an arena, derived from its observed usage.
+type Advice struct {
+	// Bytes is the amount of memory the arena is advised to hold, suited to WithMaxBytes and the initial size of
+	// safe arenas. It is the high water mark plus some headroom, or the double of the memory the arena held if
+	// any allocation fell back to the heap, as how much more the arena would have needed is unknown.
+	Bytes uint64
+
+	// BufferSize and BufferCount are the arguments to NewMonotonicArena holding Bytes, keeping the size
+	// of the current buffers.
+	BufferSize  int
+	BufferCount int
+
+	// Stats are the statistics the advice is derived from.
+	Stats Stats
+}
+
+// Advise returns a sizing recommendation for the arena based on its statistics, which are meant to have been
+// gathered over a representative workload. It reports false if the arena does not implement StatsProvider.
+func Advise(a Arena) (Advice, bool) {
+	sp, ok := a.(StatsProvider)
+	if !ok {
+		return Advice{}, false
+	}
+	s := sp.Stats()
+
+	bytes := uint64(float64(s.HighWaterMark) * (1 + adviceHeadroom))
+	if s.HeapFallbacks > 0 {
+		bytes = max(bytes, 2*s.BytesAllocated)
+	}
+	bufferSize := int(bytes)
+	if bs, ok := a.(bufferSizer); ok {
+		bufferSize = bs.bufferSizeHint()
+	} else if s.Buffers > 0 && s.BytesAllocated > 0 {
+		bufferSize = int(s.BytesAllocated / uint64(s.Buffers))
+	}
+	bufferSize = max(bufferSize, 1)
+	count := max(int((bytes+uint64(bufferSize)-1)/uint64(bufferSize)), 1)
+	return Advice{Bytes: bytes, BufferSize: bufferSize, BufferCount: count, Stats: s}, true
+}
+
+// String returns a human-readable description of the advice.
+func (a Advice) String() string {
+	return fmt.Sprintf("high water mark of %d bytes with %d heap fallbacks: hold %d bytes, %d buffers of %d bytes",
+		a.Stats.HighWaterMark, a.Stats.HeapFallbacks, a.Bytes, a.BufferCount, a.BufferSize)
+}
+
+// bufferSizer is implemented by arenas made of buffers of a configured size, which may not be allocated yet.
+type bufferSizer interface {
+	bufferSizeHint() int
+}
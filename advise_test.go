@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdviseShrinks(t *testing.T) {
+	arena := NewMonotonicArena(1024, 4)
+	_ = MakeSlice[byte](arena, 1000, 1000)
+	_ = MakeSlice[byte](arena, 600, 600)
+	arena.Reset(false)
+
+	advice, ok := Advise(arena)
+	require.True(t, ok)
+	require.Equal(t, uint64(2000), advice.Bytes)
+	require.Equal(t, 1024, advice.BufferSize)
+	require.Equal(t, 2, advice.BufferCount)
+	require.Equal(t, "high water mark of 1600 bytes with 0 heap fallbacks: hold 2000 bytes, 2 buffers of 1024 bytes", advice.String())
+}
+
+func TestAdviseGrowsOnFallbacks(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	_ = MakeSlice[byte](arena, 1000, 1000)
+	_ = MakeSlice[byte](arena, 1000, 1000)
+
+	advice, ok := Advise(arena)
+	require.True(t, ok)
+	require.Equal(t, uint64(2048), advice.Bytes)
+	require.Equal(t, 2, advice.BufferCount)
+	require.Equal(t, uint64(1), advice.Stats.HeapFallbacks)
+}
+
+func TestAdviseUnused(t *testing.T) {
+	advice, ok := Advise(NewMonotonicArena(1024, 1))
+	require.True(t, ok)
+	require.Equal(t, uint64(0), advice.Bytes)
+	require.Equal(t, 1024, advice.BufferSize)
+	require.Equal(t, 1, advice.BufferCount)
+
+	_, ok = Advise(allocHookArena{})
+	require.False(t, ok)
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// appendBufSize is the size of the stack buffer values are formatted into before being appended
+// to arena slices. Longer outputs are still supported, at the cost of a temporary heap allocation.
+const appendBufSize = 256
+
+// Appendf formats according to a format specifier, appends the result to dst and returns the extended buffer.
+// Unlike fmt.Appendf, dst grows by allocating memory from the provided arena when needed.
+func Appendf(a Arena, dst []byte, format string, args ...any) []byte {
+	var buf [appendBufSize]byte
+	return SliceAppend(a, dst, fmt.Appendf(buf[:0], format, args...)...)
+}
+
+// AppendInt is like strconv.AppendInt, but dst grows by allocating memory from the provided arena.
+func AppendInt(a Arena, dst []byte, i int64, base int) []byte {
+	var buf [appendBufSize]byte
+	return SliceAppend(a, dst, strconv.AppendInt(buf[:0], i, base)...)
+}
+
+// AppendUint is like strconv.AppendUint, but dst grows by allocating memory from the provided arena.
+func AppendUint(a Arena, dst []byte, i uint64, base int) []byte {
+	var buf [appendBufSize]byte
+	return SliceAppend(a, dst, strconv.AppendUint(buf[:0], i, base)...)
+}
+
+// AppendFloat is like strconv.AppendFloat, but dst grows by allocating memory from the provided arena.
+func AppendFloat(a Arena, dst []byte, f float64, fmt byte, prec, bitSize int) []byte {
+	var buf [appendBufSize]byte
+	return SliceAppend(a, dst, strconv.AppendFloat(buf[:0], f, fmt, prec, bitSize)...)
+}
+
+// AppendQuote is like strconv.AppendQuote, but dst grows by allocating memory from the provided arena.
+func AppendQuote(a Arena, dst []byte, s string) []byte {
+	var buf [appendBufSize]byte
+	return SliceAppend(a, dst, strconv.AppendQuote(buf[:0], s)...)
+}
+
+// FormatInt is like strconv.FormatInt, but the returned string is allocated from the provided arena.
+func FormatInt(a Arena, i int64, base int) string {
+	return SealString(AppendInt(a, nil, i, base))
+}
+
+// FormatUint is like strconv.FormatUint, but the returned string is allocated from the provided arena.
+func FormatUint(a Arena, i uint64, base int) string {
+	return SealString(AppendUint(a, nil, i, base))
+}
+
+// FormatFloat is like strconv.FormatFloat, but the returned string is allocated from the provided arena.
+func FormatFloat(a Arena, f float64, fmt byte, prec, bitSize int) string {
+	return SealString(AppendFloat(a, nil, f, fmt, prec, bitSize))
+}
+
+// Quote is like strconv.Quote, but the returned string is allocated from the provided arena.
+func Quote(a Arena, s string) string {
+	return SealString(AppendQuote(a, nil, s))
+}
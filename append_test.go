@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/ortuman/nuke/nuketest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendf(t *testing.T) {
+	arena := NewMonotonicArena(8192, 1)
+
+	b := MakeSlice[byte](arena, 0, 4)
+	b = Appendf(arena, b, "%s=%d", "answer", 42)
+	b = Appendf(arena, b, ";%v", true)
+	require.Equal(t, "answer=42;true", string(b))
+	require.True(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(b))))
+
+	// Outputs not fitting the stack buffer are supported as well
+	long := strings.Repeat("x", 2*appendBufSize)
+	require.Equal(t, long, string(Appendf(arena, nil, "%s", long)))
+
+	nuketest.RequireNoAllocs(t, func() {
+		_ = Appendf(arena, nil, "%s=%d", "answer", 42)
+		arena.Reset(false)
+	})
+}
+
+func TestAppendStrconv(t *testing.T) {
+	arena := NewMonotonicArena(8192, 1)
+
+	b := MakeSlice[byte](arena, 0, 1)
+	b = AppendInt(arena, b, -42, 10)
+	b = append(b, ' ')
+	b = AppendUint(arena, b, 255, 16)
+	b = append(b, ' ')
+	b = AppendFloat(arena, b, 1.5, 'f', 2, 64)
+	b = append(b, ' ')
+	b = AppendQuote(arena, b, "nuke\n")
+	require.Equal(t, `-42 ff 1.50 "nuke\n"`, string(b))
+	require.True(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(b))))
+
+	nuketest.RequireNoAllocs(t, func() {
+		b := AppendInt(arena, nil, -1<<62, 2)
+		b = AppendUint(arena, b, 1<<63, 10)
+		b = AppendFloat(arena, b, 3.14159, 'g', -1, 64)
+		_ = AppendQuote(arena, b, "quoted")
+		arena.Reset(false)
+	})
+}
+
+func TestFormatStrconv(t *testing.T) {
+	arena := NewMonotonicArena(8192, 1)
+
+	for _, s := range []string{
+		FormatInt(arena, -1234, 10),
+		FormatUint(arena, 0xbeef, 16),
+		FormatFloat(arena, 0.25, 'e', -1, 32),
+		Quote(arena, "☢"),
+	} {
+		require.True(t, Owns(arena, unsafe.Pointer(unsafe.StringData(s))))
+	}
+	require.Equal(t, "-1234", FormatInt(arena, -1234, 10))
+	require.Equal(t, "beef", FormatUint(arena, 0xbeef, 16))
+	require.Equal(t, "2.5e-01", FormatFloat(arena, 0.25, 'e', -1, 32))
+	require.Equal(t, `"☢"`, Quote(arena, "☢"))
+
+	// Strings are allocated from the heap without an arena
+	require.Equal(t, "7", FormatInt(nil, 7, 10))
+}
@@ -3,13 +3,17 @@
 package nuke
 
 import (
+	"reflect"
 	"unsafe"
 )
 
 // Arena is an interface that describes a memory allocation arena.
+// Arenas can be implemented outside this package, and used along with New, MakeSlice and the rest of helpers.
 type Arena interface {
 	// Alloc allocates memory of the given size and returns a pointer to it.
 	// The alignment parameter specifies the alignment of the allocated memory.
+	// A nil pointer is returned if the arena cannot satisfy the allocation, in which case
+	// New and MakeSlice fall back to Go's heap.
 	Alloc(size, alignment uintptr) unsafe.Pointer
 
 	// Reset resets the arena's state, optionally releasing the memory.
@@ -17,15 +21,67 @@ type Arena interface {
 	Reset(release bool)
 }
 
+// TypedArena is an optional interface implemented by arenas that need to know the type of the values
+// being allocated, such as those keeping pointer-containing types apart. When present, New and MakeSlice
+// use it instead of Alloc.
+type TypedArena interface {
+	Arena
+
+	// AllocType allocates memory for n contiguous values of type t.
+	// When it returns a nil pointer, fallback reports whether the values should be allocated on the heap instead.
+	AllocType(t reflect.Type, n int) (ptr unsafe.Pointer, fallback bool)
+}
+
+// Owner is an optional interface implemented by arenas able to tell whether a pointer points into their memory.
+type Owner interface {
+	// Owns reports whether ptr points into the memory backing the arena, regardless of whether it has been
+	// handed out since the last Reset. Values allocated on the heap as a fallback are not owned by the arena.
+	Owns(ptr unsafe.Pointer) bool
+}
+
+// Owns reports whether ptr points into the memory backing the provided arena, which is how applications can
+// decide whether a value needs to be copied to the heap before outliving the arena. Every arena provided by
+// this package implements the Owner interface, whereas Owns reports false for those that do not, or a nil arena.
+func Owns(a Arena, ptr unsafe.Pointer) bool {
+	o, ok := a.(Owner)
+	return ok && o.Owns(ptr)
+}
+
+// heapRoots keeps the heap allocations of the structures linking their values through arena memory, such as the
+// nodes they fall back to allocating on the heap once the arena is exhausted, reachable: the GC does not scan the
+// memory most arenas hand out, hence pointers stored in it do not keep heap values alive.
+type heapRoots map[unsafe.Pointer]struct{}
+
+// keep retains ptr unless the arena owns the memory it points to, or is nil, in which case every value is
+// allocated on the heap and linked from heap memory.
+func (r *heapRoots) keep(a Arena, ptr unsafe.Pointer) {
+	if a == nil || Owns(a, ptr) {
+		return
+	}
+	if *r == nil {
+		*r = make(heapRoots)
+	}
+	(*r)[ptr] = struct{}{}
+}
+
+// drop releases ptr, which is no longer linked from arena memory.
+func (r heapRoots) drop(ptr unsafe.Pointer) {
+	delete(r, ptr)
+}
+
 // New allocates memory for a value of type T using the provided Arena.
 // If the arena is non-nil, it returns a  *T pointer with memory allocated from the arena.
 // If passed arena is nil, it allocates memory using Go's built-in new function.
+// New returns nil only when the arena is exhausted and its policy is ExhaustedReturnNil.
 func New[T any](a Arena) *T {
 	if a != nil {
-		var x T
-		if ptr := a.Alloc(unsafe.Sizeof(x), unsafe.Alignof(x)); ptr != nil {
+		ptr, fallback := alloc[T](a, 1)
+		if ptr != nil {
 			return (*T)(ptr)
 		}
+		if !fallback {
+			return nil
+		}
 	}
 	return new(T)
 }
@@ -34,14 +90,94 @@ func New[T any](a Arena) *T {
 // using the provided Arena for memory allocation.
 // If the arena is non-nil, it returns a slice with memory allocated from the arena.
 // Otherwise, it returns a slice using Go's built-in make function.
+// MakeSlice returns nil only when the arena is exhausted and its policy is ExhaustedReturnNil.
 func MakeSlice[T any](a Arena, len, cap int) []T {
 	if a != nil {
-		var x T
-		bufSize := int(unsafe.Sizeof(x)) * cap
-		if ptr := (*T)(a.Alloc(uintptr(bufSize), unsafe.Alignof(x))); ptr != nil {
-			s := unsafe.Slice(ptr, cap)
+		ptr, fallback := alloc[T](a, cap)
+		if ptr != nil {
+			s := unsafe.Slice((*T)(ptr), cap)
 			return s[:len]
 		}
+		if !fallback {
+			return nil
+		}
 	}
 	return make([]T, len, cap)
 }
+
+// NewOfType allocates memory for a value of type t using the provided Arena, as New does for types only known
+// at runtime. It returns a reflect.Value holding a pointer to the value, which is a nil pointer only when the
+// arena is exhausted and its policy is ExhaustedReturnNil.
+func NewOfType(a Arena, t reflect.Type) reflect.Value {
+	if a != nil {
+		ptr, fallback := allocType(a, t, 1)
+		if ptr != nil {
+			return reflect.NewAt(t, ptr)
+		}
+		if !fallback {
+			return reflect.Zero(reflect.PointerTo(t))
+		}
+	}
+	return reflect.New(t)
+}
+
+// MakeOfType creates a slice of elements of type t with a given length and capacity using the provided Arena,
+// as MakeSlice does for types only known at runtime. The returned slice is nil only when the arena is exhausted
+// and its policy is ExhaustedReturnNil.
+func MakeOfType(a Arena, t reflect.Type, len, cap int) reflect.Value {
+	st := reflect.SliceOf(t)
+	if a != nil {
+		ptr, fallback := allocType(a, t, cap)
+		if ptr != nil {
+			hdr := &sliceHeader{data: ptr, len: len, cap: cap}
+			return reflect.NewAt(st, unsafe.Pointer(hdr)).Elem()
+		}
+		if !fallback {
+			return reflect.Zero(st)
+		}
+	}
+	return reflect.MakeSlice(st, len, cap)
+}
+
+// sliceHeader mirrors the runtime representation of a slice.
+type sliceHeader struct {
+	data     unsafe.Pointer
+	len, cap int
+}
+
+// Free hands the value p points to, which must have been allocated by New from the same arena since
+// its last Reset, back to the arena, so that subsequent calls to New can reuse its memory.
+// It is a no-op unless the arena recycles values, such as a SafeArena created with WithFreeLists or a SizeClassArena.
+// After invoking this function p becomes immediately invalid.
+func Free[T any](a Arena, p *T) {
+	if fa, ok := a.(freeingArena); ok && p != nil {
+		fa.free(reflect.TypeOf((*T)(nil)).Elem(), unsafe.Pointer(p))
+	}
+}
+
+// freeingArena is implemented by arenas able to recycle individual values before being reset.
+type freeingArena interface {
+	free(t reflect.Type, ptr unsafe.Pointer)
+}
+
+// alloc requests memory for n contiguous values of type T from the arena.
+func alloc[T any](a Arena, n int) (unsafe.Pointer, bool) {
+	if ta, ok := a.(TypedArena); ok {
+		return ta.AllocType(reflect.TypeOf((*T)(nil)).Elem(), n)
+	}
+	var x T
+	return a.Alloc(unsafe.Sizeof(x)*uintptr(n), unsafe.Alignof(x)), true
+}
+
+// allocType requests memory for n contiguous values of type t from the arena.
+func allocType(a Arena, t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if ta, ok := a.(TypedArena); ok {
+		return ta.AllocType(t, n)
+	}
+	return a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+// within reports whether ptr points into the size bytes starting at base.
+func within(base unsafe.Pointer, size uintptr, ptr unsafe.Pointer) bool {
+	return base != nil && uintptr(ptr) >= uintptr(base) && uintptr(ptr) < uintptr(base)+size
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ArenaPool hands out reset arenas and takes them back, resetting them on Put, so that servers can reuse arenas
+// across requests. Arenas are created by a factory whenever the pool has none to reuse. Pools can be bucketed by
+// size, in which case arenas are put back into the bucket matching the memory they hold, and GetSize hands out
+// one from the smallest bucket fitting the requested size.
+//
+// Retained arenas are held by a sync.Pool per bucket, hence dropped by the garbage collector when idle, unless
+// WithMaxRetained bounds how many of them every bucket retains. WithMaxRetainedBytes releases the arenas that
+// grew too large rather than retaining them, so that a single huge request does not inflate the pool for good.
+//
+// An ArenaPool is safe to be accessed concurrently from multiple goroutines.
+type ArenaPool struct {
+	factory  func(size int) Arena
+	buckets  []arenaBucket
+	maxBytes uint64
+
+	gets, misses, drops atomic.Uint64
+}
+
+type arenaBucket struct {
+	size int
+	pool sync.Pool
+	idle chan Arena // retained arenas, if bounded by WithMaxRetained
+}
+
+// PoolOption configures the behavior of an ArenaPool.
+type PoolOption func(*poolOptions)
+
+type poolOptions struct {
+	buckets     []int
+	maxRetained int
+	maxBytes    int
+}
+
+// WithSizeBuckets buckets the pool by the given sizes, in bytes. The factory is passed the size of the bucket
+// it creates an arena for.
+func WithSizeBuckets(sizes ...int) PoolOption {
+	return func(o *poolOptions) {
+		o.buckets = append(o.buckets, sizes...)
+	}
+}
+
+// WithMaxRetained bounds the number of arenas every bucket of the pool retains, the surplus being released.
+func WithMaxRetained(n int) PoolOption {
+	return func(o *poolOptions) {
+		o.maxRetained = n
+	}
+}
+
+// WithMaxRetainedBytes makes the pool release, rather than retain, the arenas holding more than n bytes,
+// as reported by Stats.BytesAllocated.
+func WithMaxRetainedBytes(n int) PoolOption {
+	return func(o *poolOptions) {
+		o.maxBytes = n
+	}
+}
+
+// NewArenaPool returns a pool of the arenas created by factory.
+func NewArenaPool(factory func() Arena, opts ...PoolOption) *ArenaPool {
+	return NewSizedArenaPool(func(int) Arena { return factory() }, opts...)
+}
+
+// NewSizedArenaPool returns a pool of the arenas created by factory, which is passed the size of the bucket
+// it creates an arena for, or zero if the pool is not bucketed.
+func NewSizedArenaPool(factory func(size int) Arena, opts ...PoolOption) *ArenaPool {
+	var o poolOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sizes := append([]int(nil), o.buckets...)
+	sort.Ints(sizes)
+	if len(sizes) == 0 {
+		sizes = []int{0}
+	}
+	p := &ArenaPool{
+		factory:  factory,
+		buckets:  make([]arenaBucket, len(sizes)),
+		maxBytes: uint64(max(o.maxBytes, 0)),
+	}
+	for i, size := range sizes {
+		p.buckets[i].size = size
+		if o.maxRetained > 0 {
+			p.buckets[i].idle = make(chan Arena, o.maxRetained)
+		}
+	}
+	return p
+}
+
+// Get returns an arena from the smallest bucket, creating one if none is retained.
+func (p *ArenaPool) Get() Arena {
+	return p.get(&p.buckets[0])
+}
+
+// GetSize returns an arena from the smallest bucket whose size is at least n bytes, or from the largest bucket
+// if none is, creating one if none is retained.
+func (p *ArenaPool) GetSize(n int) Arena {
+	i := sort.Search(len(p.buckets), func(i int) bool { return p.buckets[i].size >= n })
+	return p.get(&p.buckets[min(i, len(p.buckets)-1)])
+}
+
+func (p *ArenaPool) get(b *arenaBucket) Arena {
+	p.gets.Add(1)
+	if b.idle != nil {
+		select {
+		case a := <-b.idle:
+			return a
+		default:
+		}
+	} else if a, ok := b.pool.Get().(Arena); ok {
+		return a
+	}
+	p.misses.Add(1)
+	return p.factory(b.size)
+}
+
+// Put resets the arena and returns it to the pool, into the largest bucket whose size does not exceed the memory
+// it holds. The arena, and the memory allocated from it, must not be used after invoking this method.
+func (p *ArenaPool) Put(a Arena) {
+	if a == nil {
+		return
+	}
+	held := uint64(0)
+	if sp, ok := a.(StatsProvider); ok {
+		held = sp.Stats().BytesAllocated
+	}
+	if p.maxBytes > 0 && held > p.maxBytes {
+		a.Reset(true)
+		p.drops.Add(1)
+		return
+	}
+	a.Reset(false)
+	i := sort.Search(len(p.buckets), func(i int) bool { return uint64(p.buckets[i].size) > held })
+	b := &p.buckets[max(i-1, 0)]
+	if b.idle == nil {
+		b.pool.Put(a)
+		return
+	}
+	select {
+	case b.idle <- a:
+	default:
+		a.Reset(true)
+		p.drops.Add(1)
+	}
+}
+
+// ArenaPoolStats describes the usage of an ArenaPool.
+type ArenaPoolStats struct {
+	// Gets is the number of arenas handed out.
+	Gets uint64
+
+	// Misses is the number of arenas created, as the pool had none to reuse.
+	Misses uint64
+
+	// Drops is the number of arenas released rather than retained on Put.
+	Drops uint64
+}
+
+// Stats returns the usage statistics of the pool.
+func (p *ArenaPool) Stats() ArenaPoolStats {
+	return ArenaPoolStats{Gets: p.gets.Load(), Misses: p.misses.Load(), Drops: p.drops.Load()}
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaPool(t *testing.T) {
+	pool := NewArenaPool(func() Arena { return NewMonotonicArena(1024, 1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a := pool.Get()
+				require.Zero(t, a.(StatsProvider).Stats().BytesInUse)
+				*New[int](a) = j
+				pool.Put(a)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := pool.Stats()
+	require.Equal(t, uint64(800), s.Gets)
+	require.LessOrEqual(t, s.Misses, s.Gets)
+	require.Zero(t, s.Drops)
+}
+
+func TestArenaPoolMaxRetained(t *testing.T) {
+	pool := NewArenaPool(func() Arena { return NewMonotonicArena(1024, 1) }, WithMaxRetained(1), WithMaxRetainedBytes(2048))
+
+	a1, a2 := pool.Get(), pool.Get()
+	*New[int](a1) = 1
+	pool.Put(a1)
+	pool.Put(a2) // exceeds the retained arenas
+	require.Same(t, a1, pool.Get())
+	require.Zero(t, a1.(StatsProvider).Stats().BytesInUse)
+	require.NotSame(t, a2, pool.Get())
+
+	// Arenas that grew too large are released.
+	big := NewMonotonicArena(4096, 1)
+	_ = New[int](big)
+	pool.Put(big)
+	require.Nil(t, big.(*monotonicArena).buffers[0].ptr)
+	require.NotSame(t, big, pool.Get())
+	require.Equal(t, ArenaPoolStats{Gets: 5, Misses: 4, Drops: 2}, pool.Stats())
+}
+
+func TestArenaPoolSizeBuckets(t *testing.T) {
+	var sizes []int
+	pool := NewSizedArenaPool(func(size int) Arena {
+		sizes = append(sizes, size)
+		return NewMonotonicArena(size, 1)
+	}, WithSizeBuckets(64*1024, 1024), WithMaxRetained(2))
+
+	small, large := pool.Get(), pool.GetSize(2048)
+	huge := pool.GetSize(1 << 20)
+	require.Equal(t, []int{1024, 64 * 1024, 64 * 1024}, sizes)
+
+	// Arenas are put back into the bucket matching the memory they hold.
+	for _, a := range []Arena{small, large, huge} {
+		_ = New[int](a)
+	}
+	pool.Put(large)
+	pool.Put(small)
+	pool.Put(huge)
+	require.Same(t, small, pool.GetSize(1))
+	require.Same(t, large, pool.GetSize(64*1024))
+	require.Same(t, huge, pool.GetSize(2048))
+	require.Len(t, sizes, 3)
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/ortuman/nuke/nuketest"
+	"github.com/stretchr/testify/require"
+)
+
+type allocTestStruct struct {
+	a, b int64
+	c    [4]float32
+}
+
+func TestNewDoesNotAllocate(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			nuketest.RequireNoAllocs(t, func() {
+				_ = New[int](tc.arena)
+				_ = New[allocTestStruct](tc.arena)
+				tc.arena.Reset(false)
+			})
+		})
+	}
+}
+
+func TestMakeSliceDoesNotAllocate(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			nuketest.RequireNoAllocs(t, func() {
+				_ = MakeSlice[int](tc.arena, 0, 16)
+				_ = MakeSlice[allocTestStruct](tc.arena, 4, 4)
+				tc.arena.Reset(false)
+			})
+		})
+	}
+}
+
+func TestSliceAppendDoesNotAllocate(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			nuketest.RequireNoAllocs(t, func() {
+				s := MakeSlice[int](tc.arena, 0, 1)
+				for i := 0; i < 64; i++ {
+					s = SliceAppend(tc.arena, s, i, i+1)
+				}
+				tc.arena.Reset(false)
+			})
+		})
+	}
+}
+
+func TestNewOfType(t *testing.T) {
+	typ := reflect.TypeOf(allocTestStruct{})
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewOfType(tc.arena, typ)
+			require.Equal(t, reflect.PointerTo(typ), v.Type())
+			p := v.Interface().(*allocTestStruct)
+			p.a = 42
+			require.Equal(t, int64(42), v.Elem().Field(0).Int())
+		})
+	}
+
+	require.False(t, NewOfType(nil, typ).IsNil())
+
+	arena := NewMonotonicArena(8, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	require.True(t, NewOfType(arena, typ).IsNil())
+}
+
+func TestMakeOfType(t *testing.T) {
+	typ := reflect.TypeOf(int64(0))
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			v := MakeOfType(tc.arena, typ, 2, 8)
+			require.Equal(t, reflect.SliceOf(typ), v.Type())
+			require.Equal(t, 2, v.Len())
+			require.Equal(t, 8, v.Cap())
+
+			s := v.Interface().([]int64)
+			s = append(s, 1, 2)
+			require.Equal(t, []int64{0, 0, 1, 2}, s)
+			require.Equal(t, v.UnsafePointer(), unsafe.Pointer(unsafe.SliceData(s)))
+		})
+	}
+
+	require.Equal(t, 4, MakeOfType(nil, typ, 4, 4).Len())
+
+	arena := NewMonotonicArena(8, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	require.True(t, MakeOfType(arena, typ, 4, 4).IsNil())
+}
+
+func TestOwns(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			i := New[int64](tc.arena)
+			require.True(t, Owns(tc.arena, unsafe.Pointer(i)))
+			require.True(t, Owns(NewCheckedArena(tc.arena), unsafe.Pointer(i)))
+			require.True(t, Owns(Tagged(tc.arena, "tag"), unsafe.Pointer(i)))
+			require.False(t, Owns(tc.arena, unsafe.Pointer(new(int64))))
+		})
+	}
+
+	require.False(t, Owns(nil, unsafe.Pointer(new(int64))))
+	require.False(t, Owns(allocHookArena{}, unsafe.Pointer(new(int64))))
+}
+
+type namedArena struct {
+	name  string
+	arena Arena
+}
+
+// warmedArenas returns an instance of each arena implementation whose buffers have already been allocated.
+func warmedArenas() []namedArena {
+	arenas := []namedArena{
+		{name: "monotonic", arena: NewMonotonicArena(64*1024, 1)},
+		{name: "concurrent", arena: NewConcurrentArena(NewMonotonicArena(64*1024, 1))},
+		{name: "session", arena: NewSessionArena(time.Hour, 64*1024)},
+		{name: "safe", arena: NewSafeArena(64 * 1024)},
+		{name: "concurrent-safe", arena: NewConcurrentSafeArena(64 * 1024)},
+		{name: "double-ended", arena: NewDoubleEndedArena(64 * 1024)},
+		{name: "stack", arena: NewStackArena(64 * 1024)},
+		{name: "ring", arena: NewRingArena(64*1024, false)},
+		{name: "lock-free", arena: NewLockFreeArena(64*1024, 1)},
+		{name: "sharded", arena: NewShardedArena(2, func() Arena { return NewMonotonicArena(64*1024, 1) })},
+		{name: "size-class", arena: NewSizeClassArena(64 * 1024)},
+		{name: "buddy", arena: NewBuddyArena(64 * 1024)},
+		{name: "compacting", arena: NewCompactingArena(64 * 1024)},
+	}
+	for _, tc := range arenas {
+		_ = New[byte](tc.arena)
+		tc.arena.Reset(false)
+	}
+	return arenas
+}
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build asan
+
+package nuke
+
+/*
+#include <stdint.h>
+#include <sanitizer/asan_interface.h>
+
+// Addresses are passed as integers, as the memory of typed slabs holds Go pointers cgo would reject.
+static void nuke_asan_poison(uintptr_t addr, size_t size) { __asan_poison_memory_region((void *)addr, size); }
+static void nuke_asan_unpoison(uintptr_t addr, size_t size) { __asan_unpoison_memory_region((void *)addr, size); }
+static int nuke_asan_is_poisoned(uintptr_t addr) { return __asan_address_is_poisoned((void *)addr); }
+*/
+import "C"
+
+import "unsafe"
+
+// ASanEnabled reports whether arenas poison the memory they have not handed out for AddressSanitizer,
+// which is the case when built with -asan.
+const ASanEnabled = true
+
+// asanPoison marks size bytes starting at ptr as unaddressable, so that ASan reports accesses to them.
+func asanPoison(ptr unsafe.Pointer, size uintptr) {
+	if ptr != nil && size > 0 {
+		C.nuke_asan_poison(C.uintptr_t(uintptr(ptr)), C.size_t(size))
+	}
+}
+
+// asanUnpoison marks size bytes starting at ptr as addressable again.
+func asanUnpoison(ptr unsafe.Pointer, size uintptr) {
+	if ptr != nil && size > 0 {
+		C.nuke_asan_unpoison(C.uintptr_t(uintptr(ptr)), C.size_t(size))
+	}
+}
+
+// asanPoisoned reports whether the byte ptr points to is unaddressable.
+func asanPoisoned(ptr unsafe.Pointer) bool {
+	return C.nuke_asan_is_poisoned(C.uintptr_t(uintptr(ptr))) != 0
+}
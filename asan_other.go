@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !asan
+
+package nuke
+
+import "unsafe"
+
+// ASanEnabled reports whether arenas poison the memory they have not handed out for AddressSanitizer,
+// which is the case when built with -asan.
+const ASanEnabled = false
+
+func asanPoison(unsafe.Pointer, uintptr) {}
+
+func asanUnpoison(unsafe.Pointer, uintptr) {}
+
+func asanPoisoned(unsafe.Pointer) bool { return false }
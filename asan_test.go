@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+// skipUnderASan skips tests inspecting memory reclaimed by the arena, which AddressSanitizer rightfully reports.
+func skipUnderASan(t *testing.T) {
+	if ASanEnabled {
+		t.Skip("inspects reclaimed memory")
+	}
+}
+
+func TestASanPoisoning(t *testing.T) {
+	if !ASanEnabled {
+		t.Skip("requires -asan")
+	}
+
+	for name, arena := range map[string]Arena{
+		"monotonic": NewMonotonicArena(1024, 1),
+		"mmap":      NewMmapArena(4096, 1),
+		"safe":      NewSafeArena(1024),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := unsafe.Pointer(New[int64](arena))
+			require.False(t, asanPoisoned(p))
+			require.True(t, asanPoisoned(unsafe.Add(p, 8)))
+
+			arena.Reset(false)
+			require.True(t, asanPoisoned(p))
+			require.False(t, asanPoisoned(unsafe.Pointer(New[int64](arena))))
+			arena.Reset(true)
+		})
+	}
+}
+
+func TestASanPoisoningStackArena(t *testing.T) {
+	if !ASanEnabled {
+		t.Skip("requires -asan")
+	}
+
+	arena := NewStackArena(1024)
+	_ = New[int64](arena)
+	m := arena.Push()
+	p := unsafe.Pointer(New[int64](arena))
+	require.False(t, asanPoisoned(p))
+	arena.Pop(m)
+	require.True(t, asanPoisoned(p))
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"math/bits"
+	"reflect"
+	"unsafe"
+)
+
+const (
+	// minBuddyBlock is the size of the smallest block of a BuddyArena, which fits the links of a free block.
+	minBuddyBlock = 16
+
+	// buddyAlignment is the alignment of the start of every region, which bounds the alignment blocks get.
+	buddyAlignment = 4096
+
+	// Block states, as recorded for the minimum block a block starts at, along with the order of the block.
+	buddyAllocated = 0x40
+	buddyFree      = 0x80
+	buddyOrderMask = 0x3f
+
+	// noBuddyBlock terminates the free lists.
+	noBuddyBlock = ^uintptr(0)
+)
+
+// BuddyArena is an arena implementing a buddy allocator: allocations are rounded up to a power of two and served
+// by splitting the blocks of a region in halves, whereas blocks handed back by Free are coalesced with their buddy
+// whenever it is free as well. It fits workloads with highly variable allocation sizes and partial deallocation.
+// Blocks are aligned to their size, up to 4KB.
+//
+// Regions are backed by byte slices, which the GC does not scan, hence the arena is meant to hold POD types.
+// A BuddyArena is not safe to be accessed concurrently from multiple goroutines.
+type BuddyArena struct {
+	regionSize uintptr
+	opts       options
+	counters   arenaCounters
+	hooks      resetHooks
+	regions    []*buddyRegion
+
+	allocatedBytes uintptr // overall size of the regions
+}
+
+// buddyRegion is a power of two sized region split into blocks.
+type buddyRegion struct {
+	buf    []byte
+	base   unsafe.Pointer // start of the region, aligned within buf
+	size   uintptr
+	heads  []uintptr // offset of the first free block of every order
+	blocks []uint8   // state of the block starting at every minimum block, if any
+}
+
+// buddyLinks are stored at the start of free blocks.
+type buddyLinks struct {
+	next, prev uintptr
+}
+
+// NewBuddyArena returns a BuddyArena whose regions are regionSize bytes long, rounded up to a power of two,
+// unless a single allocation requires a larger one. The arena starts with a single region, allocated on first use.
+// Once the regions are exhausted, allocations trigger the exhaustion policy, unless WithGrowOnDemand is passed,
+// in which case a new region is added as long as WithMaxBytes allows it.
+func NewBuddyArena(regionSize int, opts ...Option) *BuddyArena {
+	return &BuddyArena{
+		regionSize: buddyBlockSize(uintptr(regionSize)),
+		opts:       newOptions(opts),
+	}
+}
+
+// buddyBlockSize returns the size of the smallest block fitting size bytes.
+func buddyBlockSize(size uintptr) uintptr {
+	return minBuddyBlock << buddyOrder(size)
+}
+
+// buddyOrder returns the order of the smallest block fitting size bytes.
+func buddyOrder(size uintptr) int {
+	if size <= minBuddyBlock {
+		return 0
+	}
+	return bits.Len(uint((size - 1) / minBuddyBlock))
+}
+
+// Alloc satisfies the Arena interface.
+func (a *BuddyArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *BuddyArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *BuddyArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	raceAlloc(unsafe.Pointer(a))
+	if alignment > buddyAlignment {
+		a.counters.heapFallbacks++
+		traceFallback(size)
+		return nil, true
+	}
+	order := buddyOrder(max(size, alignment))
+	for _, r := range a.regions {
+		if ptr := r.alloc(order); ptr != nil {
+			return a.allocated(ptr, size, order), true
+		}
+	}
+
+	regionSize := max(a.regionSize, minBuddyBlock<<order)
+	if len(a.regions) > 0 && !(a.opts.growOnDemand && a.canGrow(regionSize)) {
+		switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+		case ExhaustedGrow:
+			// Add a region regardless of the limits.
+
+		case ExhaustedReturnNil:
+			return nil, false
+
+		case ExhaustedPanic:
+			panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+		default:
+			a.counters.heapFallbacks++
+			traceFallback(size)
+			return nil, true
+		}
+	}
+	r := newBuddyRegion(regionSize)
+	traceGrowth(uintptr(len(r.buf)))
+	a.regions = append(a.regions, r)
+	a.allocatedBytes += r.size
+	return a.allocated(r.alloc(order), size, order), true
+}
+
+// allocated accounts for a block of the given order, zeroing out the size bytes handed out.
+func (a *BuddyArena) allocated(ptr unsafe.Pointer, size uintptr, order int) unsafe.Pointer {
+	a.counters.allocated(uint64(minBuddyBlock) << order)
+	clear(unsafe.Slice((*byte)(ptr), size))
+	return ptr
+}
+
+// canGrow reports whether a region of the given size can be added without exceeding WithMaxBytes.
+func (a *BuddyArena) canGrow(size uintptr) bool {
+	return a.opts.maxBytes <= 0 || a.allocatedBytes+size <= uintptr(a.opts.maxBytes)
+}
+
+func newBuddyRegion(size uintptr) *buddyRegion {
+	pad := min(size, buddyAlignment) - 1
+	r := &buddyRegion{
+		buf:    make([]byte, size+pad),
+		size:   size,
+		heads:  make([]uintptr, buddyOrder(size)+1),
+		blocks: make([]uint8, size/minBuddyBlock),
+	}
+	r.base = unsafe.Pointer(unsafe.SliceData(r.buf))
+	r.base = unsafe.Add(r.base, (pad+1-uintptr(r.base)%(pad+1))%(pad+1))
+	r.reset()
+	return r
+}
+
+// reset makes the region a single free block.
+func (r *buddyRegion) reset() {
+	for i := range r.heads {
+		r.heads[i] = noBuddyBlock
+	}
+	clear(r.blocks)
+	r.push(len(r.heads)-1, 0)
+}
+
+func (r *buddyRegion) links(off uintptr) *buddyLinks {
+	return (*buddyLinks)(unsafe.Add(r.base, off))
+}
+
+// push adds the block of the given order at off to its free list.
+func (r *buddyRegion) push(order int, off uintptr) {
+	l := r.links(off)
+	l.next, l.prev = r.heads[order], noBuddyBlock
+	if l.next != noBuddyBlock {
+		r.links(l.next).prev = off
+	}
+	r.heads[order] = off
+	r.blocks[off/minBuddyBlock] = buddyFree | uint8(order)
+}
+
+// remove takes the block of the given order at off out of its free list.
+func (r *buddyRegion) remove(order int, off uintptr) {
+	l := r.links(off)
+	if l.prev != noBuddyBlock {
+		r.links(l.prev).next = l.next
+	} else {
+		r.heads[order] = l.next
+	}
+	if l.next != noBuddyBlock {
+		r.links(l.next).prev = l.prev
+	}
+	r.blocks[off/minBuddyBlock] = 0
+}
+
+// alloc returns a block of the given order, splitting a larger one if needed, or nil if none is free.
+func (r *buddyRegion) alloc(order int) unsafe.Pointer {
+	k := order
+	for k < len(r.heads) && r.heads[k] == noBuddyBlock {
+		k++
+	}
+	if k == len(r.heads) {
+		return nil
+	}
+	off := r.heads[k]
+	r.remove(k, off)
+	for ; k > order; k-- {
+		r.push(k-1, off+minBuddyBlock<<(k-1)) // the upper half remains free
+	}
+	r.blocks[off/minBuddyBlock] = buddyAllocated | uint8(order)
+	return unsafe.Add(r.base, off)
+}
+
+// free hands the block at off back, coalescing it with its buddy as long as it is free, and returns its size.
+func (r *buddyRegion) free(off uintptr) uintptr {
+	state := r.blocks[off/minBuddyBlock]
+	if off%minBuddyBlock != 0 || state&buddyAllocated == 0 {
+		panic(fmt.Errorf("%w: %p was not allocated from the arena, or was already freed", ErrInvalidFree,
+			unsafe.Add(r.base, off)))
+	}
+	r.blocks[off/minBuddyBlock] = 0
+	order := int(state & buddyOrderMask)
+	size := uintptr(minBuddyBlock) << order
+	for ; order < len(r.heads)-1; order++ {
+		buddy := off ^ minBuddyBlock<<order
+		if r.blocks[buddy/minBuddyBlock] != buddyFree|uint8(order) {
+			break
+		}
+		r.remove(order, buddy)
+		off = min(off, buddy)
+	}
+	r.push(order, off)
+	return size
+}
+
+// Free hands the block ptr points to, which must have been allocated from the arena since its last Reset, back to
+// the arena, coalescing it with the adjacent free blocks. After invoking this method ptr becomes immediately
+// invalid. Passing a nil pointer, or one the arena did not allocate, such as the result of a heap fallback, is a
+// no-op, whereas freeing a block twice panics with ErrInvalidFree.
+func (a *BuddyArena) Free(ptr unsafe.Pointer) {
+	for _, r := range a.regions {
+		if within(r.base, r.size, ptr) {
+			a.counters.bytesInUse -= uint64(r.free(uintptr(ptr) - uintptr(r.base)))
+			return
+		}
+	}
+}
+
+func (a *BuddyArena) free(_ reflect.Type, ptr unsafe.Pointer) {
+	a.Free(ptr)
+}
+
+// Reset satisfies the Arena interface. Regions are retained unless releasing memory, every one of them becoming
+// a single free block again. Memory is zeroed out as it is handed out rather than on Reset.
+func (a *BuddyArena) Reset(release bool) {
+	defer traceReset().End()
+	a.hooks.run()
+	for _, r := range a.regions {
+		raceReclaim(r.base, r.size)
+		r.reset()
+	}
+	if release {
+		a.regions = nil
+		a.allocatedBytes = 0
+	}
+	a.counters.reset()
+	raceReset(unsafe.Pointer(a))
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *BuddyArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *BuddyArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. Bytes in use account for the power of two sizes allocations are
+// rounded up to.
+func (a *BuddyArena) Stats() Stats {
+	s := a.counters.stats()
+	s.BytesAllocated = uint64(a.allocatedBytes)
+	s.Buffers = len(a.regions)
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *BuddyArena) Owns(ptr unsafe.Pointer) bool {
+	for _, r := range a.regions {
+		if within(r.base, r.size, ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *BuddyArena) resetCount() uint64 {
+	return a.counters.resets
+}
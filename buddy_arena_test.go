@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuddyOrder(t *testing.T) {
+	require.Equal(t, 0, buddyOrder(1))
+	require.Equal(t, 0, buddyOrder(16))
+	require.Equal(t, 1, buddyOrder(17))
+	require.Equal(t, 2, buddyOrder(64))
+	require.Equal(t, 3, buddyOrder(65))
+	require.Equal(t, uintptr(4096), buddyBlockSize(3000))
+}
+
+func TestBuddyArenaSplitAndCoalesce(t *testing.T) {
+	arena := NewBuddyArena(256)
+
+	a := arena.Alloc(16, 8)
+	b := arena.Alloc(16, 8)
+	c := arena.Alloc(64, 8)
+	d := arena.Alloc(100, 8)
+	e := arena.Alloc(32, 8)
+	base := uintptr(a)
+	require.Zero(t, base%256)
+	require.Equal(t, base+16, uintptr(b))
+	require.Equal(t, base+64, uintptr(c))
+	require.Equal(t, base+128, uintptr(d))
+	require.Equal(t, base+32, uintptr(e))
+	require.Nil(t, arena.Alloc(1, 1)) // falls back to the heap
+	require.False(t, Owns(arena, unsafe.Pointer(New[byte](arena))))
+	require.Equal(t, Stats{BytesAllocated: 256, BytesInUse: 256, Buffers: 1, HighWaterMark: 256, HeapFallbacks: 2}, arena.Stats())
+
+	// Freeing both halves of a block coalesces them, so that the whole block can be allocated again.
+	arena.Free(a)
+	require.Equal(t, a, arena.Alloc(8, 8))
+	arena.Free(a)
+	arena.Free(b)
+	require.Equal(t, a, arena.Alloc(32, 8))
+	arena.Free(a)
+	arena.Free(e)
+	arena.Free(c)
+	require.Equal(t, a, arena.Alloc(128, 8))
+	arena.Free(d)
+	arena.Free(a)
+	require.Equal(t, a, arena.Alloc(256, 8))
+	require.Equal(t, uint64(256), arena.Stats().BytesInUse)
+
+	require.PanicsWithError(t, fmt.Sprintf("nuke: invalid free: %p was not allocated from the arena, or was already freed", b), func() { arena.Free(b) })
+	arena.Free(a)
+	requirePanicsWithErrorIs(t, ErrInvalidFree, func() { arena.Free(a) })
+	arena.Free(nil)
+	require.Zero(t, arena.Stats().BytesInUse)
+}
+
+func TestBuddyArenaZeroesMemory(t *testing.T) {
+	arena := NewBuddyArena(1024)
+
+	s := MakeSlice[byte](arena, 64, 64)
+	copy(s, "deadbeef")
+	Free(arena, (*[64]byte)(s))
+	require.Equal(t, make([]byte, 64), MakeSlice[byte](arena, 64, 64))
+}
+
+func TestBuddyArenaAlignment(t *testing.T) {
+	arena := NewBuddyArena(64 * 1024)
+
+	_ = New[byte](arena)
+	p := arena.Alloc(8, 512)
+	require.Zero(t, uintptr(p)%512)
+	require.Nil(t, arena.Alloc(8, 2*buddyAlignment))
+}
+
+func TestBuddyArenaGrowth(t *testing.T) {
+	arena := NewBuddyArena(1024, WithGrowOnDemand(), WithMaxBytes(4096))
+
+	s := MakeSlice[byte](arena, 1024, 1024)
+	big := MakeSlice[byte](arena, 2048, 2048) // gets a region of its own
+	require.True(t, Owns(arena, unsafe.Pointer(&s[0])))
+	require.True(t, Owns(arena, unsafe.Pointer(&big[2047])))
+	require.Equal(t, 2, arena.Stats().Buffers)
+	require.NotNil(t, MakeSlice[byte](arena, 1024, 1024))
+	require.False(t, Owns(arena, unsafe.Pointer(&MakeSlice[byte](arena, 1, 1)[0])))
+
+	arena.Reset(false)
+	require.Equal(t, 3, arena.Stats().Buffers)
+	require.Equal(t, unsafe.Pointer(&s[0]), unsafe.Pointer(&MakeSlice[byte](arena, 1024, 1024)[0]))
+
+	arena.Reset(true)
+	require.Equal(t, Stats{Resets: 2, HighWaterMark: 4096, HeapFallbacks: 1}, arena.Stats())
+}
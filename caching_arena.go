@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// cacheLineSize is the alignment of the chunks handed out to caches, so that caches do not share cache lines.
+const cacheLineSize = 64
+
+// CachingArena is a two-level arena made of a shared arena, which is safe to be accessed concurrently,
+// and of per-worker caches bump allocating with no synchronization from chunks they take from the shared
+// arena under a lock. Every goroutine allocating concurrently from the arena gets a cache of its own by
+// means of NewCache.
+type CachingArena struct {
+	mtx       sync.Mutex
+	a         Arena
+	chunkSize uintptr
+	resets    uint64
+	hooks     resetHooks
+
+	generation atomic.Uint64 // incremented on Reset, invalidating the chunks held by caches
+}
+
+// ArenaCache is a per-worker cache of a CachingArena. Allocations of POD types are bump allocated from the
+// chunk the cache holds, whereas those of types containing pointers, as well as those larger than half a chunk,
+// are forwarded to the shared arena. Resetting a cache only drops its chunk, leaving the shared arena untouched.
+//
+// An ArenaCache is not safe to be accessed concurrently from multiple goroutines.
+type ArenaCache struct {
+	parent     *CachingArena
+	generation uint64
+	ptr        unsafe.Pointer
+	offset     uintptr
+	size       uintptr
+}
+
+// NewCachingArena returns a caching arena on top of a, whose caches take chunks of chunkSize bytes from it.
+// The arena serializes the accesses to a, which hence does not need to be safe for concurrent use.
+func NewCachingArena(a Arena, chunkSize int) *CachingArena {
+	return &CachingArena{a: a, chunkSize: uintptr(chunkSize)}
+}
+
+// NewCache returns a new cache allocating from the arena.
+func (a *CachingArena) NewCache() *ArenaCache {
+	return &ArenaCache{parent: a, generation: a.generation.Load()}
+}
+
+// Alloc satisfies the Arena interface, allocating from the shared arena.
+func (a *CachingArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface, allocating from the shared arena.
+func (a *CachingArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if ta, ok := a.a.(TypedArena); ok {
+		return ta.AllocType(t, n)
+	}
+	return a.a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+// Reset satisfies the Arena interface. It resets the shared arena, invalidating the chunks held by every cache,
+// hence as any other arena it must not be invoked while goroutines are still allocating from it.
+func (a *CachingArena) Reset(release bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.run()
+	a.generation.Add(1)
+	a.a.Reset(release)
+	a.resets++
+}
+
+// OnReset satisfies the ResetNotifier interface.
+// Callbacks are invoked while holding the arena lock, hence they must not access the arena.
+func (a *CachingArena) OnReset(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.add(f)
+}
+
+func (a *CachingArena) watchResets(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface, reporting the statistics of the shared arena, which accounts
+// for the chunks taken by the caches as a whole. It returns zero statistics if the shared arena does not
+// implement StatsProvider.
+func (a *CachingArena) Stats() Stats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if sp, ok := a.a.(StatsProvider); ok {
+		return sp.Stats()
+	}
+	return Stats{}
+}
+
+// Owns satisfies the Owner interface, reporting whether ptr points into the shared arena,
+// including the chunks taken by the caches.
+func (a *CachingArena) Owns(ptr unsafe.Pointer) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return Owns(a.a, ptr)
+}
+
+func (a *CachingArena) resetCount() uint64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.resets
+}
+
+func (a *CachingArena) concurrencySafe() bool {
+	return true
+}
+
+// Alloc satisfies the Arena interface.
+func (c *ArenaCache) Alloc(size, alignment uintptr) unsafe.Pointer {
+	if gen := c.parent.generation.Load(); gen != c.generation {
+		c.ptr, c.generation = nil, gen // the chunk is no longer valid
+	}
+	if ptr := c.bump(size, alignment); ptr != nil {
+		return ptr
+	}
+	if size+alignment-1 > c.parent.chunkSize/2 {
+		return c.parent.Alloc(size, alignment)
+	}
+	if c.ptr = c.parent.Alloc(c.parent.chunkSize, cacheLineSize); c.ptr == nil {
+		return nil
+	}
+	c.offset, c.size = 0, c.parent.chunkSize
+	return c.bump(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (c *ArenaCache) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if hasPointers(t) {
+		return c.parent.AllocType(t, n)
+	}
+	return c.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+func (c *ArenaCache) bump(size, alignment uintptr) unsafe.Pointer {
+	if c.ptr == nil {
+		return nil
+	}
+	alignOffset := (alignment - (uintptr(c.ptr)+c.offset)%alignment) % alignment
+	if c.size-c.offset < size+alignOffset {
+		return nil
+	}
+	ptr := unsafe.Add(c.ptr, c.offset+alignOffset)
+	c.offset += size + alignOffset
+	return ptr
+}
+
+// Reset satisfies the Arena interface. It drops the chunk held by the cache, whose memory is reclaimed
+// when the shared arena is reset.
+func (c *ArenaCache) Reset(bool) {
+	c.ptr, c.offset, c.size = nil, 0, 0
+}
+
+// resetCount reports the resets of the shared arena, as those of the cache do not reclaim memory.
+func (c *ArenaCache) resetCount() uint64 {
+	return c.parent.resetCount()
+}
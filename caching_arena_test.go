@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingArenaCachesBumpFromChunks(t *testing.T) {
+	arena := NewCachingArena(NewMonotonicArena(4096, 1), 256)
+	c1, c2 := arena.NewCache(), arena.NewCache()
+
+	a := New[int64](c1)
+	b := New[int64](c1)
+	c := New[int64](c2)
+	require.Equal(t, uintptr(unsafe.Pointer(a))+8, uintptr(unsafe.Pointer(b)))
+	require.Zero(t, uintptr(unsafe.Pointer(a))%cacheLineSize)
+	require.Zero(t, uintptr(unsafe.Pointer(c))%cacheLineSize)
+
+	// Both caches took a chunk from the shared arena.
+	require.Equal(t, uint64(512), arena.Stats().BytesInUse)
+
+	// Large allocations are forwarded to the shared arena.
+	_ = MakeSlice[byte](c1, 300, 300)
+	require.Equal(t, uint64(812), arena.Stats().BytesInUse)
+}
+
+func TestCachingArenaResetInvalidatesChunks(t *testing.T) {
+	arena := NewCachingArena(NewMonotonicArena(4096, 1), 256)
+	c := arena.NewCache()
+
+	*New[int64](c) = 1
+	arena.Reset(false)
+	require.Equal(t, uint64(0), arena.Stats().BytesInUse)
+
+	// The cache takes a new chunk rather than bumping from the reset one.
+	_ = New[int64](c)
+	require.Equal(t, uint64(256), arena.Stats().BytesInUse)
+}
+
+func TestCachingArenaCacheReportsSharedResets(t *testing.T) {
+	arena := NewCachingArena(NewMonotonicArena(4096, 1), 256)
+	c := arena.NewCache()
+
+	// Resetting the cache leaves the memory it handed out untouched.
+	p := NewPtr[int64](c)
+	c.Reset(false)
+	require.True(t, p.Valid())
+	arena.Reset(false)
+	require.False(t, p.Valid())
+}
+
+func TestCachingArenaPointerTypes(t *testing.T) {
+	arena := NewCachingArena(NewSafeArena(4096), 256)
+	c := arena.NewCache()
+
+	n := New[safeTestNode](c)
+	n.name = "nuke"
+	require.Empty(t, arena.a.(*SafeArena).pod.slabs)
+	require.Equal(t, uint64(1), arena.a.(*SafeArena).TypeStats()[reflect.TypeOf(safeTestNode{})].Objects)
+}
+
+func TestCachingArenaConcurrentCaches(t *testing.T) {
+	arena := NewCachingArena(NewMonotonicArena(1024*1024, 1), 4096)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			c := arena.NewCache()
+			var refs []*int64
+			for i := 0; i < 1000; i++ {
+				p := New[int64](c)
+				*p = int64(g*1000 + i)
+				refs = append(refs, p)
+			}
+			for i, p := range refs {
+				require.Equal(t, int64(g*1000+i), *p)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CheckedArena is a debugging wrapper detecting an arena being reset while in use, which otherwise results in
+// silent memory corruption. It panics with ErrResetRace, along with the stacks of every goroutine, whenever Reset
+// is invoked while another goroutine is allocating from the arena, or while pointers handed out by the arena are
+// marked as live by means of MarkLive, in which case the stacks that marked them are reported as well.
+//
+// Closing the arena releases its memory, after which any use of the arena panics with ErrArenaClosed rather than
+// silently allocating memory again, so that lifecycle bugs surface where they happen.
+//
+// As detecting races requires the wrapper not to serialize the accesses to the wrapped arena, the latter must be
+// safe for concurrent use if the arena is shared by multiple goroutines.
+type CheckedArena struct {
+	a         Arena
+	inflight  atomic.Int64
+	resetting atomic.Bool
+	closed    atomic.Bool
+	resets    atomic.Uint64
+
+	mtx    sync.Mutex
+	live   map[uint64][]byte // stacks that marked pointers as live, by mark identifier
+	nextID uint64
+}
+
+// NewCheckedArena returns a checked arena wrapping a.
+func NewCheckedArena(a Arena) *CheckedArena {
+	return &CheckedArena{a: a, live: make(map[uint64][]byte)}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *CheckedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.enter()
+	defer a.inflight.Add(-1)
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *CheckedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.enter()
+	defer a.inflight.Add(-1)
+	return allocType(a.a, t, n)
+}
+
+func (a *CheckedArena) enter() {
+	if a.closed.Load() {
+		panic(fmt.Errorf("%w: allocation after close", ErrArenaClosed))
+	}
+	a.inflight.Add(1)
+	if a.resetting.Load() {
+		// The caller only defers leaving the arena once enter returns.
+		a.inflight.Add(-1)
+		panic(fmt.Errorf("%w: allocation while the arena is being reset\n\n%s", ErrResetRace, goroutineStacks()))
+	}
+}
+
+// MarkLive marks the pointers the caller holds to the arena memory as live until the returned function is invoked,
+// so that resetting the arena in between panics.
+func (a *CheckedArena) MarkLive() (unmark func()) {
+	stack := debug.Stack()
+	a.mtx.Lock()
+	id := a.nextID
+	a.nextID++
+	a.live[id] = stack
+	a.mtx.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mtx.Lock()
+			delete(a.live, id)
+			a.mtx.Unlock()
+		})
+	}
+}
+
+// Reset satisfies the Arena interface.
+func (a *CheckedArena) Reset(release bool) {
+	if a.closed.Load() {
+		panic(fmt.Errorf("%w: reset after close", ErrArenaClosed))
+	}
+	a.resetting.Store(true)
+	defer a.resetting.Store(false)
+	if n := a.inflight.Load(); n > 0 {
+		panic(fmt.Errorf("%w: %d allocations in progress\n\n%s", ErrResetRace, n, goroutineStacks()))
+	}
+
+	a.mtx.Lock()
+	if len(a.live) > 0 {
+		ids := make([]uint64, 0, len(a.live))
+		for id := range a.live {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		var b strings.Builder
+		for _, id := range ids {
+			fmt.Fprintf(&b, "pointers marked live at:\n%s\n", a.live[id])
+		}
+		a.mtx.Unlock()
+		panic(fmt.Errorf("%w: %d live marks\n\n%s\n%s", ErrResetRace, len(ids), b.String(), goroutineStacks()))
+	}
+	a.mtx.Unlock()
+
+	a.a.Reset(release)
+	a.resets.Add(1)
+}
+
+// Close resets the arena releasing its memory, after which allocating from the arena or resetting it panics with
+// ErrArenaClosed. It panics with ErrResetRace as Reset does, and returns ErrArenaClosed if already closed.
+func (a *CheckedArena) Close() error {
+	if a.closed.Load() {
+		return ErrArenaClosed
+	}
+	a.Reset(true)
+	if a.closed.Swap(true) {
+		return ErrArenaClosed
+	}
+	return nil
+}
+
+// Closed reports whether the arena has been closed.
+func (a *CheckedArena) Closed() bool {
+	return a.closed.Load()
+}
+
+// Stats satisfies the StatsProvider interface.
+// It returns zero statistics if the wrapped arena does not implement StatsProvider.
+func (a *CheckedArena) Stats() Stats {
+	return statsOf(a.a)
+}
+
+// Owns satisfies the Owner interface.
+func (a *CheckedArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *CheckedArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.resets)
+}
+
+func (a *CheckedArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
+
+// goroutineStacks returns the stacks of every goroutine.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
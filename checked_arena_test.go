@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckedArenaLiveMarks(t *testing.T) {
+	arena := NewCheckedArena(NewMonotonicArena(1024, 1))
+
+	*New[int](arena) = 1
+	unmark := arena.MarkLive()
+
+	defer func() {
+		err, _ := recover().(error)
+		require.ErrorIs(t, err, ErrResetRace)
+		require.True(t, strings.Contains(err.Error(), "TestCheckedArenaLiveMarks"))
+
+		// Once unmarked, the arena can be reset.
+		unmark()
+		unmark()
+		arena.Reset(false)
+		require.Equal(t, uint64(1), arena.Stats().Resets)
+	}()
+	arena.Reset(false)
+}
+
+func TestCheckedArenaClose(t *testing.T) {
+	arena := NewCheckedArena(NewMonotonicArena(1024, 1))
+	_ = New[int](arena)
+
+	require.NoError(t, arena.Close())
+	require.True(t, arena.Closed())
+	require.Zero(t, arena.Stats().BytesAllocated)
+	require.ErrorIs(t, arena.Close(), ErrArenaClosed)
+	requirePanicsWithErrorIs(t, ErrArenaClosed, func() { _ = New[int](arena) })
+	requirePanicsWithErrorIs(t, ErrArenaClosed, func() { _ = MakeSlice[byte](arena, 1, 1) })
+	requirePanicsWithErrorIs(t, ErrArenaClosed, func() { arena.Reset(false) })
+}
+
+func TestCheckedArenaResetDuringAllocation(t *testing.T) {
+	arena := NewCheckedArena(nil)
+	arena.a = allocHookArena{hook: func() {
+		requirePanicsWithErrorIs(t, ErrResetRace, func() { arena.Reset(false) })
+	}}
+	_ = New[int](arena)
+}
+
+func TestCheckedArenaAllocationDuringReset(t *testing.T) {
+	arena := NewCheckedArena(nil)
+	arena.a = allocHookArena{reset: func() {
+		requirePanicsWithErrorIs(t, ErrResetRace, func() { _ = New[int](arena) })
+	}}
+	arena.Reset(false)
+
+	// The allocation that panicked is no longer in progress.
+	require.Zero(t, arena.inflight.Load())
+	arena.a = allocHookArena{}
+	arena.Reset(false)
+}
+
+// allocHookArena is a heap-backed arena invoking hooks from within its methods.
+type allocHookArena struct {
+	hook, reset func()
+}
+
+func (a allocHookArena) Alloc(size, _ uintptr) unsafe.Pointer {
+	if a.hook != nil {
+		a.hook()
+	}
+	return unsafe.Pointer(unsafe.SliceData(make([]byte, size)))
+}
+
+func (a allocHookArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	return a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+func (a allocHookArena) Reset(bool) {
+	if a.reset != nil {
+		a.reset()
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runAssert(args []string) error {
+	fs := flag.NewFlagSet("assert", flag.ExitOnError)
+	test := fs.Bool("test", false, "assert from a test rather than from an init function")
+	output := fs.String("output", "", "name of the generated file, relative to dir (default nuke_pod_assert[_test].go)")
+	typeList := fs.String("types", "", "comma-separated list of the types to assert")
+	_ = fs.Parse(args)
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if *output == "" {
+		*output = "nuke_pod_assert.go"
+		if *test {
+			*output = "nuke_pod_assert_test.go"
+		}
+	}
+	outPath := filepath.Join(dir, *output)
+
+	var types []string
+	if *typeList != "" {
+		types = strings.Split(*typeList, ",")
+	}
+	pkgName, files, err := parsePackageDir(dir, outPath)
+	if err != nil {
+		return err
+	}
+	if err := checkAssertTypes(files, types); err != nil {
+		return err
+	}
+	src, err := generateAssertions(pkgName, types, *test)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, src, 0o644)
+}
+
+// checkAssertTypes makes sure every type is declared by the package and can be instantiated.
+func checkAssertTypes(files []*ast.File, types []string) error {
+	if len(types) == 0 {
+		return errors.New("no types to assert")
+	}
+	decls := make(map[string]*ast.TypeSpec)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					decls[ts.Name.Name] = ts
+				}
+			}
+		}
+	}
+	for _, name := range types {
+		ts, ok := decls[name]
+		if !ok {
+			return fmt.Errorf("type %s is not declared by the package", name)
+		}
+		if ts.TypeParams != nil {
+			return fmt.Errorf("type %s is generic", name)
+		}
+	}
+	return nil
+}
+
+func generateAssertions(pkgName string, types []string, test bool) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by nukegen assert; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkgName)
+	if test {
+		fmt.Fprintf(&out, "import (\n\t\"testing\"\n\n\t\"github.com/ortuman/nuke\"\n)\n\n")
+		fmt.Fprintf(&out, "func TestNukePlainOldData(t *testing.T) {\n")
+		fmt.Fprintf(&out, "for _, err := range []error{\n")
+		for _, name := range types {
+			fmt.Fprintf(&out, "nuke.CheckPlainOldData[%s](),\n", name)
+		}
+		fmt.Fprintf(&out, "} {\nif err != nil {\nt.Error(err)\n}\n}\n}\n")
+	} else {
+		fmt.Fprintf(&out, "import \"github.com/ortuman/nuke\"\n\n")
+		fmt.Fprintf(&out, "func init() {\n")
+		for _, name := range types {
+			fmt.Fprintf(&out, "nuke.AssertPlainOldData[%s]()\n", name)
+		}
+		fmt.Fprintf(&out, "}\n")
+	}
+	return format.Source(out.Bytes())
+}
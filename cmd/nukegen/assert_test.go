@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertGeneratedCodeIsUpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "asserttest")
+	outPath := filepath.Join(dir, "nuke_pod_assert_test.go")
+
+	pkgName, files, err := parsePackageDir(dir, outPath)
+	require.NoError(t, err)
+	require.NoError(t, checkAssertTypes(files, []string{"Quote", "Book"}))
+
+	src, err := generateAssertions(pkgName, []string{"Quote", "Book"}, true)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(src), "run go generate ./... to refresh generated code")
+}
+
+func TestAssertInit(t *testing.T) {
+	src, err := generateAssertions("p", []string{"A", "B"}, false)
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by nukegen assert; DO NOT EDIT.
+
+package p
+
+import "github.com/ortuman/nuke"
+
+func init() {
+	nuke.AssertPlainOldData[A]()
+	nuke.AssertPlainOldData[B]()
+}
+`, string(src))
+}
+
+func TestRunAssert(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "types.go"), []byte("package p\ntype A struct{}\ntype B struct{}\n"), 0o644))
+	require.NoError(t, runAssert([]string{"-types", "A,B", dir}))
+
+	src, err := os.ReadFile(filepath.Join(dir, "nuke_pod_assert.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(src), "nuke.AssertPlainOldData[B]()")
+	require.Error(t, runAssert([]string{dir}))
+}
+
+func TestAssertInvalidTypes(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\ntype A struct{}\ntype G[T any] struct{ v T }", 0)
+	require.NoError(t, err)
+	files := []*ast.File{f}
+
+	require.NoError(t, checkAssertTypes(files, []string{"A"}))
+	require.Error(t, checkAssertTypes(files, nil))
+	require.Error(t, checkAssertTypes(files, []string{"B"}))
+	require.Error(t, checkAssertTypes(files, []string{"G"}))
+}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package asserttest exercises the code generated by nukegen assert.
+package asserttest
+
+//go:generate go run github.com/ortuman/nuke/cmd/nukegen assert -test -types Quote,Book
+
+// Quote is a plain struct.
+type Quote struct {
+	Price    float64
+	Quantity uint32
+}
+
+// Book is a struct nesting arrays of plain structs.
+type Book struct {
+	Symbol [8]byte
+	Bids   [4]Quote
+	Asks   [4]Quote
+}
@@ -0,0 +1,102 @@
+// Code generated by nukegen records; DO NOT EDIT.
+
+package recordtest
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/ortuman/nuke"
+)
+
+// SampleRecordSize is the size in bytes of the fixed-width binary encoding of Sample.
+const SampleRecordSize = 62
+
+// DecodeSampleRecords decodes n fixed-width Sample records from b into a slice allocated from the arena.
+// It returns io.ErrUnexpectedEOF if b holds less than n records.
+func DecodeSampleRecords(a nuke.Arena, b []byte, n int) ([]Sample, error) {
+	if n < 0 || len(b)/SampleRecordSize < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	records := nuke.MakeSlice[Sample](a, n, n)
+	for i := range records {
+		p := b[i*SampleRecordSize : (i+1)*SampleRecordSize]
+		r := &records[i]
+		r.ID = ID(binary.LittleEndian.Uint32(p[0:]))
+		r.Flags = p[4]
+		r.Valid = p[5] != 0
+		r.Delta = int16(binary.LittleEndian.Uint16(p[8:]))
+		r.Value = math.Float64frombits(binary.LittleEndian.Uint64(p[10:]))
+		r.Origin.X = math.Float32frombits(binary.LittleEndian.Uint32(p[18:]))
+		r.Origin.Y = math.Float32frombits(binary.LittleEndian.Uint32(p[22:]))
+		for i0 := range r.Path {
+			r.Path[i0].X = math.Float32frombits(binary.LittleEndian.Uint32(p[26+i0*8:]))
+			r.Path[i0].Y = math.Float32frombits(binary.LittleEndian.Uint32(p[30+i0*8:]))
+		}
+		copy(r.Digest[:], p[50:])
+		r.Counter = int64(binary.LittleEndian.Uint64(p[54:]))
+	}
+	return records, nil
+}
+
+// AppendSampleRecords appends the fixed-width binary encoding of records to dst
+// and returns the extended buffer.
+func AppendSampleRecords(dst []byte, records []Sample) []byte {
+	for i := range records {
+		r := &records[i]
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(r.ID))
+		dst = append(dst, byte(r.Flags))
+		if r.Valid {
+			dst = append(dst, 1)
+		} else {
+			dst = append(dst, 0)
+		}
+		dst = append(dst, make([]byte, 2)...)
+		dst = binary.LittleEndian.AppendUint16(dst, uint16(r.Delta))
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(float64(r.Value)))
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(r.Origin.X)))
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(r.Origin.Y)))
+		for i0 := range r.Path {
+			dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(r.Path[i0].X)))
+			dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(r.Path[i0].Y)))
+		}
+		dst = append(dst, r.Digest[:]...)
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(r.Counter))
+	}
+	return dst
+}
+
+// HeaderRecordSize is the size in bytes of the fixed-width binary encoding of Header.
+const HeaderRecordSize = 17
+
+// DecodeHeaderRecords decodes n fixed-width Header records from b into a slice allocated from the arena.
+// It returns io.ErrUnexpectedEOF if b holds less than n records.
+func DecodeHeaderRecords(a nuke.Arena, b []byte, n int) ([]Header, error) {
+	if n < 0 || len(b)/HeaderRecordSize < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	records := nuke.MakeSlice[Header](a, n, n)
+	for i := range records {
+		p := b[i*HeaderRecordSize : (i+1)*HeaderRecordSize]
+		r := &records[i]
+		r.Magic = binary.BigEndian.Uint32(p[0:])
+		r.Version = int8(p[4])
+		r.Kind = rune(binary.BigEndian.Uint32(p[5:]))
+		r.Length = binary.BigEndian.Uint64(p[9:])
+	}
+	return records, nil
+}
+
+// AppendHeaderRecords appends the fixed-width binary encoding of records to dst
+// and returns the extended buffer.
+func AppendHeaderRecords(dst []byte, records []Header) []byte {
+	for i := range records {
+		r := &records[i]
+		dst = binary.BigEndian.AppendUint32(dst, uint32(r.Magic))
+		dst = append(dst, byte(r.Version))
+		dst = binary.BigEndian.AppendUint32(dst, uint32(r.Kind))
+		dst = binary.BigEndian.AppendUint64(dst, uint64(r.Length))
+	}
+	return dst
+}
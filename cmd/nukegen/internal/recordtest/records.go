@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package recordtest exercises the code generated by nukegen records.
+package recordtest
+
+//go:generate go run github.com/ortuman/nuke/cmd/nukegen records
+
+// ID is a defined type over a basic type.
+type ID uint32
+
+// Point is a plain struct nested into records.
+type Point struct {
+	X, Y float32
+}
+
+// Sample is a little-endian record.
+//
+//nuke:record
+type Sample struct {
+	ID      ID
+	Flags   uint8
+	Valid   bool
+	_       [2]byte
+	Delta   int16
+	Value   float64
+	Origin  Point
+	Path    [3]Point
+	Digest  [4]byte
+	Counter int64
+}
+
+// Header is a big-endian record.
+//
+//nuke:record endian=big
+type Header struct {
+	Magic   uint32
+	Version int8
+	Kind    rune
+	Length  uint64
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package recordtest
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleRecordsRoundTrip(t *testing.T) {
+	arena := nuke.NewMonotonicArena(8192, 1, nuke.WithStrictMode())
+
+	samples := []Sample{
+		{
+			ID:      42,
+			Flags:   0x81,
+			Valid:   true,
+			Delta:   -7,
+			Value:   3.25,
+			Origin:  Point{X: 1, Y: -1},
+			Path:    [3]Point{{X: 1, Y: 2}, {X: 3, Y: 4}, {X: 5, Y: 6}},
+			Digest:  [4]byte{0xde, 0xad, 0xbe, 0xef},
+			Counter: -1 << 40,
+		},
+		{ID: 7, Counter: 1},
+	}
+	b := AppendSampleRecords(nil, samples)
+	require.Len(t, b, len(samples)*SampleRecordSize)
+	require.Equal(t, []byte{42, 0, 0, 0, 0x81, 1, 0, 0, 0xf9, 0xff}, b[:10])
+
+	decoded, err := DecodeSampleRecords(arena, b, len(samples))
+	require.NoError(t, err)
+	require.Equal(t, samples, decoded)
+
+	_, err = DecodeSampleRecords(arena, b[:len(b)-1], len(samples))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestHeaderRecordsBigEndian(t *testing.T) {
+	arena := nuke.NewMonotonicArena(1024, 1)
+
+	headers := []Header{{Magic: 0x6e756b65, Version: -1, Kind: 'Z', Length: 1}}
+	b := AppendHeaderRecords(nil, headers)
+	require.Equal(t, []byte{'n', 'u', 'k', 'e', 0xff, 0, 0, 0, 'Z', 0, 0, 0, 0, 0, 0, 0, 1}, b)
+
+	decoded, err := DecodeHeaderRecords(arena, b, 1)
+	require.NoError(t, err)
+	require.Equal(t, headers, decoded)
+}
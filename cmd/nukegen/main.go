@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Command nukegen generates arena-aware code for the types of a Go package.
+//
+// Usage:
+//
+//	nukegen records [-endian little|big] [-output file] [dir]
+//	nukegen assert [-test] [-output file] -types type,... [dir]
+//	nukegen messages [-output file] -types type,... [dir]
+//
+// The records subcommand emits, for every struct type annotated with a
+// //nuke:record comment, a zero-reflection decoder that reads fixed-width
+// binary records straight into an arena-allocated slice, along with the
+// matching encoder. It is usually invoked through a go:generate directive:
+//
+//	//go:generate nukegen records
+//
+// The assert subcommand emits a file asserting that the listed types of the
+// package are plain old data, either from an init function, which panics when
+// the program starts, or, with -test, from a test, which fails, so that adding
+// a pointer field to a type stored in memory the GC does not scan is caught
+// before it corrupts memory:
+//
+//	//go:generate nukegen assert -test -types Trade,Quote
+//
+// The messages subcommand emits, for the listed message types generated by
+// protoc-gen-go and the message types their fields refer to, a decoder of the
+// protobuf wire format allocating the whole message tree, along with its
+// strings, bytes and repeated fields, from an arena, such as the one nukegrpc
+// attaches to every RPC. Oneof and map fields are not supported:
+//
+//	//go:generate nukegen messages -types OrderRequest
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: nukegen <command> [arguments]
+
+commands:
+  records    generate fixed-width binary record decoders and encoders
+  assert     generate plain old data assertions for a list of types
+  messages   generate protobuf decoders allocating message trees from an arena
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "records":
+		err = runRecords(args)
+	case "assert":
+		err = runAssert(args)
+	case "messages":
+		err = runMessages(args)
+	default:
+		fmt.Fprintf(os.Stderr, "nukegen: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nukegen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,410 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func runMessages(args []string) error {
+	fs := flag.NewFlagSet("messages", flag.ExitOnError)
+	output := fs.String("output", "nuke_messages.go", "name of the generated file, relative to dir")
+	typeList := fs.String("types", "", "comma-separated list of the message types to generate decoders for")
+	_ = fs.Parse(args)
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	outPath := filepath.Join(dir, *output)
+
+	var types []string
+	if *typeList != "" {
+		types = strings.Split(*typeList, ",")
+	}
+	pkgName, files, err := parsePackageDir(dir, outPath)
+	if err != nil {
+		return err
+	}
+	pkg, err := parseMessageFiles(pkgName, files, types)
+	if err != nil {
+		return err
+	}
+	src, err := generateMessages(pkg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, src, 0o644)
+}
+
+// messagePackage holds the message types of the package nukegen runs on, in the order their decoders are generated.
+type messagePackage struct {
+	name     string
+	messages []*messageType
+	usesMath bool
+	usesUTF8 bool
+}
+
+// messageType is a struct type generated by protoc-gen-go.
+type messageType struct {
+	name    string
+	fields  []messageField
+	unknown bool // whether the message retains unknown fields
+}
+
+// messageField is a field of a message type, as described by its protobuf struct tag.
+type messageField struct {
+	name     string // name of the Go field
+	num      int
+	kind     string // varint, zigzag32, zigzag64, fixed32, fixed64 or bytes
+	repeated bool
+	pointer  bool   // scalars with explicit presence are held by pointers
+	elem     string // Go type of the values, that is, a basic type, an enum or a message type
+	enum     bool
+	message  bool
+	utf8     bool // proto3 strings are validated
+}
+
+// parseMessageFiles collects the listed message types, along with the message types their fields refer to.
+func parseMessageFiles(pkgName string, files []*ast.File, types []string) (*messagePackage, error) {
+	if len(types) == 0 {
+		return nil, errors.New("no message types")
+	}
+	decls := make(map[string]ast.Expr)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					decls[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+
+	pkg := &messagePackage{name: pkgName}
+	seen := make(map[string]bool)
+	for len(types) > 0 {
+		name := types[0]
+		types = types[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		st, ok := decls[name].(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type declared by the package", name)
+		}
+		msg, err := parseMessage(name, st, decls)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, f := range msg.fields {
+			if f.message {
+				types = append(types, f.elem)
+			}
+			pkg.usesMath = pkg.usesMath || f.elem == "float32" || f.elem == "float64" || f.kind == "zigzag32"
+			pkg.usesUTF8 = pkg.usesUTF8 || f.utf8
+		}
+		pkg.messages = append(pkg.messages, msg)
+	}
+	return pkg, nil
+}
+
+func parseMessage(name string, st *ast.StructType, decls map[string]ast.Expr) (*messageType, error) {
+	msg := &messageType{name: name}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			s, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		if len(field.Names) == 1 && field.Names[0].Name == "unknownFields" {
+			msg.unknown = true
+			continue
+		}
+		if _, ok := tag.Lookup("protobuf_oneof"); ok {
+			return nil, fmt.Errorf("oneof field %s is not supported", field.Names[0].Name)
+		}
+		if _, ok := tag.Lookup("protobuf_key"); ok {
+			return nil, fmt.Errorf("map field %s is not supported", field.Names[0].Name)
+		}
+		pbTag, ok := tag.Lookup("protobuf")
+		if !ok {
+			continue
+		}
+		if len(field.Names) != 1 {
+			return nil, errors.New("embedded fields are not supported")
+		}
+		f, err := parseMessageField(field.Names[0].Name, pbTag, field.Type, decls)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Names[0].Name, err)
+		}
+		msg.fields = append(msg.fields, f)
+	}
+	return msg, nil
+}
+
+// parseMessageField parses a protobuf struct tag, such as "varint,1,opt,name=id,proto3", along with the Go type of the field.
+func parseMessageField(name, tag string, typ ast.Expr, decls map[string]ast.Expr) (messageField, error) {
+	f := messageField{name: name}
+	parts := strings.Split(tag, ",")
+	if len(parts) < 3 {
+		return f, fmt.Errorf("malformed protobuf tag %q", tag)
+	}
+	num, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return f, fmt.Errorf("malformed protobuf tag %q", tag)
+	}
+	f.num, f.kind = num, parts[0]
+	switch parts[2] {
+	case "opt":
+	case "rep":
+		f.repeated = true
+	case "req":
+		return f, errors.New("required fields are not supported")
+	default:
+		return f, fmt.Errorf("malformed protobuf tag %q", tag)
+	}
+	proto3 := false
+	for _, p := range parts[3:] {
+		proto3 = proto3 || p == "proto3"
+		f.enum = f.enum || strings.HasPrefix(p, "enum=")
+	}
+
+	if f.repeated {
+		at, ok := typ.(*ast.ArrayType)
+		if !ok || at.Len != nil {
+			return f, fmt.Errorf("unexpected type %s of a repeated field", exprString(typ))
+		}
+		typ = at.Elt
+	}
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		ident, ok := t.X.(*ast.Ident)
+		if !ok {
+			return f, fmt.Errorf("unsupported type %s", exprString(typ))
+		}
+		if _, isStruct := decls[ident.Name].(*ast.StructType); isStruct {
+			if f.kind != "bytes" {
+				return f, fmt.Errorf("unsupported %s encoding of message type %s", f.kind, ident.Name)
+			}
+			f.message, f.elem = true, ident.Name
+			return f, nil
+		}
+		if f.repeated {
+			return f, fmt.Errorf("unsupported type %s", exprString(typ))
+		}
+		f.pointer, f.elem = true, ident.Name
+
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); !ok || t.Len != nil || ident.Name != "byte" {
+			return f, fmt.Errorf("unsupported type %s", exprString(typ))
+		}
+		f.elem = "[]byte"
+
+	case *ast.Ident:
+		f.elem = t.Name
+
+	default:
+		return f, fmt.Errorf("unsupported type %s", exprString(typ))
+	}
+	if f.enum {
+		if _, ok := decls[f.elem]; !ok {
+			return f, fmt.Errorf("enum type %s is not declared by the package", f.elem)
+		}
+	}
+	f.utf8 = proto3 && f.elem == "string"
+	if _, err := f.value(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// wireType returns the name of the protowire constant of the wire type the values of the field are encoded as,
+// along with the function consuming them.
+func (f messageField) wireType() (typ, consume string) {
+	switch f.kind {
+	case "varint", "zigzag32", "zigzag64":
+		return "protowire.VarintType", "protowire.ConsumeVarint"
+	case "fixed32":
+		return "protowire.Fixed32Type", "protowire.ConsumeFixed32"
+	case "fixed64":
+		return "protowire.Fixed64Type", "protowire.ConsumeFixed64"
+	default:
+		return "protowire.BytesType", "protowire.ConsumeBytes"
+	}
+}
+
+// value returns the expression converting v, as consumed from the wire, to a value of the field.
+func (f messageField) value() (string, error) {
+	elem := f.elem
+	if f.enum {
+		elem = "enum"
+	}
+	switch f.kind + " " + elem {
+	case "varint bool":
+		return "protowire.DecodeBool(v)", nil
+	case "varint int32", "varint int64", "varint uint32", "fixed32 int32", "fixed64 int64":
+		return elem + "(v)", nil
+	case "varint uint64", "fixed32 uint32", "fixed64 uint64":
+		return "v", nil
+	case "varint enum":
+		return f.elem + "(v)", nil
+	case "zigzag32 int32":
+		return "int32(protowire.DecodeZigZag(v & math.MaxUint32))", nil
+	case "zigzag64 int64":
+		return "protowire.DecodeZigZag(v)", nil
+	case "fixed32 float32":
+		return "math.Float32frombits(v)", nil
+	case "fixed64 float64":
+		return "math.Float64frombits(v)", nil
+	case "bytes string":
+		return "nuke.SealString(nukeBytes(a, v))", nil
+	case "bytes []byte":
+		return "nukeBytes(a, v)", nil
+	}
+	return "", fmt.Errorf("unsupported %s encoding of type %s", f.kind, f.elem)
+}
+
+func generateMessages(pkg *messagePackage) ([]byte, error) {
+	g := &messageGenerator{}
+	for _, msg := range pkg.messages {
+		g.generate(msg)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by nukegen messages; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg.name)
+	fmt.Fprintf(&out, "import (\n\t\"errors\"\n")
+	if pkg.usesMath {
+		fmt.Fprintf(&out, "\t\"math\"\n")
+	}
+	if pkg.usesUTF8 {
+		fmt.Fprintf(&out, "\t\"unicode/utf8\"\n")
+	}
+	fmt.Fprintf(&out, "\n\t\"github.com/ortuman/nuke\"\n\t\"google.golang.org/protobuf/encoding/protowire\"\n)\n\n")
+	fmt.Fprintf(&out, "var errNukeRecursionLimit = errors.New(\"nuke: exceeded maximum recursion depth\")\n")
+	if pkg.usesUTF8 {
+		fmt.Fprintf(&out, "var errNukeInvalidUTF8 = errors.New(\"nuke: string field contains invalid UTF-8\")\n")
+	}
+	fmt.Fprintf(&out, "\n// nukeNew allocates a message or a scalar from the arena, falling back to the heap once it is exhausted.\n")
+	fmt.Fprintf(&out, "func nukeNew[T any](a nuke.Arena) *T {\nif p := nuke.New[T](a); p != nil {\nreturn p\n}\n")
+	fmt.Fprintf(&out, "return new(T) // the arena is exhausted\n}\n")
+	fmt.Fprintf(&out, "\n// nukeBytes copies b to the arena.\n")
+	fmt.Fprintf(&out, "func nukeBytes(a nuke.Arena, b []byte) []byte {\nreturn nuke.SliceAppend(a, []byte(nil), b...)\n}\n")
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+type messageGenerator struct {
+	body bytes.Buffer
+}
+
+func (g *messageGenerator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *messageGenerator) generate(msg *messageType) {
+	g.printf("\n// Unmarshal%s decodes the protobuf wire encoding of %s held by b into a message tree allocated from ", msg.name, msg.name)
+	g.printf("the arena.\n// Messages hold pointers, which the arena must keep visible to the GC, as SafeArena or the PointerFallback policy do.\n")
+	g.printf("func Unmarshal%s(a nuke.Arena, b []byte) (*%s, error) {\n", msg.name, msg.name)
+	g.printf("m := nukeNew[%s](a)\n", msg.name)
+	g.printf("if err := nukeUnmarshal%s(a, b, m, protowire.DefaultRecursionLimit); err != nil {\nreturn nil, err\n}\n", msg.name)
+	g.printf("return m, nil\n}\n")
+
+	g.printf("\nfunc nukeUnmarshal%s(a nuke.Arena, b []byte, m *%s, depth int) error {\n", msg.name, msg.name)
+	g.printf("if depth == 0 {\nreturn errNukeRecursionLimit\n}\n")
+	g.printf("for len(b) > 0 {\n")
+	if msg.unknown {
+		g.printf("field := b\n")
+	}
+	g.printf("num, typ, n := protowire.ConsumeTag(b)\n")
+	g.printf("if n < 0 {\nreturn protowire.ParseError(n)\n}\n")
+	g.printf("b = b[n:]\n")
+	g.printf("switch {\n")
+	for _, f := range msg.fields {
+		g.field(f)
+	}
+	g.printf("default:\n")
+	g.printf("n := protowire.ConsumeFieldValue(num, typ, b)\n")
+	g.printf("if n < 0 {\nreturn protowire.ParseError(n)\n}\n")
+	g.printf("b = b[n:]\n")
+	if msg.unknown {
+		g.printf("m.unknownFields = nuke.SliceAppend(a, m.unknownFields, field[:len(field)-len(b)]...)\n")
+	}
+	g.printf("}\n}\nreturn nil\n}\n")
+}
+
+func (g *messageGenerator) field(f messageField) {
+	typ, consume := f.wireType()
+	target := "m." + f.name
+	if f.repeated && f.kind != "bytes" {
+		// Repeated scalars are accepted both packed and unpacked, as proto.Unmarshal does.
+		g.printf("case num == %d && typ == protowire.BytesType:\n", f.num)
+		g.consume("protowire.ConsumeBytes", "packed")
+		g.printf("for len(packed) > 0 {\n")
+		g.printf("v, n := %s(packed)\n", consume)
+		g.printf("if n < 0 {\nreturn protowire.ParseError(n)\n}\n")
+		g.printf("packed = packed[n:]\n")
+		g.printf("%s = nuke.SliceAppend(a, %s, %s)\n", target, target, f.mustValue())
+		g.printf("}\n")
+	}
+	g.printf("case num == %d && typ == %s:\n", f.num, typ)
+	g.consume(consume, "v")
+	switch {
+	case f.message && f.repeated:
+		g.printf("e := nukeNew[%s](a)\n", f.elem)
+		g.printf("if err := nukeUnmarshal%s(a, v, e, depth-1); err != nil {\nreturn err\n}\n", f.elem)
+		g.printf("%s = nuke.SliceAppend(a, %s, e)\n", target, target)
+
+	case f.message:
+		// Messages occurring more than once are merged, as proto.Unmarshal does.
+		g.printf("if %s == nil {\n%s = nukeNew[%s](a)\n}\n", target, target, f.elem)
+		g.printf("if err := nukeUnmarshal%s(a, v, %s, depth-1); err != nil {\nreturn err\n}\n", f.elem, target)
+
+	default:
+		if f.utf8 {
+			g.printf("if !utf8.Valid(v) {\nreturn errNukeInvalidUTF8\n}\n")
+		}
+		switch {
+		case f.repeated:
+			g.printf("%s = nuke.SliceAppend(a, %s, %s)\n", target, target, f.mustValue())
+		case f.pointer:
+			g.printf("%s = nukeNew[%s](a)\n*%s = %s\n", target, f.elem, target, f.mustValue())
+		default:
+			g.printf("%s = %s\n", target, f.mustValue())
+		}
+	}
+}
+
+// consume emits the code consuming a value from b into the named variable.
+func (g *messageGenerator) consume(consume, name string) {
+	g.printf("%s, n := %s(b)\n", name, consume)
+	g.printf("if n < 0 {\nreturn protowire.ParseError(n)\n}\n")
+	g.printf("b = b[n:]\n")
+}
+
+// mustValue returns the expression converting v to a value of the field, which parseMessageField validated.
+func (f messageField) mustValue() string {
+	v, err := f.value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
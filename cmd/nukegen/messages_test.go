@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagesGeneratedCodeIsUpToDate(t *testing.T) {
+	// The generated code depends on the protobuf module, hence it is exercised by the nukegrpc module.
+	dir := filepath.Join("..", "..", "nukegrpc", "internal", "messagetest")
+	outPath := filepath.Join(dir, "nuke_messages.go")
+
+	pkgName, files, err := parsePackageDir(dir, outPath)
+	require.NoError(t, err)
+	pkg, err := parseMessageFiles(pkgName, files, []string{"Order"})
+	require.NoError(t, err)
+
+	src, err := generateMessages(pkg)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(src), "run go generate ./... in nukegrpc to refresh generated code")
+}
+
+func TestMessagesReferencedTypes(t *testing.T) {
+	pkg := parseTestMessages(t, `
+type A struct {
+	B *B `+"`protobuf:\"bytes,1,opt,name=b,proto3\"`"+`
+	C []*C `+"`protobuf:\"bytes,2,rep,name=c,proto3\"`"+`
+}
+type B struct {
+	A *A `+"`protobuf:\"bytes,1,opt,name=a,proto3\"`"+`
+}
+type C struct {
+	unknownFields []byte
+}`, "A")
+	require.Len(t, pkg.messages, 3)
+	require.Equal(t, "A", pkg.messages[0].name)
+	require.Equal(t, "B", pkg.messages[1].name)
+	require.Equal(t, "C", pkg.messages[2].name)
+	require.False(t, pkg.messages[0].unknown)
+	require.True(t, pkg.messages[2].unknown)
+	require.False(t, pkg.usesMath)
+	require.False(t, pkg.usesUTF8)
+
+	_, err := generateMessages(pkg)
+	require.NoError(t, err)
+}
+
+func TestMessagesUnsupportedFields(t *testing.T) {
+	for _, field := range []string{
+		"X isM_X `protobuf_oneof:\"x\"`",
+		"X map[string]int32 `protobuf:\"bytes,1,rep,name=x,proto3\" protobuf_key:\"bytes,1,opt,name=key,proto3\" protobuf_val:\"varint,2,opt,name=value,proto3\"`",
+		"X *int32 `protobuf:\"varint,1,req,name=x\"`",
+		"X *M_Group `protobuf:\"group,1,opt,name=X\"`",
+		"X string `protobuf:\"varint,1,opt,name=x,proto3\"`",
+		"X int32 `protobuf:\"fixed64,1,opt,name=x,proto3\"`",
+		"X *timestamppb.Timestamp `protobuf:\"bytes,1,opt,name=x,proto3\"`",
+		"X int32 `protobuf:\"varint,x,opt,name=x,proto3\"`",
+		"X []int32 `protobuf:\"varint,1,opt,name=x,proto3\"`",
+		"X Unknown `protobuf:\"varint,1,opt,name=x,proto3,enum=p.Unknown\"`",
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "", "package p\ntype M struct {\n"+field+"\n}\ntype M_Group struct{}", 0)
+		require.NoError(t, err)
+		_, err = parseMessageFiles("p", []*ast.File{f}, []string{"M"})
+		require.Error(t, err, field)
+	}
+
+	_, err := parseMessageFiles("p", nil, nil)
+	require.Error(t, err)
+	_, err = parseMessageFiles("p", nil, []string{"M"})
+	require.Error(t, err)
+}
+
+func TestRunMessages(t *testing.T) {
+	dir := t.TempDir()
+	src := "package p\ntype M struct {\nX float32 `protobuf:\"fixed32,1,opt,name=x,proto3\"`\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "m.pb.go"), []byte(src), 0o644))
+	require.NoError(t, runMessages([]string{"-types", "M", dir}))
+
+	out, err := os.ReadFile(filepath.Join(dir, "nuke_messages.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(out), "func UnmarshalM(a nuke.Arena, b []byte) (*M, error) {")
+	require.Contains(t, string(out), "m.X = math.Float32frombits(v)")
+	require.Error(t, runMessages([]string{dir}))
+}
+
+func parseTestMessages(t *testing.T, decls string, types ...string) *messagePackage {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+decls, 0)
+	require.NoError(t, err)
+	pkg, err := parseMessageFiles("p", []*ast.File{f}, types)
+	require.NoError(t, err)
+	return pkg
+}
@@ -0,0 +1,522 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const recordDirective = "//nuke:record"
+
+// basicTypes maps the supported fixed-width basic types to their encoded size.
+var basicTypes = map[string]int{
+	"bool":    1,
+	"byte":    1,
+	"int8":    1,
+	"uint8":   1,
+	"int16":   2,
+	"uint16":  2,
+	"int32":   4,
+	"rune":    4,
+	"uint32":  4,
+	"float32": 4,
+	"int64":   8,
+	"uint64":  8,
+	"float64": 8,
+}
+
+func runRecords(args []string) error {
+	fs := flag.NewFlagSet("records", flag.ExitOnError)
+	endian := fs.String("endian", "little", "default byte order of the generated code (little or big)")
+	output := fs.String("output", "nuke_records.go", "name of the generated file, relative to dir")
+	_ = fs.Parse(args)
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	if *endian != "little" && *endian != "big" {
+		return fmt.Errorf("invalid byte order %q", *endian)
+	}
+	outPath := filepath.Join(dir, *output)
+
+	pkg, err := loadRecordPackage(dir, outPath)
+	if err != nil {
+		return err
+	}
+	src, err := generateRecords(pkg, *endian)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, src, 0o644)
+}
+
+// recordPackage holds the type declarations of the package nukegen runs on.
+type recordPackage struct {
+	name    string
+	types   map[string]ast.Expr
+	records []recordType
+}
+
+// recordType is a struct type annotated with the record directive.
+type recordType struct {
+	name   string
+	endian string
+}
+
+func loadRecordPackage(dir, skip string) (*recordPackage, error) {
+	pkgName, files, err := parsePackageDir(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecordFiles(pkgName, files)
+}
+
+// parsePackageDir parses the non-test files of the package in dir, except for skip, sorted by name.
+func parsePackageDir(dir, skip string) (string, []*ast.File, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(skip)
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected exactly one package in %s, found %d", dir, len(pkgs))
+	}
+	var files []*ast.File
+	var pkgName string
+	for name, p := range pkgs {
+		pkgName = name
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	// Keep the output stable regardless of map iteration order.
+	sort.Slice(files, func(i, j int) bool {
+		return fset.Position(files[i].Package).Filename < fset.Position(files[j].Package).Filename
+	})
+	return pkgName, files, nil
+}
+
+func parseRecordFiles(pkgName string, files []*ast.File) (*recordPackage, error) {
+	pkg := &recordPackage{name: pkgName, types: make(map[string]ast.Expr)}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				pkg.types[ts.Name.Name] = ts.Type
+
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				endian, ok, err := recordAnnotation(doc)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", ts.Name.Name, err)
+				}
+				if !ok {
+					continue
+				}
+				if _, isStruct := ts.Type.(*ast.StructType); !isStruct {
+					return nil, fmt.Errorf("%s: %s can only annotate struct types", ts.Name.Name, recordDirective)
+				}
+				pkg.records = append(pkg.records, recordType{name: ts.Name.Name, endian: endian})
+			}
+		}
+	}
+	if len(pkg.records) == 0 {
+		return nil, errors.New("no types annotated with " + recordDirective)
+	}
+	return pkg, nil
+}
+
+// recordAnnotation looks for the record directive in a doc comment, returning the
+// byte order it requests, if any.
+func recordAnnotation(doc *ast.CommentGroup) (endian string, ok bool, err error) {
+	if doc == nil {
+		return "", false, nil
+	}
+	for _, c := range doc.List {
+		if c.Text != recordDirective && !strings.HasPrefix(c.Text, recordDirective+" ") {
+			continue
+		}
+		for _, arg := range strings.Fields(strings.TrimPrefix(c.Text, recordDirective)) {
+			switch arg {
+			case "endian=little":
+				endian = "little"
+			case "endian=big":
+				endian = "big"
+			default:
+				return "", false, fmt.Errorf("unknown %s argument %q", recordDirective, arg)
+			}
+		}
+		return endian, true, nil
+	}
+	return "", false, nil
+}
+
+func generateRecords(pkg *recordPackage, defaultEndian string) ([]byte, error) {
+	g := &recordGenerator{pkg: pkg}
+	for _, rec := range pkg.records {
+		endian := rec.endian
+		if endian == "" {
+			endian = defaultEndian
+		}
+		if err := g.generate(rec.name, endian); err != nil {
+			return nil, fmt.Errorf("%s: %w", rec.name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by nukegen records; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg.name)
+	fmt.Fprintf(&out, "import (\n")
+	if g.usesBinary {
+		fmt.Fprintf(&out, "\t\"encoding/binary\"\n")
+	}
+	fmt.Fprintf(&out, "\t\"io\"\n")
+	if g.usesMath {
+		fmt.Fprintf(&out, "\t\"math\"\n")
+	}
+	fmt.Fprintf(&out, "\n\t\"github.com/ortuman/nuke\"\n)\n")
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+type recordGenerator struct {
+	pkg        *recordPackage
+	body       bytes.Buffer
+	usesBinary bool
+	usesMath   bool
+	order      string
+	depth      int
+	fields     int
+}
+
+func (g *recordGenerator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *recordGenerator) generate(name, endian string) error {
+	typ := g.pkg.types[name]
+	size, err := g.sizeOf(typ, nil)
+	if err != nil {
+		return err
+	}
+	g.order = "binary.LittleEndian"
+	if endian == "big" {
+		g.order = "binary.BigEndian"
+	}
+
+	sizeConst := name + "RecordSize"
+	g.printf("\n// %s is the size in bytes of the fixed-width binary encoding of %s.\n", sizeConst, name)
+	g.printf("const %s = %d\n", sizeConst, size)
+
+	g.printf("\n// Decode%sRecords decodes n fixed-width %s records from b into a slice allocated from the arena.\n", name, name)
+	g.printf("// It returns io.ErrUnexpectedEOF if b holds less than n records.\n")
+	g.printf("func Decode%sRecords(a nuke.Arena, b []byte, n int) ([]%s, error) {\n", name, name)
+	g.printf("if n < 0 || len(b)/%s < n {\nreturn nil, io.ErrUnexpectedEOF\n}\n", sizeConst)
+	g.printf("records := nuke.MakeSlice[%s](a, n, n)\n", name)
+	g.printf("for i := range records {\n")
+	g.printf("p := b[i*%s : (i+1)*%s]\n", sizeConst, sizeConst)
+	g.printf("r := &records[i]\n")
+	g.depth, g.fields = 0, 0
+	if err := g.decode("r", typ, offset{}); err != nil {
+		return err
+	}
+	if g.fields == 0 {
+		return errors.New("record has no encodable fields")
+	}
+	g.printf("}\nreturn records, nil\n}\n")
+
+	g.printf("\n// Append%sRecords appends the fixed-width binary encoding of records to dst\n", name)
+	g.printf("// and returns the extended buffer.\n")
+	g.printf("func Append%sRecords(dst []byte, records []%s) []byte {\n", name, name)
+	g.printf("for i := range records {\n")
+	g.printf("r := &records[i]\n")
+	g.depth = 0
+	if err := g.encode("r", typ); err != nil {
+		return err
+	}
+	g.printf("}\nreturn dst\n}\n")
+	return nil
+}
+
+// offset is a byte offset within an encoded record, made of a constant part and
+// the index terms of the enclosing array loops.
+type offset struct {
+	c     int
+	terms []string
+}
+
+func (o offset) add(n int) offset {
+	return offset{c: o.c + n, terms: o.terms}
+}
+
+func (o offset) index(idx string, elemSize int) offset {
+	terms := append(append([]string(nil), o.terms...), fmt.Sprintf("%s*%d", idx, elemSize))
+	return offset{c: o.c, terms: terms}
+}
+
+func (o offset) String() string {
+	if len(o.terms) == 0 {
+		return strconv.Itoa(o.c)
+	}
+	s := strings.Join(o.terms, "+")
+	if o.c != 0 {
+		s = strconv.Itoa(o.c) + "+" + s
+	}
+	return s
+}
+
+// resolve follows package-level type names until reaching a basic type or a type literal.
+func (g *recordGenerator) resolve(expr ast.Expr) (ast.Expr, error) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return expr, nil
+	}
+	if _, ok := basicTypes[ident.Name]; ok {
+		return ident, nil
+	}
+	def, ok := g.pkg.types[ident.Name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %s", ident.Name)
+	}
+	return g.resolve(def)
+}
+
+func (g *recordGenerator) sizeOf(expr ast.Expr, seen []string) (int, error) {
+	if ident, ok := expr.(*ast.Ident); ok {
+		for _, s := range seen {
+			if s == ident.Name {
+				return 0, fmt.Errorf("recursive type %s", ident.Name)
+			}
+		}
+		seen = append(seen, ident.Name)
+	}
+	def, err := g.resolve(expr)
+	if err != nil {
+		return 0, err
+	}
+	switch t := def.(type) {
+	case *ast.Ident:
+		return basicTypes[t.Name], nil
+
+	case *ast.ArrayType:
+		n, err := arrayLen(t)
+		if err != nil {
+			return 0, err
+		}
+		elemSize, err := g.sizeOf(t.Elt, seen)
+		if err != nil {
+			return 0, err
+		}
+		return n * elemSize, nil
+
+	case *ast.StructType:
+		size := 0
+		for _, field := range t.Fields.List {
+			if len(field.Names) == 0 {
+				return 0, errors.New("embedded fields are not supported")
+			}
+			fieldSize, err := g.sizeOf(field.Type, seen)
+			if err != nil {
+				return 0, err
+			}
+			size += fieldSize * len(field.Names)
+		}
+		return size, nil
+
+	default:
+		return 0, fmt.Errorf("unsupported type %s", exprString(expr))
+	}
+}
+
+func arrayLen(t *ast.ArrayType) (int, error) {
+	lit, ok := t.Len.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, fmt.Errorf("array length of %s must be an integer literal", exprString(t))
+	}
+	n, err := strconv.Atoi(lit.Value)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// basicOf returns the basic type a type expression is defined as, if any, along with the
+// name of the defined type that needs an explicit conversion from it.
+func (g *recordGenerator) basicOf(expr ast.Expr) (basic string, named string) {
+	def, _ := g.resolve(expr)
+	ident, ok := def.(*ast.Ident)
+	if !ok {
+		return "", ""
+	}
+	if orig, ok := expr.(*ast.Ident); ok && orig.Name != ident.Name {
+		named = orig.Name
+	}
+	return ident.Name, named
+}
+
+func (g *recordGenerator) decode(target string, expr ast.Expr, off offset) error {
+	if basic, named := g.basicOf(expr); basic != "" {
+		g.printf("%s = %s\n", target, g.readBasic(basic, named, off))
+		g.fields++
+		return nil
+	}
+	def, err := g.resolve(expr)
+	if err != nil {
+		return err
+	}
+	switch t := def.(type) {
+	case *ast.ArrayType:
+		if basic, named := g.basicOf(t.Elt); named == "" && (basic == "byte" || basic == "uint8") {
+			g.printf("copy(%s[:], p[%s:])\n", target, off)
+			g.fields++
+			return nil
+		}
+		elemSize, _ := g.sizeOf(t.Elt, nil)
+		idx := fmt.Sprintf("i%d", g.depth)
+		g.depth++
+		g.printf("for %s := range %s {\n", idx, target)
+		if err := g.decode(fmt.Sprintf("%s[%s]", target, idx), t.Elt, off.index(idx, elemSize)); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		g.depth--
+
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			fieldSize, _ := g.sizeOf(field.Type, nil)
+			for _, name := range field.Names {
+				if name.Name != "_" {
+					if err := g.decode(target+"."+name.Name, field.Type, off); err != nil {
+						return err
+					}
+				}
+				off = off.add(fieldSize)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *recordGenerator) readBasic(basic, named string, off offset) string {
+	var expr string
+	switch basic {
+	case "bool":
+		expr = fmt.Sprintf("p[%s] != 0", off)
+	case "byte", "uint8":
+		expr = fmt.Sprintf("p[%s]", off)
+	case "int8":
+		expr = fmt.Sprintf("int8(p[%s])", off)
+	case "uint16", "uint32", "uint64":
+		g.usesBinary = true
+		expr = fmt.Sprintf("%s.%s(p[%s:])", g.order, strings.ToTitle(basic[:1])+basic[1:], off)
+	case "int16", "int32", "rune", "int64":
+		g.usesBinary = true
+		bits := strings.TrimPrefix(basic, "int")
+		if basic == "rune" {
+			bits = "32"
+		}
+		expr = fmt.Sprintf("%s(%s.Uint%s(p[%s:]))", basic, g.order, bits, off)
+	case "float32", "float64":
+		g.usesBinary = true
+		g.usesMath = true
+		bits := strings.TrimPrefix(basic, "float")
+		expr = fmt.Sprintf("math.Float%sfrombits(%s.Uint%s(p[%s:]))", bits, g.order, bits, off)
+	}
+	if named != "" {
+		expr = fmt.Sprintf("%s(%s)", named, expr)
+	}
+	return expr
+}
+
+func (g *recordGenerator) encode(source string, expr ast.Expr) error {
+	if basic, _ := g.basicOf(expr); basic != "" {
+		g.writeBasic(source, basic)
+		return nil
+	}
+	def, err := g.resolve(expr)
+	if err != nil {
+		return err
+	}
+	switch t := def.(type) {
+	case *ast.ArrayType:
+		if basic, named := g.basicOf(t.Elt); named == "" && (basic == "byte" || basic == "uint8") {
+			g.printf("dst = append(dst, %s[:]...)\n", source)
+			return nil
+		}
+		idx := fmt.Sprintf("i%d", g.depth)
+		g.depth++
+		g.printf("for %s := range %s {\n", idx, source)
+		if err := g.encode(fmt.Sprintf("%s[%s]", source, idx), t.Elt); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		g.depth--
+
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			fieldSize, _ := g.sizeOf(field.Type, nil)
+			for _, name := range field.Names {
+				if name.Name == "_" {
+					g.printf("dst = append(dst, make([]byte, %d)...)\n", fieldSize)
+					continue
+				}
+				if err := g.encode(source+"."+name.Name, field.Type); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (g *recordGenerator) writeBasic(source, basic string) {
+	switch basic {
+	case "bool":
+		g.printf("if %s {\ndst = append(dst, 1)\n} else {\ndst = append(dst, 0)\n}\n", source)
+	case "byte", "uint8", "int8":
+		g.printf("dst = append(dst, byte(%s))\n", source)
+	case "uint16", "int16":
+		g.printf("dst = %s.AppendUint16(dst, uint16(%s))\n", g.order, source)
+	case "uint32", "int32", "rune":
+		g.printf("dst = %s.AppendUint32(dst, uint32(%s))\n", g.order, source)
+	case "uint64", "int64":
+		g.printf("dst = %s.AppendUint64(dst, uint64(%s))\n", g.order, source)
+	case "float32":
+		g.usesMath = true
+		g.printf("dst = %s.AppendUint32(dst, math.Float32bits(float32(%s)))\n", g.order, source)
+	case "float64":
+		g.usesMath = true
+		g.printf("dst = %s.AppendUint64(dst, math.Float64bits(float64(%s)))\n", g.order, source)
+	}
+	if basic != "bool" && basic != "byte" && basic != "uint8" && basic != "int8" {
+		g.usesBinary = true
+	}
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordsGeneratedCodeIsUpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "recordtest")
+	outPath := filepath.Join(dir, "nuke_records.go")
+
+	pkg, err := loadRecordPackage(dir, outPath)
+	require.NoError(t, err)
+
+	src, err := generateRecords(pkg, "little")
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(src), "run go generate ./... to refresh generated code")
+}
+
+func TestRecordsUnsupportedTypes(t *testing.T) {
+	for _, src := range []string{
+		"type R struct { A int }",
+		"type R struct { A string }",
+		"type R struct { A *uint32 }",
+		"type R struct { A []byte }",
+		"type R struct { Point }\ntype Point struct { X int32 }",
+		"type R struct { A [N]byte }\nconst N = 4",
+		"type R struct { Next T }\ntype T struct { R R }",
+		"type R struct { _ [4]byte }",
+	} {
+		pkg := parseTestRecords(t, src)
+		_, err := generateRecords(pkg, "little")
+		require.Error(t, err, src)
+	}
+}
+
+func TestRecordsAnnotation(t *testing.T) {
+	pkg := parseTestRecords(t, "type R struct { A uint16 }")
+	require.Equal(t, []recordType{{name: "R"}}, pkg.records)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n//nuke:record endian=middle\ntype R struct{}", parser.ParseComments)
+	require.NoError(t, err)
+	_, err = parseRecordFiles("p", []*ast.File{f})
+	require.Error(t, err)
+}
+
+func parseTestRecords(t *testing.T, decls string) *recordPackage {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n//nuke:record\n"+decls, parser.ParseComments)
+	require.NoError(t, err)
+	pkg, err := parseRecordFiles("p", []*ast.File{f})
+	require.NoError(t, err)
+	return pkg
+}
@@ -0,0 +1,322 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// CompactingArena is an arena whose live values can be compacted into fresh slabs, so that long-lived arenas,
+// such as those holding session state, do not accumulate the space of the values handed back by Free forever.
+// Compact moves every live value, rewriting the pointers registered with Track and invoking the callbacks
+// registered with OnRelocate, so that the application can fix up the pointers it holds otherwise.
+//
+// Slabs are backed by byte slices, which the GC does not scan, hence the arena is meant to hold POD types.
+// A CompactingArena is not safe to be accessed concurrently from multiple goroutines.
+type CompactingArena struct {
+	slabSize int
+	opts     options
+	counters arenaCounters
+	hooks    resetHooks
+
+	slabs   []*compactingSlab
+	current int               // index of the slab being allocated from
+	blocks  []compactingBlock // allocations since the last reset, in allocation order
+	index   map[uintptr]int   // index of the block starting at every address
+	dead    uint64            // number of bytes of the blocks handed back by Free
+	tracked []*unsafe.Pointer
+	moved   []func(from, to unsafe.Pointer, size uintptr)
+
+	allocatedBytes uintptr // overall size of the slabs
+}
+
+type compactingSlab struct {
+	buf    []byte
+	offset uintptr
+}
+
+type compactingBlock struct {
+	ptr   unsafe.Pointer
+	size  uintptr
+	align uintptr
+	dead  bool
+}
+
+// relocation records the move of a block by Compact.
+type relocation struct {
+	from, to unsafe.Pointer
+	size     uintptr
+}
+
+// NewCompactingArena returns a CompactingArena whose slabs are slabSize bytes long, or of the size of a single
+// allocation if larger. Slabs are allocated on demand, and WithMaxBytes bounds their overall size, beyond which
+// allocations trigger the exhaustion policy.
+func NewCompactingArena(slabSize int, opts ...Option) *CompactingArena {
+	return &CompactingArena{
+		slabSize: slabSize,
+		opts:     newOptions(opts),
+		index:    make(map[uintptr]int),
+	}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *CompactingArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *CompactingArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *CompactingArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	raceAlloc(unsafe.Pointer(a))
+	// The current slab only moves forward once an allocation fits, so that the room left in the
+	// slabs a larger allocation did not fit in is not given up on.
+	var s *compactingSlab
+	for i := a.current; i < len(a.slabs); i++ {
+		if a.slabs[i].fits(size, alignment) {
+			s, a.current = a.slabs[i], i
+			break
+		}
+	}
+	if s == nil {
+		slabSize := max(uintptr(a.slabSize), size+alignment-1)
+		if a.opts.maxBytes > 0 && a.allocatedBytes+slabSize > uintptr(a.opts.maxBytes) {
+			switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+			case ExhaustedGrow:
+				// Add a slab regardless of the limits.
+
+			case ExhaustedReturnNil:
+				return nil, false
+
+			case ExhaustedPanic:
+				panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+			default:
+				a.counters.heapFallbacks++
+				traceFallback(size)
+				return nil, true
+			}
+		}
+		s = a.newSlab(slabSize)
+	}
+	ptr := s.alloc(size, alignment)
+	a.index[uintptr(ptr)] = len(a.blocks)
+	a.blocks = append(a.blocks, compactingBlock{ptr: ptr, size: size, align: alignment})
+	a.counters.allocated(uint64(size))
+	return ptr, true
+}
+
+func (a *CompactingArena) newSlab(size uintptr) *compactingSlab {
+	traceGrowth(size)
+	s := &compactingSlab{buf: make([]byte, size)}
+	a.slabs = append(a.slabs, s)
+	a.current = len(a.slabs) - 1
+	a.allocatedBytes += size
+	return s
+}
+
+func (s *compactingSlab) fits(size, alignment uintptr) bool {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(s.buf)))
+	aligned := (base + s.offset + alignment - 1) / alignment * alignment
+	return aligned+size-base <= uintptr(len(s.buf))
+}
+
+// alloc bump allocates size bytes from the slab, which must fit them.
+func (s *compactingSlab) alloc(size, alignment uintptr) unsafe.Pointer {
+	base := unsafe.Pointer(unsafe.SliceData(s.buf))
+	pad := (alignment - (uintptr(base)+s.offset)%alignment) % alignment
+	ptr := unsafe.Add(base, s.offset+pad)
+	s.offset += pad + size
+	return ptr
+}
+
+// Free marks the value ptr points to, which must have been allocated from the arena since its last Reset,
+// as dead, so that the next Compact does not carry it over. Passing a nil pointer, or one the arena did not
+// allocate, such as the result of a heap fallback, is a no-op.
+func (a *CompactingArena) Free(ptr unsafe.Pointer) {
+	i, ok := a.index[uintptr(ptr)]
+	if !ok || a.blocks[i].dead {
+		return
+	}
+	a.blocks[i].dead = true
+	a.dead += uint64(a.blocks[i].size)
+	a.counters.bytesInUse -= uint64(a.blocks[i].size)
+}
+
+func (a *CompactingArena) free(_ reflect.Type, ptr unsafe.Pointer) {
+	a.Free(ptr)
+}
+
+// DeadBytes returns the number of bytes of the values handed back by Free, which the next Compact reclaims.
+func (a *CompactingArena) DeadBytes() uint64 {
+	return a.dead
+}
+
+// Track registers field, which holds a pointer into the arena, so that Compact rewrites it to point to the new
+// location of the value it points into. The field may itself live in the arena, in which case it is tracked
+// along with the value holding it, and dropped once that value is freed. Registrations are dropped on Reset.
+func Track[T any](a *CompactingArena, field **T) {
+	a.tracked = append(a.tracked, (*unsafe.Pointer)(unsafe.Pointer(field)))
+}
+
+// OnRelocate registers f to be invoked by Compact for every value it moves, along with its old and new address,
+// and its size. Callbacks are dropped on Reset.
+func (a *CompactingArena) OnRelocate(f func(from, to unsafe.Pointer, size uintptr)) {
+	a.moved = append(a.moved, f)
+}
+
+// Compact copies the live values into fresh slabs, in allocation order, and drops the previous ones, returning
+// the number of bytes of freed values reclaimed. Tracked pointers into moved values are rewritten, whereas those
+// into freed values are set to nil; then the relocation callbacks are invoked. Any other pointer into the arena
+// becomes invalid.
+func (a *CompactingArena) Compact() uint64 {
+	raceAlloc(unsafe.Pointer(a))
+	old := a.slabs
+	a.slabs, a.current, a.allocatedBytes = nil, 0, 0
+	moves := make([]relocation, 0, len(a.blocks))
+	blocks := a.blocks[:0]
+	clear(a.index)
+	for _, b := range a.blocks {
+		if b.dead {
+			continue
+		}
+		var slab *compactingSlab
+		if n := len(a.slabs); n > 0 && a.slabs[n-1].fits(b.size, b.align) {
+			slab = a.slabs[n-1]
+		} else {
+			slab = a.newSlab(max(uintptr(a.slabSize), b.size+b.align-1))
+		}
+		to := slab.alloc(b.size, b.align)
+		copy(unsafe.Slice((*byte)(to), b.size), unsafe.Slice((*byte)(b.ptr), b.size))
+		moves = append(moves, relocation{from: b.ptr, to: to, size: b.size})
+		a.index[uintptr(to)] = len(blocks)
+		blocks = append(blocks, compactingBlock{ptr: to, size: b.size, align: b.align})
+	}
+	clear(a.blocks[len(blocks):])
+	a.blocks = blocks
+
+	// Blocks are allocated in increasing address order within every slab, but slabs are not sorted.
+	slices.SortFunc(moves, func(x, y relocation) int {
+		return compareAddr(x.from, y.from)
+	})
+	tracked := a.tracked[:0]
+	for _, field := range a.tracked {
+		if owned(old, unsafe.Pointer(field)) {
+			to, ok := relocate(moves, unsafe.Pointer(field))
+			if !ok {
+				continue // the field belonged to a freed value
+			}
+			field = (*unsafe.Pointer)(to)
+		}
+		if owned(old, *field) {
+			*field, _ = relocate(moves, *field)
+		}
+		tracked = append(tracked, field)
+	}
+	clear(a.tracked[len(tracked):])
+	a.tracked = tracked
+	for _, m := range moves {
+		for _, f := range a.moved {
+			f(m.from, m.to, m.size)
+		}
+	}
+
+	reclaimed := a.dead
+	a.dead = 0
+	return reclaimed
+}
+
+func compareAddr(x, y unsafe.Pointer) int {
+	switch {
+	case uintptr(x) < uintptr(y):
+		return -1
+	case uintptr(x) > uintptr(y):
+		return 1
+	}
+	return 0
+}
+
+// relocate returns the new address of ptr, which points into one of the moved blocks, sorted by their old address.
+func relocate(moves []relocation, ptr unsafe.Pointer) (unsafe.Pointer, bool) {
+	i, _ := slices.BinarySearchFunc(moves, ptr, func(m relocation, p unsafe.Pointer) int {
+		return compareAddr(m.from, p)
+	})
+	if i < len(moves) && moves[i].from == ptr {
+		return moves[i].to, true
+	}
+	if i > 0 && within(moves[i-1].from, moves[i-1].size, ptr) {
+		return unsafe.Add(moves[i-1].to, uintptr(ptr)-uintptr(moves[i-1].from)), true
+	}
+	return nil, false
+}
+
+// owned reports whether ptr points into one of the slabs.
+func owned(slabs []*compactingSlab, ptr unsafe.Pointer) bool {
+	for _, s := range slabs {
+		if within(unsafe.Pointer(unsafe.SliceData(s.buf)), uintptr(len(s.buf)), ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset satisfies the Arena interface, dropping the tracked pointers and relocation callbacks.
+// Slabs are zeroed out and retained, to be allocated from again in order, unless releasing memory.
+func (a *CompactingArena) Reset(release bool) {
+	defer traceReset().End()
+	a.hooks.run()
+	for _, s := range a.slabs {
+		raceReclaim(unsafe.Pointer(unsafe.SliceData(s.buf)), s.offset)
+		clear(s.buf[:s.offset])
+		s.offset = 0
+	}
+	a.current = 0
+	if release {
+		a.slabs, a.allocatedBytes = nil, 0
+	}
+	clear(a.blocks)
+	a.blocks = a.blocks[:0]
+	clear(a.index)
+	clear(a.tracked)
+	a.tracked, a.moved, a.dead = a.tracked[:0], nil, 0
+	a.counters.reset()
+	raceReset(unsafe.Pointer(a))
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *CompactingArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *CompactingArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. Bytes in use do not account for the values handed back by Free.
+func (a *CompactingArena) Stats() Stats {
+	s := a.counters.stats()
+	s.BytesAllocated = uint64(a.allocatedBytes)
+	s.Buffers = len(a.slabs)
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *CompactingArena) Owns(ptr unsafe.Pointer) bool {
+	return owned(a.slabs, ptr)
+}
+
+func (a *CompactingArena) resetCount() uint64 {
+	return a.counters.resets
+}
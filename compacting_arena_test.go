@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type compactingNode struct {
+	value int
+	next  *compactingNode
+}
+
+func TestCompactingArena(t *testing.T) {
+	arena := NewCompactingArena(64, WithPointerPolicy(PointerAllow))
+
+	// Build a list of 8 nodes, whose links are tracked.
+	var head *compactingNode
+	Track(arena, &head)
+	nodes := make([]*compactingNode, 8)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		n := New[compactingNode](arena)
+		n.value, n.next = i, head
+		Track(arena, &n.next)
+		head, nodes[i] = n, n
+	}
+
+	// Unlink and free every other node.
+	for i := 0; i < len(nodes)-1; i += 2 {
+		nodes[i].next = nodes[i+1].next
+		arena.Free(unsafe.Pointer(nodes[i+1]))
+	}
+	arena.Free(unsafe.Pointer(nodes[1])) // already freed
+	size := uint64(unsafe.Sizeof(compactingNode{}))
+	require.Equal(t, 4*size, arena.DeadBytes())
+	before := arena.Stats()
+	require.Equal(t, 4*size, before.BytesInUse)
+
+	var moves int
+	arena.OnRelocate(func(from, to unsafe.Pointer, n uintptr) {
+		require.True(t, arena.Owns(to))
+		require.False(t, arena.Owns(from))
+		require.Equal(t, uintptr(size), n)
+		moves++
+	})
+	require.Equal(t, 4*size, arena.Compact())
+	require.Equal(t, 4, moves)
+	require.Zero(t, arena.DeadBytes())
+	require.Less(t, arena.Stats().BytesAllocated, before.BytesAllocated)
+
+	var values []int
+	for n := head; n != nil; n = n.next {
+		require.True(t, arena.Owns(unsafe.Pointer(n)))
+		values = append(values, n.value)
+	}
+	require.Equal(t, []int{0, 2, 4, 6}, values)
+
+	// Tracked pointers into freed values are cleared.
+	arena.Free(unsafe.Pointer(head.next))
+	arena.Compact()
+	require.Nil(t, head.next)
+
+	arena.Reset(false)
+	require.Zero(t, arena.Stats().BytesInUse)
+	require.Zero(t, arena.Compact())
+}
+
+func TestCompactingArenaInteriorPointers(t *testing.T) {
+	arena := NewCompactingArena(64)
+
+	dead := MakeSlice[byte](arena, 32, 32)
+	s := MakeSlice[int64](arena, 4, 4)
+	s[2] = 42
+	p := &s[2]
+	Track(arena, &p)
+	arena.Free(unsafe.Pointer(&dead[0]))
+
+	arena.Compact()
+	require.True(t, arena.Owns(unsafe.Pointer(p)))
+	require.NotSame(t, &s[2], p)
+	require.Equal(t, int64(42), *p)
+}
+
+func TestCompactingArenaReuseSlabs(t *testing.T) {
+	arena := NewCompactingArena(64)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 20; j++ {
+			_ = New[int64](arena)
+		}
+		require.Equal(t, 3, arena.Stats().Buffers)
+		require.Equal(t, uint64(3*64), arena.Stats().BytesAllocated)
+		arena.Reset(false)
+	}
+
+	arena.Reset(true)
+	require.Zero(t, arena.Stats().Buffers)
+	_ = New[int64](arena)
+	require.Equal(t, 1, arena.Stats().Buffers)
+}
+
+func TestCompactingArenaKeepsCurrentSlabOnFailure(t *testing.T) {
+	arena := NewCompactingArena(1024, WithMaxBytes(1024))
+	_ = MakeSlice[byte](arena, 100, 100)
+
+	// The allocation falls back to the heap without skipping the slab that still has room
+	b := MakeSlice[byte](arena, 4096, 4096)
+	require.False(t, arena.Owns(unsafe.Pointer(&b[0])))
+	require.True(t, arena.Owns(unsafe.Pointer(New[int64](arena))))
+	require.Equal(t, uint64(1), arena.Stats().HeapFallbacks)
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyStatsProvider is implemented by concurrent arenas able to report lock contention statistics.
+type ConcurrencyStatsProvider interface {
+	// ConcurrencyStats returns a snapshot of the arena lock contention statistics.
+	ConcurrencyStats() ConcurrencyStats
+}
+
+// ConcurrencyStats holds the lock contention statistics of a concurrent arena, which help telling whether
+// it would benefit from more shards or bigger caches.
+type ConcurrencyStats struct {
+	// LockAcquisitions is the number of times allocations acquired a lock.
+	LockAcquisitions uint64
+
+	// ContendedAcquisitions is the number of lock acquisitions that had to wait for another goroutine.
+	ContendedAcquisitions uint64
+
+	// ContentionTime is the overall time allocations spent waiting for locks.
+	ContentionTime time.Duration
+
+	// Fallovers is the number of allocations served by another shard than the preferred one, as it was contended.
+	Fallovers uint64
+
+	// ShardAllocations holds the number of allocations served by every shard.
+	ShardAllocations []uint64
+}
+
+// contentionMeter measures the contention of the locks guarding allocations.
+type contentionMeter struct {
+	acquisitions atomic.Uint64
+	contended    atomic.Uint64
+	waitNanos    atomic.Int64
+	fallovers    atomic.Uint64
+}
+
+// lock acquires mtx, measuring the time spent waiting for it if contended.
+func (m *contentionMeter) lock(mtx *sync.Mutex) {
+	if !mtx.TryLock() {
+		start := time.Now()
+		mtx.Lock()
+		m.waited(start)
+	}
+	m.acquisitions.Add(1)
+}
+
+func (m *contentionMeter) waited(start time.Time) {
+	m.contended.Add(1)
+	m.waitNanos.Add(int64(time.Since(start)))
+}
+
+func (m *contentionMeter) stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		LockAcquisitions:      m.acquisitions.Load(),
+		ContendedAcquisitions: m.contended.Load(),
+		ContentionTime:        time.Duration(m.waitNanos.Load()),
+		Fallovers:             m.fallovers.Load(),
+	}
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentArenaConcurrencyStats(t *testing.T) {
+	arena := NewConcurrentArena(NewMonotonicArena(1024, 1)).(*concurrentArena)
+	_ = New[int64](arena)
+
+	arena.mtx.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = New[int64](arena)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	arena.mtx.Unlock()
+	<-done
+
+	s := arena.ConcurrencyStats()
+	require.Equal(t, uint64(2), s.LockAcquisitions)
+	require.Equal(t, uint64(1), s.ContendedAcquisitions)
+	require.GreaterOrEqual(t, s.ContentionTime, 10*time.Millisecond)
+	require.Equal(t, []uint64{2}, s.ShardAllocations)
+}
+
+func TestShardedArenaConcurrencyStats(t *testing.T) {
+	arena := NewShardedArena(2, func() Arena { return NewMonotonicArena(1024, 1) }).(*shardedArena)
+
+	arena.shards[0].mtx.Lock()
+	for i := 0; i < 4; i++ {
+		_ = New[int64](arena)
+	}
+	arena.shards[0].mtx.Unlock()
+
+	require.Equal(t, ConcurrencyStats{
+		LockAcquisitions: 4,
+		Fallovers:        2, // half of the allocations preferred the first shard
+		ShardAllocations: []uint64{0, 4},
+	}, arena.ConcurrencyStats())
+}
@@ -3,13 +3,19 @@
 package nuke
 
 import (
+	"errors"
+	"io"
+	"reflect"
 	"sync"
 	"unsafe"
 )
 
 type concurrentArena struct {
-	mtx sync.Mutex
-	a   Arena
+	mtx    sync.Mutex
+	a      Arena
+	resets uint64
+	hooks  resetHooks
+	meter  contentionMeter
 }
 
 // NewConcurrentArena returns an arena that is safe to be accessed concurrently
@@ -20,15 +26,95 @@ func NewConcurrentArena(a Arena) Arena {
 
 // Alloc satisfies the Arena interface.
 func (a *concurrentArena) Alloc(size, alignment uintptr) unsafe.Pointer {
-	a.mtx.Lock()
+	a.meter.lock(&a.mtx)
 	ptr := a.a.Alloc(size, alignment)
 	a.mtx.Unlock()
 	return ptr
 }
 
+// AllocType satisfies the TypedArena interface.
+func (a *concurrentArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.meter.lock(&a.mtx)
+	defer a.mtx.Unlock()
+	if ta, ok := a.a.(TypedArena); ok {
+		return ta.AllocType(t, n)
+	}
+	return a.a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
 // Reset satisfies the Arena interface.
 func (a *concurrentArena) Reset(release bool) {
 	a.mtx.Lock()
+	a.hooks.run()
 	a.a.Reset(release)
+	a.resets++
+	a.mtx.Unlock()
+}
+
+// OnReset satisfies the ResetNotifier interface.
+// Callbacks are invoked while holding the arena lock, hence they must not access the arena.
+func (a *concurrentArena) OnReset(f func()) {
+	a.mtx.Lock()
+	a.hooks.add(f)
 	a.mtx.Unlock()
 }
+
+func (a *concurrentArena) watchResets(f func()) {
+	a.mtx.Lock()
+	a.hooks.watch(f)
+	a.mtx.Unlock()
+}
+
+func (a *concurrentArena) free(t reflect.Type, ptr unsafe.Pointer) {
+	a.meter.lock(&a.mtx)
+	defer a.mtx.Unlock()
+	if fa, ok := a.a.(freeingArena); ok {
+		fa.free(t, ptr)
+	}
+}
+
+func (a *concurrentArena) resetCount() uint64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.resets
+}
+
+func (a *concurrentArena) concurrencySafe() bool {
+	return true
+}
+
+// ConcurrencyStats satisfies the ConcurrencyStatsProvider interface.
+func (a *concurrentArena) ConcurrencyStats() ConcurrencyStats {
+	s := a.meter.stats()
+	s.ShardAllocations = []uint64{s.LockAcquisitions}
+	return s
+}
+
+// Stats satisfies the StatsProvider interface.
+// It returns zero statistics if the underlying arena does not implement StatsProvider.
+func (a *concurrentArena) Stats() Stats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if sp, ok := a.a.(StatsProvider); ok {
+		return sp.Stats()
+	}
+	return Stats{}
+}
+
+// Owns satisfies the Owner interface.
+func (a *concurrentArena) Owns(ptr unsafe.Pointer) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return Owns(a.a, ptr)
+}
+
+// Dump satisfies the Dumper interface, describing the underlying arena while holding the arena lock.
+// It returns errors.ErrUnsupported if the underlying arena does not implement Dumper.
+func (a *concurrentArena) Dump(w io.Writer) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if d, ok := a.a.(Dumper); ok {
+		return d.Dump(w)
+	}
+	return errors.ErrUnsupported
+}
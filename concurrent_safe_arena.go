@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"maps"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// concurrentSafeArena is a safe arena whose slab groups are locked independently, so that goroutines
+// allocating different types do not contend. Types of the same GC shape share a SafeArena serving
+// their typed slab group, whereas POD allocations are spread across a set of striped SafeArenas.
+type concurrentSafeArena struct {
+	podSlabSize int
+	opts        []Option
+
+	stripes []lockedSafeArena
+	next    atomic.Uint32
+
+	// types maps every type allocated so far to its typed arena, or nil for POD types. The map is copied on write,
+	// under mtx, so that looking types up neither locks nor allocates.
+	types atomic.Pointer[map[reflect.Type]*lockedSafeArena]
+
+	// mtx guards the creation of typed arenas, as well as the operations spanning the whole arena.
+	mtx           sync.Mutex
+	shapes        map[gcShape]*lockedSafeArena
+	highWaterMark uint64
+	resets        atomic.Uint64
+	hooks         resetHooks
+}
+
+type lockedSafeArena struct {
+	mtx sync.Mutex
+	a   *SafeArena
+}
+
+// NewConcurrentSafeArena returns a safe arena that is safe to be accessed concurrently from multiple goroutines.
+// Rather than serializing every allocation behind a single lock as NewConcurrentArena does, every typed slab group
+// is locked on its own, and POD allocations are striped across as many slab groups as GOMAXPROCS, each one
+// starting with a slab of podSlabSize bytes. The provided options apply to every slab group separately.
+func NewConcurrentSafeArena(podSlabSize int, opts ...Option) Arena {
+	a := &concurrentSafeArena{
+		podSlabSize: podSlabSize,
+		opts:        opts,
+		stripes:     make([]lockedSafeArena, runtime.GOMAXPROCS(0)),
+		shapes:      make(map[gcShape]*lockedSafeArena),
+	}
+	for i := range a.stripes {
+		a.stripes[i].a = NewSafeArena(podSlabSize, opts...)
+	}
+	return a
+}
+
+// Alloc satisfies the Arena interface.
+func (a *concurrentSafeArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	s := a.stripe()
+	defer s.mtx.Unlock()
+	return s.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *concurrentSafeArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	s := a.arenaOf(t)
+	if s == nil {
+		s = a.stripe()
+	} else {
+		s.mtx.Lock()
+	}
+	defer s.mtx.Unlock()
+	return s.a.AllocType(t, n)
+}
+
+// stripe returns a locked POD stripe, preferring those not being used by other goroutines.
+func (a *concurrentSafeArena) stripe() *lockedSafeArena {
+	first := int(a.next.Add(1))
+	for i := 0; i < len(a.stripes); i++ {
+		if s := &a.stripes[(first+i)%len(a.stripes)]; s.mtx.TryLock() {
+			return s
+		}
+	}
+	s := &a.stripes[first%len(a.stripes)]
+	s.mtx.Lock()
+	return s
+}
+
+// arenaOf returns the arena serving values of type t, or nil if t is a POD type.
+func (a *concurrentSafeArena) arenaOf(t reflect.Type) *lockedSafeArena {
+	if types := a.types.Load(); types != nil {
+		if s, ok := (*types)[t]; ok {
+			return s
+		}
+	}
+	var s *lockedSafeArena
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if hasPointers(t) {
+		shape := shapeOf(t)
+		if s = a.shapes[shape]; s == nil {
+			s = &lockedSafeArena{a: NewSafeArena(a.podSlabSize, a.opts...)}
+			a.shapes[shape] = s
+		}
+	}
+	types := map[reflect.Type]*lockedSafeArena{t: s}
+	if old := a.types.Load(); old != nil {
+		maps.Copy(types, *old)
+	}
+	a.types.Store(&types)
+	return s
+}
+
+func (a *concurrentSafeArena) free(t reflect.Type, ptr unsafe.Pointer) {
+	// POD values are handed back to whichever stripe is available, as any of them can reuse them.
+	s := a.arenaOf(t)
+	if s == nil {
+		s = a.stripe()
+	} else {
+		s.mtx.Lock()
+	}
+	defer s.mtx.Unlock()
+	s.a.free(t, ptr)
+}
+
+// Reset satisfies the Arena interface.
+func (a *concurrentSafeArena) Reset(release bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.run()
+	a.highWaterMark = max(a.highWaterMark, a.bytesInUse())
+	a.each(func(sa *SafeArena) { sa.Reset(release) })
+	a.resets.Add(1)
+}
+
+// OnReset satisfies the ResetNotifier interface.
+// Callbacks are invoked while holding the arena lock, hence they must not allocate from the arena.
+func (a *concurrentSafeArena) OnReset(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.add(f)
+}
+
+func (a *concurrentSafeArena) watchResets(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. The high water mark is sampled
+// whenever the arena is reset or its statistics are queried.
+func (a *concurrentSafeArena) Stats() Stats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var s Stats
+	a.each(func(sa *SafeArena) {
+		ss := sa.Stats()
+		s.BytesAllocated += ss.BytesAllocated
+		s.BytesInUse += ss.BytesInUse
+		s.Buffers += ss.Buffers
+		s.HeapFallbacks += ss.HeapFallbacks
+		s.PointerAllocations += ss.PointerAllocations
+	})
+	a.highWaterMark = max(a.highWaterMark, s.BytesInUse)
+	s.HighWaterMark = a.highWaterMark
+	s.Resets = a.resets.Load()
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *concurrentSafeArena) Owns(ptr unsafe.Pointer) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	var owned bool
+	a.each(func(sa *SafeArena) { owned = owned || sa.Owns(ptr) })
+	return owned
+}
+
+func (a *concurrentSafeArena) bytesInUse() uint64 {
+	var n uint64
+	a.each(func(sa *SafeArena) { n += sa.counters.bytesInUse })
+	return n
+}
+
+// each invokes f on every underlying arena, holding its lock. The caller must hold mtx.
+func (a *concurrentSafeArena) each(f func(sa *SafeArena)) {
+	for i := range a.stripes {
+		a.stripes[i].do(f)
+	}
+	for _, s := range a.shapes {
+		s.do(f)
+	}
+}
+
+func (s *lockedSafeArena) do(f func(sa *SafeArena)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	f(s.a)
+}
+
+func (a *concurrentSafeArena) resetCount() uint64 {
+	return a.resets.Load()
+}
+
+func (a *concurrentSafeArena) concurrencySafe() bool {
+	return true
+}
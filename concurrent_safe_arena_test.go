@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentSafeArena(t *testing.T) {
+	arena := NewConcurrentSafeArena(1024, WithInitialTypedSlots(16))
+
+	const goroutines, perGoroutine = 8, 200
+
+	var wg sync.WaitGroup
+	nodes := make([][]*safeTestNode, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				n := New[safeTestNode](arena)
+				n.name = strconv.Itoa(i*perGoroutine + j)
+				nodes[i] = append(nodes[i], n)
+
+				p := New[safeTestPOD](arena)
+				p.id = uint64(j)
+				_ = MakeSlice[string](arena, 0, 4)
+			}
+		}(i)
+	}
+	wg.Wait()
+	runtime.GC()
+
+	for i := range nodes {
+		for j, n := range nodes[i] {
+			require.Equal(t, strconv.Itoa(i*perGoroutine+j), n.name)
+		}
+	}
+
+	// Types of the same GC shape share an arena, whereas POD types are served by the stripes
+	ca := arena.(*concurrentSafeArena)
+	require.Len(t, ca.shapes, 2)
+	require.Len(t, ca.stripes, runtime.GOMAXPROCS(0))
+
+	stats := arena.(StatsProvider).Stats()
+	require.NotZero(t, stats.BytesInUse)
+	require.Equal(t, stats.BytesInUse, stats.HighWaterMark)
+
+	arena.Reset(false)
+	stats = arena.(StatsProvider).Stats()
+	require.Zero(t, stats.BytesInUse)
+	require.Equal(t, uint64(1), stats.Resets)
+	require.NotZero(t, stats.HighWaterMark)
+}
+
+func TestConcurrentSafeArenaFree(t *testing.T) {
+	arena := NewConcurrentSafeArena(1024, WithFreeLists())
+
+	n := New[safeTestNode](arena)
+	n.name = "nuke"
+	Free(arena, n)
+	require.Empty(t, n.name)
+	require.Same(t, n, New[safeTestNode](arena))
+}
+
+func BenchmarkConcurrentSafeArenaParallel(b *testing.B) {
+	for _, tc := range []struct {
+		name  string
+		arena Arena
+	}{
+		{name: "global-lock", arena: NewConcurrentArena(NewSafeArena(2*1024*1024, WithInitialTypedSlots(1024)))},
+		{name: "striped", arena: NewConcurrentSafeArena(2*1024*1024, WithInitialTypedSlots(1024))},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					if i%2 == 0 {
+						_ = New[safeTestNode](tc.arena)
+					} else {
+						_ = New[*int](tc.arena)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,8 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build nukedebug || race
+
+package nuke
+
+// debugChecks enables the checks of debug builds, which are too expensive otherwise.
+const debugChecks = true
@@ -0,0 +1,7 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !(nukedebug || race)
+
+package nuke
+
+const debugChecks = false
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+// Deque is a double-ended queue of values of type T stored in fixed-size chunks allocated from an arena, which
+// fits BFS frontiers, work queues and streaming windows that should die with the arena. Pushing never moves
+// the values already queued, and chunks emptied by pops are recycled, so that a queue whose length stays bounded
+// stops allocating. As with any memory allocated from the arena, the deque must not be used anymore once the arena
+// is reset. Only the small directory of chunks is allocated on the heap, which keeps the chunks the arena cannot
+// serve reachable. A Deque is not safe to be accessed concurrently from multiple goroutines.
+type Deque[T any] struct {
+	a         Arena
+	chunkSize int
+	dir       [][]T // circular directory of chunks, whose length is a power of two
+	first     int   // index of the first chunk in dir
+	chunks    int   // number of chunks in use
+	head      int   // index of the first value in the first chunk
+	len       int
+	spare     []T // emptied chunk, reused by the next push needing one
+}
+
+// NewDeque returns an empty deque allocating from a chunks of chunkSize values. If the arena is nil, chunks are
+// allocated on the heap.
+func NewDeque[T any](a Arena, chunkSize int) *Deque[T] {
+	return &Deque[T]{a: a, chunkSize: max(chunkSize, 1)}
+}
+
+// Len returns the number of values in the deque.
+func (d *Deque[T]) Len() int {
+	return d.len
+}
+
+// At returns the value at index i, counting from the front. It panics if i is out of range.
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.len {
+		panic("nuke: deque index out of range")
+	}
+	return *d.slot(i)
+}
+
+func (d *Deque[T]) slot(i int) *T {
+	i += d.head
+	return &d.dir[(d.first+i/d.chunkSize)&(len(d.dir)-1)][i%d.chunkSize]
+}
+
+// PushBack adds v at the back of the deque.
+func (d *Deque[T]) PushBack(v T) {
+	if d.head+d.len == d.chunks*d.chunkSize {
+		d.growDir()
+		d.dir[(d.first+d.chunks)&(len(d.dir)-1)] = d.newChunk()
+		d.chunks++
+	}
+	d.len++
+	*d.slot(d.len - 1) = v
+}
+
+// PushFront adds v at the front of the deque.
+func (d *Deque[T]) PushFront(v T) {
+	if d.head == 0 {
+		d.growDir()
+		d.first = (d.first - 1) & (len(d.dir) - 1)
+		d.dir[d.first] = d.newChunk()
+		d.chunks++
+		d.head = d.chunkSize
+	}
+	d.head--
+	d.len++
+	*d.slot(0) = v
+}
+
+// PopFront removes the value at the front of the deque and returns it, reporting false if the deque is empty.
+func (d *Deque[T]) PopFront() (T, bool) {
+	var zero T
+	if d.len == 0 {
+		return zero, false
+	}
+	p := d.slot(0)
+	v := *p
+	*p = zero
+	d.head++
+	d.len--
+	if d.head == d.chunkSize || d.len == 0 {
+		d.dropChunk(d.first)
+		d.first = (d.first + 1) & (len(d.dir) - 1)
+		d.head = 0
+	}
+	return v, true
+}
+
+// PopBack removes the value at the back of the deque and returns it, reporting false if the deque is empty.
+func (d *Deque[T]) PopBack() (T, bool) {
+	var zero T
+	if d.len == 0 {
+		return zero, false
+	}
+	p := d.slot(d.len - 1)
+	v := *p
+	*p = zero
+	d.len--
+	if (d.head+d.len)%d.chunkSize == 0 || d.len == 0 {
+		d.dropChunk((d.first + d.chunks - 1) & (len(d.dir) - 1))
+		if d.len == 0 {
+			d.head = 0
+		}
+	}
+	return v, true
+}
+
+// Front returns the value at the front of the deque, reporting false if the deque is empty.
+func (d *Deque[T]) Front() (T, bool) {
+	if d.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return *d.slot(0), true
+}
+
+// Back returns the value at the back of the deque, reporting false if the deque is empty.
+func (d *Deque[T]) Back() (T, bool) {
+	if d.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return *d.slot(d.len - 1), true
+}
+
+func (d *Deque[T]) newChunk() []T {
+	if c := d.spare; c != nil {
+		d.spare = nil
+		return c
+	}
+	c := MakeSlice[T](d.a, d.chunkSize, d.chunkSize)
+	if c == nil {
+		c = make([]T, d.chunkSize) // the arena is exhausted
+	}
+	return c
+}
+
+// dropChunk removes the chunk at index i of the directory, which must be the first or the last one.
+func (d *Deque[T]) dropChunk(i int) {
+	d.spare, d.dir[i] = d.dir[i], nil
+	d.chunks--
+}
+
+// growDir makes room for one more chunk in the directory, doubling it if full.
+func (d *Deque[T]) growDir() {
+	if d.chunks < len(d.dir) {
+		return
+	}
+	// The directory lives on the heap, so that the GC sees the chunks falling back to the heap.
+	n := max(2*len(d.dir), 4)
+	dir := make([][]T, n)
+	for i := 0; i < d.chunks; i++ {
+		dir[i] = d.dir[(d.first+i)&(len(d.dir)-1)]
+	}
+	d.dir, d.first = dir, 0
+}
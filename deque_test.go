@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeque(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+	d := NewDeque[int](arena, 4)
+
+	for i := 0; i < 10; i++ {
+		d.PushBack(i)
+		d.PushFront(-i - 1)
+	}
+	require.Equal(t, 20, d.Len())
+	require.Equal(t, -10, d.At(0))
+	require.Equal(t, 9, d.At(19))
+	require.True(t, Owns(arena, unsafe.Pointer(d.slot(0))))
+
+	v, ok := d.Front()
+	require.True(t, ok)
+	require.Equal(t, -10, v)
+	v, ok = d.Back()
+	require.True(t, ok)
+	require.Equal(t, 9, v)
+
+	for i := -10; i < 10; i++ {
+		v, ok := d.PopFront()
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	_, ok = d.PopFront()
+	require.False(t, ok)
+	_, ok = d.PopBack()
+	require.False(t, ok)
+	require.Panics(t, func() { d.At(0) })
+}
+
+func TestDequeRecyclesChunks(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+	d := NewDeque[int](arena, 8)
+	for i := 0; i < 8; i++ {
+		d.PushBack(i)
+	}
+	inUse := statsOf(arena).BytesInUse
+	for i := 0; i < 1000; i++ {
+		d.PushBack(i)
+		_, _ = d.PopFront()
+	}
+	require.Equal(t, inUse+8*8, statsOf(arena).BytesInUse)
+}
+
+func TestDequeHeapChunksSurviveGC(t *testing.T) {
+	arena := NewMonotonicArena(96, 1)
+	d := NewDeque[int64](arena, 4)
+	for i := int64(0); i < 16; i++ {
+		d.PushBack(i)
+	}
+	require.False(t, Owns(arena, unsafe.Pointer(d.slot(15))))
+
+	churnHeap()
+	for i := 0; i < 16; i++ {
+		require.Equal(t, int64(i), d.At(i))
+	}
+}
+
+func TestDequeMatchesSlice(t *testing.T) {
+	d := NewDeque[int](nil, 3)
+	var want []int
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		switch rnd.Intn(4) {
+		case 0:
+			d.PushBack(i)
+			want = append(want, i)
+		case 1:
+			d.PushFront(i)
+			want = append([]int{i}, want...)
+		case 2:
+			v, ok := d.PopFront()
+			require.Equal(t, len(want) > 0, ok)
+			if ok {
+				require.Equal(t, want[0], v)
+				want = want[1:]
+			}
+		case 3:
+			v, ok := d.PopBack()
+			require.Equal(t, len(want) > 0, ok)
+			if ok {
+				require.Equal(t, want[len(want)-1], v)
+				want = want[:len(want)-1]
+			}
+		}
+		require.Equal(t, len(want), d.Len())
+	}
+	for i, v := range want {
+		require.Equal(t, v, d.At(i))
+	}
+}
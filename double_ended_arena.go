@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// DoubleEndedArena is an arena allocating from both ends of a single buffer: long-lived values, such as the
+// results of a computation, are allocated from the bottom, whereas scratch values are allocated from the top
+// by means of the arena returned by Top, and popped en masse by ResetTop while the bottom persists.
+//
+// A DoubleEndedArena is not safe to be accessed concurrently from multiple goroutines.
+type DoubleEndedArena struct {
+	ptr    unsafe.Pointer
+	size   uintptr
+	bottom uintptr // offset the next bottom allocation starts at
+	top    uintptr // offset the last top allocation starts at
+	opts   options
+
+	counters arenaCounters
+	hooks    resetHooks
+}
+
+// NewDoubleEndedArena creates a new double-ended arena backed by a buffer of size bytes.
+// The arena does not grow, hence allocations not fitting the space left between both ends trigger
+// the exhaustion policy, falling back to the heap even if the policy asks for the arena to grow.
+func NewDoubleEndedArena(size int, opts ...Option) *DoubleEndedArena {
+	return &DoubleEndedArena{
+		size: uintptr(size),
+		top:  uintptr(size),
+		opts: newOptions(opts),
+	}
+}
+
+// Alloc satisfies the Arena interface, allocating from the bottom of the buffer.
+func (a *DoubleEndedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil, false)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface, allocating from the bottom of the buffer.
+func (a *DoubleEndedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	return a.allocType(t, n, false)
+}
+
+func (a *DoubleEndedArena) allocType(t reflect.Type, n int, top bool) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t, top)
+}
+
+func (a *DoubleEndedArena) alloc(size, alignment uintptr, t reflect.Type, top bool) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	if a.ptr == nil {
+		buf := make([]byte, a.size) // allocate the buffer lazily
+		a.ptr = unsafe.Pointer(unsafe.SliceData(buf))
+	}
+	base := uintptr(a.ptr)
+	if top {
+		// Top allocations grow downwards, hence aligning them rounds their start down.
+		if end := base + a.top; end >= size && end-size >= base+a.bottom {
+			if start := (end - size) &^ (alignment - 1); start >= base+a.bottom {
+				a.counters.allocated(uint64(end - start))
+				a.top = start - base
+				return unsafe.Add(a.ptr, a.top), true
+			}
+		}
+	} else {
+		start := (base + a.bottom + alignment - 1) &^ (alignment - 1)
+		if end := start + size; end <= base+a.top {
+			a.counters.allocated(uint64(end - base - a.bottom))
+			a.bottom = end - base
+			return unsafe.Add(a.ptr, start-base), true
+		}
+	}
+	switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+	case ExhaustedReturnNil:
+		return nil, false
+
+	case ExhaustedPanic:
+		panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+	default:
+		a.counters.heapFallbacks++
+		traceFallback(size)
+		return nil, true
+	}
+}
+
+// Top returns an arena allocating from the top of the buffer, whose Reset method pops every top allocation
+// as ResetTop does, leaving the bottom untouched.
+func (a *DoubleEndedArena) Top() Arena {
+	return doubleEndedTop{a: a}
+}
+
+// ResetTop pops every allocation made from the top of the buffer since the last reset.
+// After invoking this method any pointer previously returned by the top arena becomes immediately invalid.
+func (a *DoubleEndedArena) ResetTop() {
+	if a.ptr == nil || a.top == a.size {
+		return
+	}
+	clear(unsafe.Slice((*byte)(unsafe.Add(a.ptr, a.top)), a.size-a.top))
+	a.counters.bytesInUse -= uint64(a.size - a.top)
+	a.top = a.size
+}
+
+// Reset satisfies the Arena interface, resetting both ends of the buffer.
+func (a *DoubleEndedArena) Reset(release bool) {
+	defer traceReset().End()
+	a.hooks.run()
+	if release {
+		a.ptr = nil
+	} else if a.ptr != nil {
+		clear(unsafe.Slice((*byte)(a.ptr), a.bottom))
+		a.ResetTop()
+	}
+	a.bottom, a.top = 0, a.size
+	a.counters.reset()
+}
+
+// OnReset satisfies the ResetNotifier interface. Callbacks are only invoked by Reset, not by ResetTop.
+func (a *DoubleEndedArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *DoubleEndedArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. BytesInUse accounts for both ends of the buffer.
+func (a *DoubleEndedArena) Stats() Stats {
+	s := a.counters.stats()
+	s.Buffers = 1
+	if a.ptr != nil {
+		s.BytesAllocated = uint64(a.size)
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *DoubleEndedArena) Owns(ptr unsafe.Pointer) bool {
+	return within(a.ptr, a.size, ptr)
+}
+
+func (a *DoubleEndedArena) resetCount() uint64 {
+	return a.counters.resets
+}
+
+// doubleEndedTop is the arena allocating from the top of a DoubleEndedArena.
+type doubleEndedTop struct {
+	a *DoubleEndedArena
+}
+
+// Alloc satisfies the Arena interface.
+func (t doubleEndedTop) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := t.a.alloc(size, alignment, nil, true)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (t doubleEndedTop) AllocType(typ reflect.Type, n int) (unsafe.Pointer, bool) {
+	return t.a.allocType(typ, n, true)
+}
+
+// Reset satisfies the Arena interface. It pops every top allocation regardless of release.
+func (t doubleEndedTop) Reset(bool) {
+	t.a.ResetTop()
+}
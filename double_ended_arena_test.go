@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoubleEndedArenaAllocatesFromBothEnds(t *testing.T) {
+	arena := NewDoubleEndedArena(128)
+	top := arena.Top()
+
+	b := New[int64](arena)
+	s := MakeSlice[int32](top, 4, 4)
+	require.Equal(t, uintptr(arena.ptr), uintptr(unsafe.Pointer(b)))
+	require.Equal(t, uintptr(arena.ptr)+128-16, uintptr(unsafe.Pointer(&s[0])))
+
+	// Top allocations grow downwards, aligned for their type.
+	_ = New[byte](top)
+	i := New[int64](top)
+	require.Equal(t, uintptr(arena.ptr)+128-32, uintptr(unsafe.Pointer(i)))
+	require.Equal(t, uint64(8+32), arena.Stats().BytesInUse)
+
+	// Both ends meet.
+	_ = MakeSlice[byte](arena, 96, 96)
+	require.Equal(t, uint64(1), arena.Stats().HeapFallbacks)
+	_ = MakeSlice[byte](top, 96, 96)
+	require.Equal(t, uint64(2), arena.Stats().HeapFallbacks)
+	_ = MakeSlice[byte](arena, 88, 88)
+	require.Equal(t, arena.bottom, arena.top)
+}
+
+func TestDoubleEndedArenaResetTop(t *testing.T) {
+	arena := NewDoubleEndedArena(64)
+	top := arena.Top()
+
+	b := New[int64](arena)
+	*b = 42
+	s := MakeSlice[byte](top, 8, 8)
+	copy(s, "deadbeef")
+
+	top.Reset(false)
+	require.Equal(t, int64(42), *b)
+	require.Equal(t, make([]byte, 8), s)
+	require.Equal(t, uint64(8), arena.Stats().BytesInUse)
+
+	// The whole buffer is available to the top again.
+	require.NotNil(t, MakeSlice[byte](top, 56, 56))
+
+	arena.Reset(false)
+	require.Zero(t, *b)
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		Buffers:        1,
+		HighWaterMark:  64,
+		Resets:         1,
+	}, arena.Stats())
+}
+
+func TestDoubleEndedArenaExhausted(t *testing.T) {
+	arena := NewDoubleEndedArena(16, WithStrictMode())
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() {
+		_ = MakeSlice[byte](arena.Top(), 32, 32)
+	})
+	requirePanicsWithErrorIs(t, ErrPointerType, func() {
+		_ = New[*int](arena)
+	})
+}
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// EpochArena wraps an arena shared by concurrent goroutines, preventing it from being reset while any of them
+// still holds pointers to its memory. Goroutines Retain the arena before allocating from it and Release it once
+// done with the memory they allocated, whereas Reset is deferred until every goroutine has released the arena.
+type EpochArena struct {
+	a Arena
+
+	mtx     sync.Mutex
+	cond    sync.Cond
+	refs    int
+	pending bool // a Reset is waiting for the references to be released
+	release bool // whether the pending Reset releases memory
+	resets  uint64
+	hooks   resetHooks
+}
+
+// NewEpochArena returns an epoch arena wrapping a, which must be safe to be accessed concurrently
+// from multiple goroutines, such as those returned by NewConcurrentArena or NewLockFreeArena.
+func NewEpochArena(a Arena) *EpochArena {
+	ea := &EpochArena{a: a}
+	ea.cond.L = &ea.mtx
+	return ea
+}
+
+// Retain acquires a reference to the arena, preventing it from being reset until the reference is released.
+// If a Reset is pending, Retain blocks until it completes, so that the references acquired after Reset
+// was invoked belong to the next epoch.
+func (a *EpochArena) Retain() {
+	a.mtx.Lock()
+	for a.pending {
+		a.cond.Wait()
+	}
+	a.refs++
+	a.mtx.Unlock()
+}
+
+// Release releases a reference acquired by Retain. Releasing the last reference
+// performs the pending Reset, if any.
+func (a *EpochArena) Release() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.refs == 0 {
+		panic("nuke: epoch arena released more times than retained")
+	}
+	if a.refs--; a.refs == 0 && a.pending {
+		a.reset(a.release)
+	}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *EpochArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *EpochArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if ta, ok := a.a.(TypedArena); ok {
+		return ta.AllocType(t, n)
+	}
+	return a.a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+// Reset satisfies the Arena interface. If any reference is held, the arena is reset once the last one
+// is released rather than right away, releasing memory if any of the deferred calls asked to.
+func (a *EpochArena) Reset(release bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.refs > 0 {
+		a.pending = true
+		a.release = a.release || release
+		return
+	}
+	a.reset(release)
+}
+
+// reset resets the underlying arena, waking up the goroutines waiting to retain it. The caller must hold mtx.
+func (a *EpochArena) reset(release bool) {
+	a.hooks.run()
+	a.a.Reset(release)
+	a.resets++
+	a.pending, a.release = false, false
+	a.cond.Broadcast()
+}
+
+// Pending reports whether a Reset is waiting for the references to the arena to be released.
+func (a *EpochArena) Pending() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.pending
+}
+
+// OnReset satisfies the ResetNotifier interface.
+// Callbacks are invoked while holding the arena lock, hence they must not access the arena.
+func (a *EpochArena) OnReset(f func()) {
+	a.mtx.Lock()
+	a.hooks.add(f)
+	a.mtx.Unlock()
+}
+
+func (a *EpochArena) watchResets(f func()) {
+	a.mtx.Lock()
+	a.hooks.watch(f)
+	a.mtx.Unlock()
+}
+
+// Stats satisfies the StatsProvider interface.
+// It returns zero statistics if the underlying arena does not implement StatsProvider.
+func (a *EpochArena) Stats() Stats {
+	if sp, ok := a.a.(StatsProvider); ok {
+		return sp.Stats()
+	}
+	return Stats{}
+}
+
+// Owns satisfies the Owner interface.
+func (a *EpochArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *EpochArena) resetCount() uint64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.resets
+}
+
+func (a *EpochArena) concurrencySafe() bool {
+	return true
+}
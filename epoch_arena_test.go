@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpochArenaDefersReset(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewEpochArena(NewConcurrentArena(NewMonotonicArena(1024, 1)))
+
+	arena.Retain()
+	arena.Retain()
+	v := New[int](arena)
+	*v = 42
+
+	arena.Reset(false)
+	require.True(t, arena.Pending())
+	require.Equal(t, 42, *v)
+
+	arena.Release()
+	require.Equal(t, 42, *v)
+
+	// Releasing the last reference performs the pending reset.
+	arena.Release()
+	require.False(t, arena.Pending())
+	require.Zero(t, *v)
+	require.Equal(t, uint64(1), arena.Stats().Resets)
+
+	// Without references, the arena is reset right away.
+	arena.Reset(true)
+	require.Equal(t, uint64(0), arena.Stats().BytesAllocated)
+	require.Panics(t, arena.Release)
+}
+
+func TestEpochArenaRetainWaitsForPendingReset(t *testing.T) {
+	arena := NewEpochArena(NewConcurrentArena(NewMonotonicArena(1024, 1)))
+
+	arena.Retain()
+	arena.Reset(false)
+
+	var wg sync.WaitGroup
+	retained := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		arena.Retain()
+		close(retained)
+		arena.Release()
+	}()
+
+	select {
+	case <-retained:
+		t.Fatal("arena retained while a reset is pending")
+	case <-time.After(10 * time.Millisecond):
+	}
+	arena.Release()
+	wg.Wait()
+	require.Equal(t, uint64(1), arena.Stats().Resets)
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "errors"
+
+var (
+	// ErrArenaExhausted is the error a strict arena panics with when it cannot satisfy an allocation.
+	ErrArenaExhausted = errors.New("nuke: arena exhausted")
+
+	// ErrPointerType is the error a strict arena panics with when a type containing pointers is allocated from it.
+	ErrPointerType = errors.New("nuke: type contains pointers")
+
+	// ErrRingOverwrite is the error a guarded RingArena panics with when an allocation would overwrite memory
+	// that has not been released yet.
+	ErrRingOverwrite = errors.New("nuke: ring arena overwrite")
+
+	// ErrResetRace is the error a CheckedArena panics with when Reset races with an allocation,
+	// or is invoked while pointers to its memory are still marked as live.
+	ErrResetRace = errors.New("nuke: reset racing with arena use")
+
+	// ErrArenaClosed is the error closable arenas, such as CheckedArena, panic with when used after being closed,
+	// and the one Close returns when closing them again.
+	ErrArenaClosed = errors.New("nuke: arena closed")
+
+	// ErrInvalidArenaFile is the error OpenFileArena returns when the file is not an arena file, or is corrupted.
+	ErrInvalidArenaFile = errors.New("nuke: invalid arena file")
+
+	// ErrArenaSealed is the error a sealed arena panics with when allocated from.
+	ErrArenaSealed = errors.New("nuke: arena sealed")
+
+	// ErrLiveSnapshots is the error a SnapshotArena panics with when reset while snapshots of it are live,
+	// and the one Unseal returns in that case.
+	ErrLiveSnapshots = errors.New("nuke: arena has live snapshots")
+
+	// ErrUseAfterReset is the error a Ptr panics with when dereferenced after its arena has been reset.
+	ErrUseAfterReset = errors.New("nuke: use after reset")
+
+	// ErrCanaryCorrupted is the error a GuardedArena panics with when a write overflowing an allocation
+	// has overwritten the guard bytes following it.
+	ErrCanaryCorrupted = errors.New("nuke: canary corrupted")
+
+	// ErrInvalidFree is the error a BuddyArena panics with when handed back memory it did not allocate,
+	// or that has already been freed.
+	ErrInvalidFree = errors.New("nuke: invalid free")
+)
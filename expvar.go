@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// PublishExpvar publishes the statistics of the arena as an expvar variable with the given name, which is
+// rendered as a JSON object holding the fields of Stats. As the variable is read from the goroutines serving
+// expvar, the arena must be safe for concurrent use, such as those returned by NewConcurrentArena, otherwise
+// PublishExpvar panics. Like expvar.Publish, it panics if the name is already in use as well.
+func PublishExpvar(name string, a Arena) {
+	if !isConcurrencySafe(a) {
+		panic(fmt.Sprintf("nuke: arena %T published as %q is not safe for concurrent use", a, name))
+	}
+	expvar.Publish(name, expvar.Func(func() any { return statsOf(a) }))
+}
+
+// PublishRegistryExpvar publishes the statistics of every registered arena as an expvar variable with the given
+// name, which is rendered as a JSON object mapping registered names to the statistics of their arenas.
+// Arenas registered after publishing the variable are reported as well, and Register guarantees that every one
+// of them is safe for concurrent use.
+func PublishRegistryExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		registry.mtx.RLock()
+		defer registry.mtx.RUnlock()
+		stats := make(map[string]Stats, len(registry.arenas))
+		for name, a := range registry.arenas {
+			stats[name] = statsOf(a)
+		}
+		return stats
+	}))
+}
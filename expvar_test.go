@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	arena := NewConcurrentArena(NewMonotonicArena(1024, 1))
+	PublishExpvar("nuke-test-arena", arena)
+	_ = New[int64](arena)
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("nuke-test-arena").String()), &stats))
+	require.Equal(t, arena.(StatsProvider).Stats(), stats)
+	require.Equal(t, uint64(8), stats.BytesInUse)
+
+	require.Panics(t, func() { PublishExpvar("nuke-test-unsafe", NewMonotonicArena(1024, 1)) })
+	require.Nil(t, expvar.Get("nuke-test-unsafe"))
+}
+
+func TestPublishRegistryExpvar(t *testing.T) {
+	PublishRegistryExpvar("nuke-test-registry")
+
+	arena := NewConcurrentArena(NewMonotonicArena(1024, 1))
+	Register("expvar-arena", arena)
+	defer Unregister("expvar-arena")
+	arena.Reset(false)
+
+	var stats map[string]Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("nuke-test-registry").String()), &stats))
+	require.Equal(t, uint64(1), stats["expvar-arena"].Resets)
+}
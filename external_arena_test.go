@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke_test
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetArena is an arena implemented outside the package, which refuses allocations
+// once its budget of values is spent.
+type budgetArena struct {
+	budget int
+	types  []reflect.Type
+}
+
+func (a *budgetArena) Alloc(size, _ uintptr) unsafe.Pointer {
+	return unsafe.Pointer(unsafe.SliceData(make([]byte, size)))
+}
+
+func (a *budgetArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.types = append(a.types, t)
+	if n > a.budget {
+		return nil, false
+	}
+	a.budget -= n
+	return reflect.New(reflect.ArrayOf(n, t)).UnsafePointer(), false
+}
+
+func (a *budgetArena) Reset(bool) {}
+
+// heapArena is a plain arena implemented outside the package, which never satisfies an allocation.
+type heapArena struct{}
+
+func (heapArena) Alloc(uintptr, uintptr) unsafe.Pointer { return nil }
+func (heapArena) Reset(bool)                            {}
+
+func TestExternalTypedArena(t *testing.T) {
+	arena := &budgetArena{budget: 4}
+
+	require.NotNil(t, nuke.New[int](arena))
+	require.Len(t, nuke.MakeSlice[string](arena, 3, 3), 3)
+
+	// Budget exhausted and heap fallback refused
+	require.Nil(t, nuke.New[int](arena))
+	require.Equal(t, []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(""), reflect.TypeOf(0)}, arena.types)
+
+	// The concurrent wrapper forwards typed allocations
+	require.Nil(t, nuke.New[int](nuke.NewConcurrentArena(arena)))
+	require.Len(t, arena.types, 4)
+}
+
+func TestExternalArenaHeapFallback(t *testing.T) {
+	var arena heapArena
+
+	p := nuke.New[int](arena)
+	require.NotNil(t, p)
+	require.Equal(t, 0, *p)
+	require.Len(t, nuke.MakeSlice[int](arena, 2, 8), 2)
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+const (
+	// fileMagic identifies arena files.
+	fileMagic = "nukefile"
+
+	// fileHeaderSize is the size of the header preceding the data of an arena file, which leaves room for
+	// future fields while keeping the data cache line aligned.
+	fileHeaderSize = 64
+)
+
+// fileHeader is the header of an arena file, stored at its start.
+type fileHeader struct {
+	magic [8]byte
+	used  uint64 // number of bytes allocated, as of the last Sync
+	root  uint64 // offset of the root value plus one, or zero if unset
+}
+
+// FileArena is a monotonic arena bump allocating from a file mapped in memory, so that POD data built once can be
+// flushed to disk with Sync and reloaded instantly by reopening the file. As the file may be mapped at a different
+// address every time it is opened, the data must not hold pointers, not even to memory allocated from the arena:
+// values should instead refer to one another by offsets, as returned by Offset. The root value, from which the
+// rest of the data can be reached, is found through Root.
+type FileArena struct {
+	*monotonicArena
+	f      *os.File
+	data   []byte
+	header *fileHeader
+	closed bool
+}
+
+// OpenFileArena opens the arena file at path, creating it if it does not exist, and maps it in memory. The file is
+// grown, if needed, so that at least size bytes of data can be allocated from it. Allocations made before the last
+// Sync of a previous arena on the same file are preserved. The arena never touches the Go heap: it does not grow
+// beyond the file, and exhausting it makes New and MakeSlice return nil. Allocating types containing pointers
+// panics with ErrPointerType, regardless of WithPointerPolicy. Close must be invoked before discarding the arena.
+// Mapping files is only supported on the platforms reported by MmapSupported, except Windows, elsewhere
+// errors.ErrUnsupported is returned.
+func OpenFileArena(path string, size int, opts ...Option) (*FileArena, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	a, err := openFileArena(f, size, opts)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func openFileArena(f *os.File, size int, opts []Option) (*FileArena, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	existing := fi.Size()
+	if existing > 0 && existing < fileHeaderSize {
+		return nil, fmt.Errorf("%w: %s is too short", ErrInvalidArenaFile, f.Name())
+	}
+	total := max(existing, int64(fileHeaderSize+size))
+	if existing < total {
+		if err := f.Truncate(total); err != nil {
+			return nil, err
+		}
+	}
+	data, err := mmapFile(f, int(total))
+	if err != nil {
+		return nil, err
+	}
+	h := (*fileHeader)(unsafe.Pointer(unsafe.SliceData(data)))
+	capacity := uint64(total - fileHeaderSize)
+	switch {
+	case existing == 0:
+		copy(h.magic[:], fileMagic)
+	case string(h.magic[:]) != fileMagic:
+		err = fmt.Errorf("%w: %s lacks the arena file signature", ErrInvalidArenaFile, f.Name())
+	case h.used > capacity || h.root > h.used:
+		err = fmt.Errorf("%w: %s holds offsets beyond its end", ErrInvalidArenaFile, f.Name())
+	}
+	if err != nil {
+		return nil, errors.Join(err, munmapFile(data))
+	}
+
+	a := newFixedArena(unsafe.Pointer(&data[fileHeaderSize]), int(capacity),
+		newOptions(append(slices.Clip(opts), WithPointerPolicy(PointerPanic))))
+	a.external = true
+	a.buffers[0].offset = uintptr(h.used)
+	a.counters.allocated(h.used)
+	return &FileArena{monotonicArena: a, f: f, data: data, header: h}, nil
+}
+
+// Alloc satisfies the Arena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *FileArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.checkOpen()
+	return a.monotonicArena.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *FileArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.checkOpen()
+	return a.monotonicArena.AllocType(t, n)
+}
+
+// Reset satisfies the Arena interface, zeroing out the data of the file and unsetting the root value, which
+// reaches the disk on the next Sync. It panics with ErrArenaClosed once the arena is closed.
+func (a *FileArena) Reset(release bool) {
+	a.checkOpen()
+	a.monotonicArena.Reset(release)
+	a.header.used, a.header.root = 0, 0
+}
+
+// Offset returns the offset of ptr within the data of the arena, which remains valid across reopenings of the
+// file. It panics if ptr does not point into the arena.
+func (a *FileArena) Offset(ptr unsafe.Pointer) uint64 {
+	if !a.Owns(ptr) {
+		panic(fmt.Sprintf("nuke: %p does not point into the arena file", ptr))
+	}
+	return uint64(uintptr(ptr) - uintptr(a.buffers[0].ptr))
+}
+
+// At returns a pointer to the data at the given offset, as returned by Offset.
+func (a *FileArena) At(offset uint64) unsafe.Pointer {
+	return unsafe.Add(a.buffers[0].ptr, offset)
+}
+
+// SetRoot records ptr as the root value of the arena, which Root returns once the file is reopened.
+// It panics if ptr does not point into the arena.
+func (a *FileArena) SetRoot(ptr unsafe.Pointer) {
+	a.header.root = a.Offset(ptr) + 1
+}
+
+// Root returns the root value of the arena, or nil if it is unset.
+func (a *FileArena) Root() unsafe.Pointer {
+	if a.header.root == 0 {
+		return nil
+	}
+	return a.At(a.header.root - 1)
+}
+
+// Sync flushes the data allocated from the arena to the file, so that it is preserved once reopened.
+func (a *FileArena) Sync() error {
+	a.checkOpen()
+	a.header.used = uint64(a.buffers[0].offset)
+	return msyncFile(a.data)
+}
+
+// Close flushes the arena to the file, then unmaps and closes it, invalidating the memory allocated from the arena.
+// Further use of the arena panics, and closing it again returns ErrArenaClosed.
+func (a *FileArena) Close() error {
+	if a.closed {
+		return ErrArenaClosed
+	}
+	err := a.Sync()
+	a.closed = true
+	return errors.Join(err, munmapFile(a.data), a.f.Close())
+}
+
+func (a *FileArena) checkOpen() {
+	if a.closed {
+		panic(fmt.Errorf("%w: %T used after Close", ErrArenaClosed, a))
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fileIndex struct {
+	keys    uint64 // offset of the keys
+	numKeys int
+}
+
+func openTestFileArena(t *testing.T, path string, size int, opts ...Option) *FileArena {
+	t.Helper()
+	a, err := OpenFileArena(path, size, opts...)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("mapping files is not supported on this platform")
+	}
+	require.NoError(t, err)
+	return a
+}
+
+func TestFileArenaPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+
+	a := openTestFileArena(t, path, 4096)
+	require.Nil(t, a.Root())
+	keys := MakeSlice[uint64](a, 100, 100)
+	for i := range keys {
+		keys[i] = uint64(i * i)
+	}
+	idx := New[fileIndex](a)
+	idx.keys, idx.numKeys = a.Offset(unsafe.Pointer(&keys[0])), len(keys)
+	a.SetRoot(unsafe.Pointer(idx))
+	require.NoError(t, a.Close())
+	require.ErrorIs(t, a.Close(), ErrArenaClosed)
+	require.PanicsWithError(t, "nuke: arena closed: *nuke.FileArena used after Close", func() { New[int](a) })
+
+	// Reopening the file with a bigger size grows it, preserving the data.
+	a = openTestFileArena(t, path, 8192)
+	defer a.Close()
+	idx = (*fileIndex)(a.Root())
+	require.NotNil(t, idx)
+	keys = unsafe.Slice((*uint64)(a.At(idx.keys)), idx.numKeys)
+	for i := range keys {
+		require.Equal(t, uint64(i*i), keys[i])
+	}
+	require.Equal(t, uint64(816), a.Stats().BytesInUse)
+	require.Equal(t, uint64(8192), a.Stats().BytesAllocated)
+
+	// Allocations resume after the preserved data.
+	require.Equal(t, uint64(816), a.Offset(unsafe.Pointer(New[uint64](a))))
+	require.Nil(t, MakeSlice[byte](a, 8192, 8192))
+	require.Panics(t, func() { New[*int](a) })
+
+	a.Reset(false)
+	require.Nil(t, a.Root())
+	require.Zero(t, keys[99])
+}
+
+func TestFileArenaUnsyncedAllocations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+
+	a := openTestFileArena(t, path, 4096)
+	*New[uint64](a) = 1
+	require.NoError(t, a.Sync())
+	*New[uint64](a) = 2
+	require.NoError(t, a.Close())
+
+	a = openTestFileArena(t, path, 4096)
+	defer a.Close()
+	require.Equal(t, uint64(16), a.Stats().BytesInUse)
+}
+
+func TestFileArenaPointerPolicy(t *testing.T) {
+	// Pointers are meaningless once the file is mapped again, hence they cannot be allowed.
+	a := openTestFileArena(t, filepath.Join(t.TempDir(), "index"), 4096, WithPointerPolicy(PointerAllow))
+	defer a.Close()
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { New[*int](a) })
+}
+
+func TestFileArenaInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	require.NoError(t, os.WriteFile(path, make([]byte, 128), 0o644))
+	if _, err := OpenFileArena(path, 4096); !errors.Is(err, errors.ErrUnsupported) {
+		require.ErrorIs(t, err, ErrInvalidArenaFile)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("nukefile"), 0o644))
+	_, err := OpenFileArena(path, 4096)
+	require.ErrorIs(t, err, ErrInvalidArenaFile)
+}
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "unsafe"
+
+// NewFixedArena returns a monotonic arena bump allocating from buf, which allows serving allocations from memory
+// the caller owns, such as a scratch array, an mmap region or memory obtained through cgo. The buffer is never
+// released, and it is zeroed out whenever the arena is reset. Memory not allocated by the Go runtime is not scanned
+// by the garbage collector, hence it must only hold POD types. Once buf is full, allocations trigger the exhaustion
+// policy, and growing the arena appends buffers allocated from the heap.
+func NewFixedArena(buf []byte, opts ...Option) Arena {
+	return newFixedArena(unsafe.Pointer(unsafe.SliceData(buf)), len(buf), newOptions(opts))
+}
+
+// NewExternalArena returns a monotonic arena bump allocating from the size bytes starting at ptr, a memory region
+// the caller owns and keeps alive for as long as the arena is used, such as memory obtained from C, a device driver
+// or a pre-mapped region. Unlike NewFixedArena, the arena never touches the Go heap: it does not grow, oversized
+// allocations are served from the region like any other, and exhausting it makes New and MakeSlice return nil
+// rather than falling back to the heap, unless strict mode or the exhaustion policy make it panic. As the garbage
+// collector does not scan the region, allocating types containing pointers panics with ErrPointerType, unless
+// WithPointerPolicy states otherwise.
+func NewExternalArena(ptr unsafe.Pointer, size int, opts ...Option) Arena {
+	a := newFixedArena(ptr, size, newOptions(append([]Option{WithPointerPolicy(PointerPanic)}, opts...)))
+	a.external = true
+	return a
+}
+
+func newFixedArena(ptr unsafe.Pointer, size int, opts options) *monotonicArena {
+	a := newMonotonicArena(size, 0, opts)
+	a.buffers = append(a.buffers, &monotonicBuffer{
+		ptr:    ptr,
+		base:   ptr,
+		size:   uintptr(size),
+		fixed:  true,
+		lazy:   a.opts.lazyZeroing || a.opts.poison,
+		poison: a.opts.poison,
+	})
+	a.size = uintptr(size)
+	return a
+}
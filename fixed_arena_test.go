@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedArenaAllocatesFromBuffer(t *testing.T) {
+	buf := make([]byte, 64)
+	arena := NewFixedArena(buf)
+
+	i := New[int64](arena)
+	*i = -1
+	require.True(t, uintptr(unsafe.Pointer(i)) >= uintptr(unsafe.Pointer(&buf[0])))
+	require.True(t, uintptr(unsafe.Pointer(i)) < uintptr(unsafe.Pointer(&buf[0]))+uintptr(len(buf)))
+
+	// The arena does not grow beyond the buffer.
+	require.False(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(MakeSlice[byte](arena, 64, 64)))))
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		BytesInUse:     8,
+		Buffers:        1,
+		HighWaterMark:  8,
+		HeapFallbacks:  1,
+	}, arena.(StatsProvider).Stats())
+}
+
+func TestFixedArenaReset(t *testing.T) {
+	buf := make([]byte, 64)
+	arena := NewFixedArena(buf)
+
+	s := MakeSlice[byte](arena, 8, 8)
+	copy(s, "deadbeef")
+
+	// The buffer is zeroed out, yet retained, when releasing memory.
+	arena.Reset(true)
+	require.Equal(t, make([]byte, 64), buf)
+
+	s = MakeSlice[byte](arena, 8, 8)
+	require.Equal(t, unsafe.Pointer(&buf[0]), unsafe.Pointer(&s[0]))
+}
+
+func TestFixedArenaEmptyBuffer(t *testing.T) {
+	arena := NewFixedArena(nil, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	require.Nil(t, New[int](arena))
+}
+
+var externalMemory [64]byte
+
+func TestExternalArena(t *testing.T) {
+	arena := NewExternalArena(unsafe.Pointer(&externalMemory), len(externalMemory), WithGrowOnDemand(), WithOversizedThreshold(8))
+	t.Cleanup(func() { arena.Reset(true) })
+
+	// Oversized allocations are served from the region, and exhausting it neither grows the arena nor falls back.
+	s := MakeSlice[byte](arena, 16, 16)
+	copy(s, "deadbeef")
+	require.Equal(t, unsafe.Pointer(&externalMemory), unsafe.Pointer(unsafe.SliceData(s)))
+	require.NotNil(t, MakeSlice[byte](arena, 48, 48))
+	require.Nil(t, New[int64](arena))
+	require.Nil(t, MakeSlice[byte](arena, 1, 1))
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		BytesInUse:     64,
+		Buffers:        1,
+		HighWaterMark:  64,
+	}, arena.(StatsProvider).Stats())
+
+	require.PanicsWithError(t, "nuke: type contains pointers: *int", func() { New[*int](arena) })
+
+	arena.Reset(true)
+	require.Equal(t, [64]byte{}, externalMemory)
+	require.True(t, Owns(arena, unsafe.Pointer(New[int64](arena))))
+}
+
+func TestExternalArenaStrictMode(t *testing.T) {
+	var mem [8]byte
+	arena := NewExternalArena(unsafe.Pointer(&mem), len(mem), WithStrictMode())
+
+	New[int64](arena)
+	require.PanicsWithError(t, "nuke: arena exhausted: unable to allocate 8 bytes", func() { New[int64](arena) })
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "fmt"
+
+// Graph is a directed graph in compressed sparse row form, whose adjacency arrays are laid out contiguously in
+// an arena, as built by a GraphBuilder. Nodes are identified by their index.
+type Graph struct {
+	offsets []uint32 // offset of the first edge of every node, followed by the number of edges
+	edges   []uint32 // target of every edge, grouped by source node
+}
+
+// GraphBuilder builds a Graph in two passes, so that every adjacency array is allocated once, with its exact size:
+// the edges are first counted by means of Count, then filled in by means of Fill, in any order. This suits
+// per-query graph construction in routing and dependency-resolution services, as the graph dies with the arena.
+type GraphBuilder struct {
+	a       Arena
+	offsets []uint32
+	cursors []uint32 // offset of the next edge to fill of every node, once filling
+	edges   []uint32
+	filled  int
+}
+
+// NewGraphBuilder returns a builder of a graph of the given number of nodes, allocating from a.
+func NewGraphBuilder(a Arena, nodes int) *GraphBuilder {
+	return &GraphBuilder{a: a, offsets: makeCounts(a, nodes+1)}
+}
+
+// makeCounts allocates n integers from the arena, or from the heap if it is exhausted.
+func makeCounts(a Arena, n int) []uint32 {
+	if s := MakeSlice[uint32](a, n, n); s != nil {
+		return s
+	}
+	return make([]uint32, n)
+}
+
+// Count accounts for an edge from the given node, in the first pass. It panics once filling has started.
+func (b *GraphBuilder) Count(from uint32) {
+	if b.cursors != nil {
+		panic("nuke: graph edges counted after filling started")
+	}
+	b.offsets[from+1]++
+}
+
+// Fill adds the edge from the given node to the given one, in the second pass. It panics if more edges are
+// filled in for the source node than were counted.
+func (b *GraphBuilder) Fill(from, to uint32) {
+	if b.cursors == nil {
+		b.startFilling()
+	}
+	c := b.cursors[from]
+	if c == b.offsets[from+1] {
+		panic(fmt.Sprintf("nuke: more edges filled in for node %d than counted", from))
+	}
+	b.edges[c] = to
+	b.cursors[from]++
+	b.filled++
+}
+
+// startFilling turns the edge counts into offsets, and allocates the adjacency arrays.
+func (b *GraphBuilder) startFilling() {
+	n := len(b.offsets) - 1
+	for i := 1; i <= n; i++ {
+		b.offsets[i] += b.offsets[i-1]
+	}
+	b.cursors = makeCounts(b.a, n)
+	copy(b.cursors, b.offsets[:n])
+	b.edges = makeCounts(b.a, int(b.offsets[n]))
+}
+
+// Build returns the graph. It panics unless every counted edge has been filled in.
+func (b *GraphBuilder) Build() *Graph {
+	if b.cursors == nil {
+		b.startFilling()
+	}
+	if b.filled != len(b.edges) {
+		panic(fmt.Sprintf("nuke: %d graph edges counted, but %d filled in", len(b.edges), b.filled))
+	}
+	return &Graph{offsets: b.offsets, edges: b.edges}
+}
+
+// Nodes returns the number of nodes of the graph.
+func (g *Graph) Nodes() int {
+	return len(g.offsets) - 1
+}
+
+// Edges returns the number of edges of the graph.
+func (g *Graph) Edges() int {
+	return len(g.edges)
+}
+
+// Neighbors returns the targets of the edges from the given node, in the order they were filled in.
+// The returned slice must not be modified.
+func (g *Graph) Neighbors(node uint32) []uint32 {
+	return g.edges[g.offsets[node]:g.offsets[node+1]:g.offsets[node+1]]
+}
+
+// Degree returns the number of edges from the given node.
+func (g *Graph) Degree(node uint32) int {
+	return int(g.offsets[node+1] - g.offsets[node])
+}
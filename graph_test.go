@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphBuilder(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+	edges := [][2]uint32{{0, 1}, {0, 2}, {2, 0}, {3, 2}, {0, 3}}
+
+	b := NewGraphBuilder(arena, 4)
+	for _, e := range edges {
+		b.Count(e[0])
+	}
+	for _, e := range edges {
+		b.Fill(e[0], e[1])
+	}
+	require.Panics(t, func() { b.Count(1) })
+	require.Panics(t, func() { b.Fill(1, 0) })
+
+	g := b.Build()
+	require.Equal(t, 4, g.Nodes())
+	require.Equal(t, 5, g.Edges())
+	require.Equal(t, []uint32{1, 2, 3}, g.Neighbors(0))
+	require.Empty(t, g.Neighbors(1))
+	require.Equal(t, []uint32{0}, g.Neighbors(2))
+	require.Equal(t, 1, g.Degree(3))
+	require.True(t, Owns(arena, unsafe.Pointer(&g.Neighbors(0)[0])))
+}
+
+func TestGraphBuilderMissingEdges(t *testing.T) {
+	b := NewGraphBuilder(nil, 2)
+	b.Count(0)
+	b.Count(1)
+	b.Fill(1, 0)
+	require.PanicsWithValue(t, "nuke: 2 graph edges counted, but 1 filled in", func() { b.Build() })
+}
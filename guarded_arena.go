@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	// canarySize is the minimum number of guard bytes a GuardedArena places after every allocation.
+	canarySize = 8
+
+	// canaryByte is the value the guard bytes following pointer-free allocations are filled with.
+	// Guard bytes following values holding pointers are left zeroed, as the GC scans them.
+	canaryByte = 0xCA
+)
+
+// GuardedArena is a debugging wrapper detecting writes overflowing the bounds of the values allocated from
+// an arena, which otherwise silently corrupt adjacent values. It places guard bytes, known as canaries, after
+// every allocation, and verifies them on Reset, panicking with ErrCanaryCorrupted along with the type, size
+// and tag of every value that overflowed them. Allocations coming through a Tagged arena are reported along
+// with its tag.
+//
+// The wrapper is safe for concurrent use if the wrapped arena is.
+type GuardedArena struct {
+	a      Arena
+	resets atomic.Uint64
+
+	mtx    sync.Mutex
+	guards []canary
+}
+
+// canary describes the guard bytes following an allocation.
+type canary struct {
+	ptr  unsafe.Pointer // start of the guard bytes
+	size uintptr        // number of guard bytes
+	zero bool           // whether the guard bytes are zeroed rather than filled with canaryByte
+
+	t   reflect.Type // type of the allocated values, or nil for untyped allocations
+	n   int          // number of allocated values, or bytes for untyped allocations
+	tag string
+}
+
+// NewGuardedArena returns a guarded arena wrapping a.
+func NewGuardedArena(a Arena) *GuardedArena {
+	return &GuardedArena{a: a}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *GuardedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.allocTagged(nil, size, alignment, 1, "")
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *GuardedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	return a.allocTagged(t, t.Size(), uintptr(t.Align()), n, "")
+}
+
+func (a *GuardedArena) allocTagged(t reflect.Type, size, alignment uintptr, n int, tag string) (unsafe.Pointer, bool) {
+	c := canary{t: t, n: n, tag: tag}
+	var ptr unsafe.Pointer
+	var fallback bool
+	switch {
+	case t == nil:
+		c.n, c.size = int(size), canarySize
+		ptr, fallback = a.allocParent(nil, size+canarySize, alignment, 1, tag)
+	case size == 0:
+		return a.allocParent(t, size, alignment, n, tag)
+	default:
+		// Values are padded with enough extra values to hold the guard bytes, so that typed arenas
+		// keep serving them from memory of the right type.
+		extra := int((canarySize + size - 1) / size)
+		c.size, c.zero = uintptr(extra)*size, hasPointers(t)
+		ptr, fallback = a.allocParent(t, size, alignment, n+extra, tag)
+	}
+	if ptr == nil {
+		return nil, fallback
+	}
+
+	c.ptr = unsafe.Add(ptr, size*uintptr(n))
+	if !c.zero {
+		guard := unsafe.Slice((*byte)(c.ptr), c.size)
+		for i := range guard {
+			guard[i] = canaryByte
+		}
+	}
+	a.mtx.Lock()
+	a.guards = append(a.guards, c)
+	a.mtx.Unlock()
+	return ptr, false
+}
+
+func (a *GuardedArena) allocParent(t reflect.Type, size, alignment uintptr, n int, tag string) (unsafe.Pointer, bool) {
+	if ta, ok := a.a.(tagAllocator); ok && tag != "" {
+		return ta.allocTagged(t, size, alignment, n, tag)
+	}
+	if t == nil {
+		return a.a.Alloc(size, alignment), true
+	}
+	return allocType(a.a, t, n)
+}
+
+// Verify checks the guard bytes of every allocation made since the last Reset, returning an error wrapping
+// ErrCanaryCorrupted that describes the values that overflowed them, if any.
+func (a *GuardedArena) Verify() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var b strings.Builder
+	var corrupted int
+	for _, c := range a.guards {
+		off, ok := c.check()
+		if ok {
+			continue
+		}
+		corrupted++
+		b.WriteString("\n\t")
+		if c.t == nil {
+			fmt.Fprintf(&b, "%d bytes", c.n)
+		} else {
+			fmt.Fprintf(&b, "[%d]%s (%d bytes)", c.n, c.t, uintptr(c.n)*c.t.Size())
+		}
+		if c.tag != "" {
+			fmt.Fprintf(&b, " tagged %q", c.tag)
+		}
+		fmt.Fprintf(&b, " overflowed up to byte %d past its end", off+1)
+	}
+	if corrupted > 0 {
+		return fmt.Errorf("%w: %d allocations overflowed%s", ErrCanaryCorrupted, corrupted, b.String())
+	}
+	return nil
+}
+
+// check returns the offset of the last guard byte that was overwritten, if any.
+func (c canary) check() (uintptr, bool) {
+	guard := unsafe.Slice((*byte)(c.ptr), c.size)
+	want := byte(canaryByte)
+	if c.zero {
+		want = 0
+	}
+	for i := len(guard) - 1; i >= 0; i-- {
+		if guard[i] != want {
+			return uintptr(i), false
+		}
+	}
+	return 0, true
+}
+
+// Reset satisfies the Arena interface. It panics if the guard bytes of any allocation have been overwritten,
+// in which case the wrapped arena is not reset.
+func (a *GuardedArena) Reset(release bool) {
+	if err := a.Verify(); err != nil {
+		panic(err)
+	}
+	a.mtx.Lock()
+	clear(a.guards)
+	a.guards = a.guards[:0]
+	a.mtx.Unlock()
+	a.a.Reset(release)
+	a.resets.Add(1)
+}
+
+// Stats satisfies the StatsProvider interface.
+// It returns zero statistics if the wrapped arena does not implement StatsProvider.
+func (a *GuardedArena) Stats() Stats {
+	return statsOf(a.a)
+}
+
+// Owns satisfies the Owner interface.
+func (a *GuardedArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *GuardedArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.resets)
+}
+
+func (a *GuardedArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardedArena(t *testing.T) {
+	arena := NewGuardedArena(NewMonotonicArena(1024, 1))
+
+	s := MakeSlice[byte](arena, 10, 10)
+	i := New[int64](arena)
+	*i = 42
+	copy(s, "0123456789")
+	require.NoError(t, arena.Verify())
+	require.Equal(t, uint64(40), arena.Stats().BytesInUse) // 10 bytes and their guard, padding, an int64 and its guard
+
+	// Writes past the end of the slice clobber the canaries rather than the next value
+	s = unsafe.Slice(&s[0], 12)
+	s[11] = 'x'
+	require.Equal(t, int64(42), *i)
+	require.EqualError(t, arena.Verify(), "nuke: canary corrupted: 1 allocations overflowed\n\t[10]uint8 (10 bytes) overflowed up to byte 2 past its end")
+	requirePanicsWithErrorIs(t, ErrCanaryCorrupted, func() { arena.Reset(false) })
+
+	s[11] = canaryByte
+	arena.Reset(false)
+	require.Zero(t, arena.Stats().BytesInUse)
+}
+
+func TestGuardedArenaPointers(t *testing.T) {
+	arena := NewGuardedArena(NewSafeArena(1024))
+
+	n := MakeSlice[safeTestNode](arena, 2, 2)
+	n = unsafe.Slice(&n[0], 3)
+	n[2].name = "overflow"
+	err := arena.Verify()
+	require.ErrorIs(t, err, ErrCanaryCorrupted)
+	require.Contains(t, err.Error(), "[2]nuke.safeTestNode")
+}
+
+func TestGuardedArenaTagged(t *testing.T) {
+	arena := NewGuardedArena(NewMonotonicArena(1024, 1))
+
+	p := (*[16]byte)(Tagged(arena, "parser").Alloc(12, 1))
+	p[12] = 1
+	require.EqualError(t, arena.Verify(), "nuke: canary corrupted: 1 allocations overflowed\n\t12 bytes tagged \"parser\" overflowed up to byte 1 past its end")
+}
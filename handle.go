@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+// Handle is a stable reference to a value of a HandleArena, made of the index of its slot and the generation of
+// the slot at the time the value was created. Unlike a pointer, a handle whose value has been removed, or whose
+// arena has been reset, is detected as such rather than aliasing whichever value reuses the slot.
+// The zero Handle refers to no value.
+type Handle[T any] struct {
+	index uint32
+	gen   uint32
+}
+
+// IsZero reports whether h is the zero Handle.
+func (h Handle[T]) IsZero() bool {
+	return h.gen == 0
+}
+
+// HandleArena holds values of type T allocated from an arena in chunks of slots, handing out a Handle for each
+// of them. Slots are reused once their value is removed, bumping their generation, so that stale handles can be
+// told apart. Every handle is invalidated whenever the arena is reset.
+//
+// Resets are only detected when the arena can report them, as every arena provided by this package does, wrappers
+// reporting those of the arena they wrap or, failing that, the resets made through them. A HandleArena is not safe
+// to be accessed concurrently from multiple goroutines.
+type HandleArena[T any] struct {
+	a         Arena
+	chunkSize int
+	chunks    [][]T
+	gens      []uint32 // generation of every slot, which is odd while the slot holds a value
+	free      []uint32
+	live      int
+	resets    uint64
+}
+
+// NewHandleArena returns a HandleArena allocating its values from the provided arena, chunkSize slots at a time.
+func NewHandleArena[T any](a Arena, chunkSize int) *HandleArena[T] {
+	h := &HandleArena[T]{a: a, chunkSize: max(chunkSize, 1)}
+	h.sync()
+	return h
+}
+
+// New creates a zeroed value, returning both its handle and a pointer to it. The pointer remains valid until
+// the value is removed or the arena is reset.
+func (h *HandleArena[T]) New() (Handle[T], *T) {
+	h.sync()
+	var index uint32
+	if n := len(h.free); n > 0 {
+		index = h.free[n-1]
+		h.free = h.free[:n-1]
+	} else {
+		index = uint32(len(h.gens))
+		h.gens = append(h.gens, 0)
+	}
+	for int(index)/h.chunkSize >= len(h.chunks) {
+		chunk := MakeSlice[T](h.a, h.chunkSize, h.chunkSize)
+		if chunk == nil {
+			chunk = make([]T, h.chunkSize) // the arena is exhausted
+		}
+		h.chunks = append(h.chunks, chunk)
+	}
+	h.gens[index]++
+	h.live++
+	return Handle[T]{index: index, gen: h.gens[index]}, h.slot(index)
+}
+
+// Get returns a pointer to the value h refers to, or nil if it has been removed or the arena has been reset.
+func (h *HandleArena[T]) Get(handle Handle[T]) *T {
+	if !h.Valid(handle) {
+		return nil
+	}
+	return h.slot(handle.index)
+}
+
+// Valid reports whether the value h refers to still exists.
+func (h *HandleArena[T]) Valid(handle Handle[T]) bool {
+	h.sync()
+	return int(handle.index) < len(h.gens) && handle.gen != 0 && h.gens[handle.index] == handle.gen
+}
+
+// Remove removes the value h refers to, making its slot available for reuse, and reports whether it existed.
+func (h *HandleArena[T]) Remove(handle Handle[T]) bool {
+	if !h.Valid(handle) {
+		return false
+	}
+	var zero T
+	*h.slot(handle.index) = zero // drop the references the value holds
+	h.gens[handle.index]++
+	h.free = append(h.free, handle.index)
+	h.live--
+	return true
+}
+
+// Len returns the number of values held.
+func (h *HandleArena[T]) Len() int {
+	h.sync()
+	return h.live
+}
+
+func (h *HandleArena[T]) slot(index uint32) *T {
+	return &h.chunks[int(index)/h.chunkSize][int(index)%h.chunkSize]
+}
+
+// sync invalidates every handle, and drops the chunks of slots, if the arena has been reset since the last call.
+// Slot generations are retained, so that handles created before the reset remain invalid once slots are reused.
+func (h *HandleArena[T]) sync() {
+	rc, ok := h.a.(resetCounter)
+	if !ok {
+		return
+	}
+	resets := rc.resetCount()
+	if resets == h.resets {
+		return
+	}
+	h.resets = resets
+	clear(h.chunks)
+	h.chunks = h.chunks[:0]
+	h.free = h.free[:0]
+	for i := range h.gens {
+		if h.gens[i]%2 == 1 {
+			h.gens[i]++
+		}
+	}
+	// Slots are handed out again in index order, as chunks are allocated anew.
+	for i := len(h.gens) - 1; i >= 0; i-- {
+		h.free = append(h.free, uint32(i))
+	}
+	h.live = 0
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type handleTestEntity struct {
+	name string
+	hp   int
+}
+
+func TestHandleArena(t *testing.T) {
+	arena := NewSafeArena(1024)
+	entities := NewHandleArena[handleTestEntity](arena, 2)
+
+	h1, e1 := entities.New()
+	e1.name = "orc"
+	h2, _ := entities.New()
+	h3, e3 := entities.New() // allocates a second chunk
+	e3.hp = 3
+	require.Equal(t, 3, entities.Len())
+	require.Same(t, e1, entities.Get(h1))
+	require.Equal(t, 3, entities.Get(h3).hp)
+
+	// Removed values are detected, even once their slot is reused.
+	require.True(t, entities.Remove(h1))
+	require.False(t, entities.Remove(h1))
+	require.Nil(t, entities.Get(h1))
+	require.Equal(t, handleTestEntity{}, *e1)
+	h4, e4 := entities.New()
+	require.Same(t, e1, e4)
+	require.NotEqual(t, h1, h4)
+	require.Nil(t, entities.Get(h1))
+	require.Same(t, e4, entities.Get(h4))
+	require.Equal(t, 3, entities.Len())
+
+	require.True(t, Handle[handleTestEntity]{}.IsZero())
+	require.False(t, h2.IsZero())
+	require.Nil(t, entities.Get(Handle[handleTestEntity]{}))
+}
+
+func TestHandleArenaReset(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	entities := NewHandleArena[int](arena, 4)
+
+	h1, p1 := entities.New()
+	*p1 = 1
+	h2, _ := entities.New()
+
+	// Resetting the arena invalidates every handle, including once slots are handed out again.
+	arena.Reset(false)
+	require.Zero(t, entities.Len())
+	require.False(t, entities.Valid(h1))
+	h3, p3 := entities.New()
+	require.Zero(t, *p3)
+	require.Nil(t, entities.Get(h1))
+	require.Nil(t, entities.Get(h2))
+	require.Same(t, p3, entities.Get(h3))
+	require.Equal(t, 1, entities.Len())
+}
+
+func TestHandleArenaExhausted(t *testing.T) {
+	arena := NewMonotonicArena(8, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	entities := NewHandleArena[int64](arena, 4)
+
+	// Chunks are allocated on the heap once the arena is exhausted.
+	h, p := entities.New()
+	*p = 42
+	require.Equal(t, int64(42), *entities.Get(h))
+}
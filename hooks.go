@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+// ResetNotifier is implemented by arenas able to run callbacks when they are reset.
+type ResetNotifier interface {
+	// OnReset registers f to be invoked the next time the arena is reset, before its memory is reclaimed,
+	// so that values allocated from the arena can still be accessed. Callbacks are invoked in LIFO order,
+	// and must not allocate from the arena.
+	OnReset(f func())
+}
+
+// OnReset registers f to be invoked the next time the arena is reset, which is typically used to release
+// non-memory resources owned by values allocated from the arena, such as file descriptors or cgo handles.
+// It reports false if the arena does not implement ResetNotifier, in which case f is never invoked.
+func OnReset(a Arena, f func()) bool {
+	if rn, ok := a.(ResetNotifier); ok {
+		rn.OnReset(f)
+		return true
+	}
+	return false
+}
+
+// resetHooks holds the callbacks registered through OnReset, along with those watching every reset.
+type resetHooks struct {
+	once  []func()
+	every []func()
+}
+
+func (h *resetHooks) add(f func()) {
+	h.once = append(h.once, f)
+}
+
+func (h *resetHooks) watch(f func()) {
+	h.every = append(h.every, f)
+}
+
+// run invokes and unregisters every callback in LIFO order, including those registered by the callbacks themselves,
+// and then invokes the watching callbacks.
+func (h *resetHooks) run() {
+	for n := len(h.once); n > 0; n = len(h.once) {
+		f := h.once[n-1]
+		h.once[n-1] = nil
+		h.once = h.once[:n-1]
+		f()
+	}
+	for _, f := range h.every {
+		f()
+	}
+}
+
+// resetWatcher is implemented by arenas able to invoke a callback on every Reset rather than only the next one.
+// Callbacks are invoked as those registered through OnReset are, hence they must not access the arena.
+type resetWatcher interface {
+	watchResets(f func())
+}
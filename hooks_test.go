@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnResetRunsInLIFOOrder(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []int
+			for i := 0; i < 3; i++ {
+				i := i
+				require.True(t, OnReset(tc.arena, func() { calls = append(calls, i) }))
+			}
+			tc.arena.Reset(false)
+			require.Equal(t, []int{2, 1, 0}, calls)
+
+			// callbacks are unregistered once invoked
+			tc.arena.Reset(true)
+			require.Equal(t, []int{2, 1, 0}, calls)
+		})
+	}
+}
+
+func TestOnResetRunsBeforeMemoryIsReclaimed(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewMonotonicArena(1024, 1)
+	v := New[int](arena)
+	*v = 42
+
+	var seen int
+	OnReset(arena, func() { seen = *v })
+	arena.Reset(false)
+	require.Equal(t, 42, seen)
+	require.Zero(t, *v)
+}
+
+func TestOnResetNestedRegistration(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+
+	var calls []string
+	OnReset(arena, func() { calls = append(calls, "outer") })
+	OnReset(arena, func() {
+		calls = append(calls, "first")
+		OnReset(arena, func() { calls = append(calls, "nested") })
+	})
+	arena.Reset(false)
+	require.Equal(t, []string{"first", "nested", "outer"}, calls)
+}
+
+func TestOnResetUnsupportedArena(t *testing.T) {
+	require.False(t, OnReset(nil, func() {}))
+}
+
+func TestWatchResets(t *testing.T) {
+	for _, tc := range warmedArenas() {
+		t.Run(tc.name, func(t *testing.T) {
+			rw, ok := tc.arena.(resetWatcher)
+			require.True(t, ok)
+
+			var calls []string
+			rw.watchResets(func() { calls = append(calls, "watch") })
+			OnReset(tc.arena, func() { calls = append(calls, "once") })
+			tc.arena.Reset(false)
+			tc.arena.Reset(true)
+			require.Equal(t, []string{"once", "watch", "watch"}, calls)
+		})
+	}
+}
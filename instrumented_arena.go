@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Instrumentation holds the callbacks an instrumented arena invokes, any of which can be nil.
+// Callbacks are invoked synchronously, hence they should be lightweight, and must be safe for
+// concurrent use if the arena is.
+type Instrumentation struct {
+	// OnAlloc is invoked on every allocation with its size in bytes and the allocated type,
+	// which is nil for the allocations requested through Alloc.
+	OnAlloc func(size uintptr, t reflect.Type)
+
+	// OnReset is invoked on every Reset with the number of bytes reclaimed, namely those the arena
+	// reports to be in use right before being reset, or zero if it does not implement StatsProvider.
+	OnReset func(reclaimed uint64)
+}
+
+type instrumentedArena struct {
+	a      Arena
+	in     Instrumentation
+	resets atomic.Uint64
+}
+
+// NewInstrumentedArena returns an arena wrapping a that invokes the provided callbacks on every allocation and
+// Reset, which is the building block for custom metrics, quotas and tracing.
+func NewInstrumentedArena(a Arena, in Instrumentation) Arena {
+	return &instrumentedArena{a: a, in: in}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *instrumentedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	if a.in.OnAlloc != nil {
+		a.in.OnAlloc(size, nil)
+	}
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *instrumentedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if a.in.OnAlloc != nil {
+		a.in.OnAlloc(t.Size()*uintptr(n), t)
+	}
+	return allocType(a.a, t, n)
+}
+
+// Reset satisfies the Arena interface.
+func (a *instrumentedArena) Reset(release bool) {
+	if a.in.OnReset == nil {
+		a.a.Reset(release)
+		a.resets.Add(1)
+		return
+	}
+	reclaimed := statsOf(a.a).BytesInUse
+	a.a.Reset(release)
+	a.resets.Add(1)
+	a.in.OnReset(reclaimed)
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *instrumentedArena) Stats() Stats {
+	return statsOf(a.a)
+}
+
+// Owns satisfies the Owner interface.
+func (a *instrumentedArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *instrumentedArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.resets)
+}
+
+func (a *instrumentedArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedArena(t *testing.T) {
+	type alloc struct {
+		size uintptr
+		t    reflect.Type
+	}
+	var allocs []alloc
+	var reclaimed []uint64
+	arena := NewInstrumentedArena(NewMonotonicArena(1024, 1), Instrumentation{
+		OnAlloc: func(size uintptr, t reflect.Type) { allocs = append(allocs, alloc{size, t}) },
+		OnReset: func(n uint64) { reclaimed = append(reclaimed, n) },
+	})
+
+	_ = New[allocTestStruct](arena)
+	_ = MakeSlice[int32](arena, 0, 3)
+	_ = arena.Alloc(5, 1)
+	require.Equal(t, []alloc{
+		{32, reflect.TypeOf(allocTestStruct{})},
+		{12, reflect.TypeOf(int32(0))},
+		{5, nil},
+	}, allocs)
+
+	arena.Reset(false)
+	arena.Reset(false)
+	require.Equal(t, []uint64{49, 0}, reclaimed)
+	require.Equal(t, uint64(2), arena.(StatsProvider).Stats().Resets)
+}
+
+func TestInstrumentedArenaNilCallbacks(t *testing.T) {
+	arena := NewInstrumentedArena(NewMonotonicArena(1024, 1), Instrumentation{})
+	require.NotNil(t, New[int](arena))
+	arena.Reset(false)
+}
@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "unsafe"
+
+// List is a doubly linked list of values of type T whose nodes are allocated from an arena, which allows building
+// queues and graphs of POD nodes without allocating every node on the heap. As with any memory allocated from the
+// arena, the nodes become invalid once it is reset, hence the list must not be used anymore. The nodes the arena
+// cannot serve are allocated on the heap and kept reachable by the list, whereas values pointing to the heap must
+// only be stored from arenas whose memory the GC scans, such as safe arenas, as for any other value holding pointers.
+type List[T any] struct {
+	a          Arena
+	head, tail *ListNode[T]
+	len        int
+	heap       heapRoots // nodes allocated on the heap
+}
+
+// ListNode is a node of a List.
+type ListNode[T any] struct {
+	Value      T
+	next, prev *ListNode[T]
+	list       *List[T]
+}
+
+// NewList returns an empty list allocating its nodes from a. If the arena is nil, nodes are allocated on the heap.
+func NewList[T any](a Arena) *List[T] {
+	return &List[T]{a: a}
+}
+
+// Next returns the node following n, or nil if n is the last one.
+func (n *ListNode[T]) Next() *ListNode[T] {
+	return n.next
+}
+
+// Prev returns the node preceding n, or nil if n is the first one.
+func (n *ListNode[T]) Prev() *ListNode[T] {
+	return n.prev
+}
+
+// Len returns the number of nodes of the list.
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Front returns the first node of the list, or nil if it is empty.
+func (l *List[T]) Front() *ListNode[T] {
+	return l.head
+}
+
+// Back returns the last node of the list, or nil if it is empty.
+func (l *List[T]) Back() *ListNode[T] {
+	return l.tail
+}
+
+// PushFront inserts a node holding v at the front of the list and returns it.
+func (l *List[T]) PushFront(v T) *ListNode[T] {
+	return l.insert(v, nil, l.head)
+}
+
+// PushBack inserts a node holding v at the back of the list and returns it.
+func (l *List[T]) PushBack(v T) *ListNode[T] {
+	return l.insert(v, l.tail, nil)
+}
+
+// InsertAfter inserts a node holding v right after mark, which must be a node of the list, and returns it.
+func (l *List[T]) InsertAfter(v T, mark *ListNode[T]) *ListNode[T] {
+	l.check(mark)
+	return l.insert(v, mark, mark.next)
+}
+
+// InsertBefore inserts a node holding v right before mark, which must be a node of the list, and returns it.
+func (l *List[T]) InsertBefore(v T, mark *ListNode[T]) *ListNode[T] {
+	l.check(mark)
+	return l.insert(v, mark.prev, mark)
+}
+
+func (l *List[T]) insert(v T, prev, next *ListNode[T]) *ListNode[T] {
+	n := New[ListNode[T]](l.a)
+	if n == nil {
+		n = new(ListNode[T]) // the arena is exhausted
+	}
+	l.heap.keep(l.a, unsafe.Pointer(n))
+	n.Value, n.prev, n.next, n.list = v, prev, next, l
+	if prev != nil {
+		prev.next = n
+	} else {
+		l.head = n
+	}
+	if next != nil {
+		next.prev = n
+	} else {
+		l.tail = n
+	}
+	l.len++
+	return n
+}
+
+// Remove unlinks n, which must be a node of the list, handing its memory back to the arena, which reuses it if it
+// recycles values, as Free does, and returns its value. After invoking this method n becomes immediately invalid.
+func (l *List[T]) Remove(n *ListNode[T]) T {
+	l.check(n)
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		l.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		l.tail = n.prev
+	}
+	l.len--
+	v := n.Value
+	*n = ListNode[T]{}
+	l.heap.drop(unsafe.Pointer(n))
+	Free(l.a, n)
+	return v
+}
+
+func (l *List[T]) check(n *ListNode[T]) {
+	if n.list != l {
+		panic("nuke: node does not belong to the list")
+	}
+}
+
+// SList is a singly linked list of values of type T whose nodes are allocated from an arena, which fits stacks and
+// FIFO queues, having one pointer less per node than a List. As with any memory allocated from the arena, the nodes
+// become invalid once it is reset, hence the list must not be used anymore. As with a List, the nodes the arena
+// cannot serve are kept reachable by the list.
+type SList[T any] struct {
+	a          Arena
+	head, tail *SListNode[T]
+	len        int
+	heap       heapRoots // nodes allocated on the heap
+}
+
+// SListNode is a node of a SList.
+type SListNode[T any] struct {
+	Value T
+	next  *SListNode[T]
+}
+
+// NewSList returns an empty singly linked list allocating its nodes from a. If the arena is nil, nodes are
+// allocated on the heap.
+func NewSList[T any](a Arena) *SList[T] {
+	return &SList[T]{a: a}
+}
+
+// Next returns the node following n, or nil if n is the last one.
+func (n *SListNode[T]) Next() *SListNode[T] {
+	return n.next
+}
+
+// Len returns the number of nodes of the list.
+func (l *SList[T]) Len() int {
+	return l.len
+}
+
+// Front returns the first node of the list, or nil if it is empty.
+func (l *SList[T]) Front() *SListNode[T] {
+	return l.head
+}
+
+// Back returns the last node of the list, or nil if it is empty.
+func (l *SList[T]) Back() *SListNode[T] {
+	return l.tail
+}
+
+// PushFront inserts a node holding v at the front of the list and returns it.
+func (l *SList[T]) PushFront(v T) *SListNode[T] {
+	n := l.newNode(v)
+	n.next = l.head
+	l.head = n
+	if l.tail == nil {
+		l.tail = n
+	}
+	return n
+}
+
+// PushBack inserts a node holding v at the back of the list and returns it.
+func (l *SList[T]) PushBack(v T) *SListNode[T] {
+	n := l.newNode(v)
+	if l.tail != nil {
+		l.tail.next = n
+	} else {
+		l.head = n
+	}
+	l.tail = n
+	return n
+}
+
+func (l *SList[T]) newNode(v T) *SListNode[T] {
+	n := New[SListNode[T]](l.a)
+	if n == nil {
+		n = new(SListNode[T]) // the arena is exhausted
+	}
+	l.heap.keep(l.a, unsafe.Pointer(n))
+	n.Value = v
+	l.len++
+	return n
+}
+
+// PopFront removes the first node of the list, handing its memory back to the arena, which reuses it if it
+// recycles values, as Free does, and returns its value. It reports false if the list is empty.
+func (l *SList[T]) PopFront() (T, bool) {
+	n := l.head
+	if n == nil {
+		var zero T
+		return zero, false
+	}
+	l.head = n.next
+	if l.head == nil {
+		l.tail = nil
+	}
+	l.len--
+	v := n.Value
+	*n = SListNode[T]{}
+	l.heap.drop(unsafe.Pointer(n))
+	Free(l.a, n)
+	return v, true
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listValues[T any](l *List[T]) []T {
+	var values []T
+	for n := l.Front(); n != nil; n = n.Next() {
+		values = append(values, n.Value)
+	}
+	return values
+}
+
+func TestList(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	l := NewList[int](arena)
+
+	two := l.PushBack(2)
+	l.PushFront(0)
+	l.InsertBefore(1, two)
+	four := l.PushBack(4)
+	l.InsertAfter(3, two)
+	require.Equal(t, []int{0, 1, 2, 3, 4}, listValues(l))
+	require.Equal(t, 5, l.Len())
+	require.True(t, Owns(arena, unsafe.Pointer(two)))
+
+	require.Equal(t, 2, l.Remove(two))
+	require.Equal(t, 4, l.Remove(four))
+	require.Equal(t, 0, l.Remove(l.Front()))
+	require.Equal(t, []int{1, 3}, listValues(l))
+	require.Equal(t, 3, l.Back().Value)
+	require.Equal(t, 1, l.Back().Prev().Value)
+
+	other := NewList[int](nil)
+	require.Panics(t, func() { other.Remove(l.Front()) })
+}
+
+func TestListReusesFreedNodes(t *testing.T) {
+	arena := NewSizeClassArena(1024)
+	l := NewList[uint64](arena)
+
+	n := l.PushBack(1)
+	l.Remove(n)
+	require.Same(t, n, l.PushBack(2))
+}
+
+func TestSList(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	q := NewSList[int](arena)
+
+	q.PushBack(1)
+	q.PushBack(2)
+	q.PushFront(0)
+	require.Equal(t, 3, q.Len())
+	require.Equal(t, 2, q.Back().Value)
+	require.Equal(t, 1, q.Front().Next().Value)
+
+	for i := 0; i < 3; i++ {
+		v, ok := q.PopFront()
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+	_, ok := q.PopFront()
+	require.False(t, ok)
+	require.Nil(t, q.Back())
+
+	q.PushBack(3)
+	require.Equal(t, 3, q.Front().Value)
+}
+
+func TestListHeapNodesSurviveGC(t *testing.T) {
+	arena := NewMonotonicArena(48, 1)
+	l, sl := NewList[int64](arena), NewSList[int64](arena)
+	for i := int64(0); i < 6; i++ {
+		l.PushBack(i)
+		sl.PushBack(i)
+	}
+	l.InsertAfter(42, l.Front())
+	l.Remove(l.Back())
+
+	// The nodes allocated on the heap once the arena is exhausted are only linked from arena memory.
+	churnHeap()
+	require.Equal(t, []int64{0, 42, 1, 2, 3, 4}, listValues(l))
+	var values []int64
+	for n := sl.Front(); n != nil; n = n.Next() {
+		values = append(values, n.Value)
+	}
+	require.Equal(t, []int64{0, 1, 2, 3, 4, 5}, values)
+}
+
+// churnHeap collects garbage and fills the heap with new allocations, both with and without pointers, so that
+// values the GC wrongly deemed unreachable are overwritten.
+func churnHeap() {
+	runtime.GC()
+	var sentinel byte
+	var bytes [][]byte
+	var ptrs [][]*byte
+	for size := 1; size <= 32; size++ {
+		for i := 0; i < 256; i++ {
+			b := make([]byte, size*8)
+			for j := range b {
+				b[j] = 0xab
+			}
+			p := make([]*byte, size)
+			for j := range p {
+				p[j] = &sentinel
+			}
+			bytes, ptrs = append(bytes, b), append(ptrs, p)
+		}
+	}
+	runtime.GC()
+	runtime.KeepAlive(bytes)
+	runtime.KeepAlive(ptrs)
+}
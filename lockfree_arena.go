@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// lockFreeArena is a monotonic arena whose buffers are bump allocated by means of atomic operations,
+// so that concurrent allocations do not serialize behind a lock.
+type lockFreeArena struct {
+	buffers []lockFreeBuffer
+	current atomic.Int64 // index of the first buffer allocations are served from
+	opts    options
+
+	bytesInUse    atomic.Uint64
+	highWaterMark atomic.Uint64
+	heapFallbacks atomic.Uint64
+	pointerAllocs atomic.Uint64
+	resets        atomic.Uint64
+
+	mtx   sync.Mutex // guards hooks
+	hooks resetHooks
+}
+
+type lockFreeBuffer struct {
+	ptr    atomic.Pointer[byte]
+	offset atomic.Uintptr
+	size   uintptr
+}
+
+// NewLockFreeArena creates a monotonic arena with the given number of buffers of bufferSize bytes each, which is
+// safe to be accessed concurrently from multiple goroutines. Rather than serializing allocations behind a lock as
+// NewConcurrentArena does, buffers are bump allocated by means of atomic compare-and-swap operations. The arena
+// does not grow, hence once every buffer is full, allocations trigger the exhaustion policy, falling back to
+// the heap even if the policy asks for the arena to grow.
+//
+// Reset is not synchronized with allocations, hence it must only be invoked once every goroutine is done
+// allocating from the arena.
+func NewLockFreeArena(bufferSize, bufferCount int, opts ...Option) Arena {
+	a := &lockFreeArena{
+		buffers: make([]lockFreeBuffer, bufferCount),
+		opts:    newOptions(opts),
+	}
+	for i := range a.buffers {
+		a.buffers[i].size = uintptr(bufferSize)
+	}
+	return a
+}
+
+// Alloc satisfies the Arena interface.
+func (a *lockFreeArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *lockFreeArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if hasPointers(t) {
+		switch a.opts.pointerPolicy() {
+		case PointerFallback:
+			a.heapFallbacks.Add(1)
+			tracePointerFallback(t)
+			return nil, true
+
+		case PointerPanic:
+			panic(fmt.Errorf("%w: %s", ErrPointerType, t))
+
+		default:
+			a.pointerAllocs.Add(1)
+		}
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *lockFreeArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	for i := int(a.current.Load()); i < len(a.buffers); i++ {
+		if ptr, n, ok := a.buffers[i].alloc(size, alignment); ok {
+			a.allocated(n)
+			return ptr, true
+		}
+		// Move on to the next buffer, unless the allocation would not fit an empty one either,
+		// so that the remaining space is not given up for it.
+		if size+alignment-1 <= a.buffers[i].size {
+			a.current.CompareAndSwap(int64(i), int64(i+1))
+		}
+	}
+	switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+	case ExhaustedReturnNil:
+		return nil, false
+
+	case ExhaustedPanic:
+		panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+	default:
+		a.heapFallbacks.Add(1)
+		traceFallback(size)
+		return nil, true
+	}
+}
+
+func (a *lockFreeArena) allocated(n uint64) {
+	inUse := a.bytesInUse.Add(n)
+	for hwm := a.highWaterMark.Load(); inUse > hwm; hwm = a.highWaterMark.Load() {
+		if a.highWaterMark.CompareAndSwap(hwm, inUse) {
+			break
+		}
+	}
+}
+
+// alloc bumps the offset of the buffer, returning the number of bytes consumed including alignment padding.
+func (s *lockFreeBuffer) alloc(size, alignment uintptr) (unsafe.Pointer, uint64, bool) {
+	base := s.ptr.Load()
+	if base == nil {
+		if size > s.size {
+			return nil, 0, false // do not allocate a buffer the allocation cannot fit
+		}
+		// Goroutines racing to allocate the buffer lazily agree on the first one being stored.
+		buf := make([]byte, s.size)
+		if !s.ptr.CompareAndSwap(nil, unsafe.SliceData(buf)) {
+			base = s.ptr.Load()
+		} else {
+			base = unsafe.SliceData(buf)
+		}
+	}
+	for {
+		offset := s.offset.Load()
+		alignOffset := (alignment - (uintptr(unsafe.Pointer(base))+offset)%alignment) % alignment
+		end := offset + alignOffset + size
+		if end > s.size {
+			return nil, 0, false
+		}
+		if s.offset.CompareAndSwap(offset, end) {
+			return unsafe.Add(unsafe.Pointer(base), offset+alignOffset), uint64(end - offset), true
+		}
+	}
+}
+
+// Reset satisfies the Arena interface.
+func (a *lockFreeArena) Reset(release bool) {
+	defer traceReset().End()
+	a.mtx.Lock()
+	a.hooks.run()
+	a.mtx.Unlock()
+
+	for i := range a.buffers {
+		s := &a.buffers[i]
+		if release {
+			s.ptr.Store(nil)
+		} else if offset := s.offset.Load(); offset > 0 {
+			clear(unsafe.Slice(s.ptr.Load(), offset))
+		}
+		s.offset.Store(0)
+	}
+	a.current.Store(0)
+	a.bytesInUse.Store(0)
+	a.resets.Add(1)
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *lockFreeArena) OnReset(f func()) {
+	a.mtx.Lock()
+	a.hooks.add(f)
+	a.mtx.Unlock()
+}
+
+func (a *lockFreeArena) watchResets(f func()) {
+	a.mtx.Lock()
+	a.hooks.watch(f)
+	a.mtx.Unlock()
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *lockFreeArena) Stats() Stats {
+	s := Stats{
+		BytesInUse:         a.bytesInUse.Load(),
+		Buffers:            len(a.buffers),
+		HighWaterMark:      a.highWaterMark.Load(),
+		HeapFallbacks:      a.heapFallbacks.Load(),
+		PointerAllocations: a.pointerAllocs.Load(),
+		Resets:             a.resets.Load(),
+	}
+	for i := range a.buffers {
+		if a.buffers[i].ptr.Load() != nil {
+			s.BytesAllocated += uint64(a.buffers[i].size)
+		}
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *lockFreeArena) Owns(ptr unsafe.Pointer) bool {
+	for i := range a.buffers {
+		if within(unsafe.Pointer(a.buffers[i].ptr.Load()), a.buffers[i].size, ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *lockFreeArena) resetCount() uint64 {
+	return a.resets.Load()
+}
+
+func (a *lockFreeArena) concurrencySafe() bool {
+	return true
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFreeArenaConcurrentAllocations(t *testing.T) {
+	const goroutines, perGoroutine = 8, 1000
+	arena := NewLockFreeArena(4096, 20)
+
+	refs := make([][]*int64, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				p := New[int64](arena)
+				*p = int64(g*perGoroutine + i)
+				refs[g] = append(refs[g], p)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Every allocation got memory of its own.
+	for g := range refs {
+		for i, p := range refs[g] {
+			require.Equal(t, int64(g*perGoroutine+i), *p)
+		}
+	}
+	s := arena.(StatsProvider).Stats()
+	require.Equal(t, uint64(goroutines*perGoroutine*8), s.BytesInUse)
+	require.Zero(t, s.HeapFallbacks)
+}
+
+func TestLockFreeArenaExhausted(t *testing.T) {
+	arena := NewLockFreeArena(64, 2)
+
+	_ = MakeSlice[byte](arena, 128, 128) // does not fit any buffer
+	_ = MakeSlice[byte](arena, 60, 60)
+	_ = MakeSlice[byte](arena, 60, 60) // moves on to the second buffer
+	_ = MakeSlice[byte](arena, 8, 8)   // does not fit anymore
+	require.Equal(t, Stats{
+		BytesAllocated: 128,
+		BytesInUse:     120,
+		Buffers:        2,
+		HighWaterMark:  120,
+		HeapFallbacks:  2,
+	}, arena.(StatsProvider).Stats())
+
+	arena.Reset(false)
+	require.NotNil(t, MakeSlice[byte](arena, 64, 64))
+	require.Equal(t, uint64(64), arena.(StatsProvider).Stats().BytesInUse)
+}
+
+func BenchmarkLockFreeArenaParallel(b *testing.B) {
+	for _, tc := range []struct {
+		name  string
+		arena Arena
+	}{
+		{name: "mutex", arena: NewConcurrentArena(NewMonotonicArena(64*1024*1024, 4))},
+		{name: "lock-free", arena: NewLockFreeArena(64*1024*1024, 4)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = New[int64](tc.arena)
+				}
+			})
+			tc.arena.Reset(false)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// decommitBuffer returns the pages of a mapped buffer to the OS. As the buffer is a private anonymous mapping,
+// the pages are zero-filled when accessed again, hence the buffer does not need to be zeroed out.
+func decommitBuffer(ptr unsafe.Pointer, size uintptr) {
+	if err := syscall.Madvise(unsafe.Slice((*byte)(ptr), size), syscall.MADV_DONTNEED); err != nil {
+		clear(unsafe.Slice((*byte)(ptr), size))
+	}
+}
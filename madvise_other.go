@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package nuke
+
+import "unsafe"
+
+// decommitBuffer zeroes out a mapped buffer, as returning its pages to the OS is only supported on Linux.
+func decommitBuffer(ptr unsafe.Pointer, size uintptr) {
+	clear(unsafe.Slice((*byte)(ptr), size))
+}
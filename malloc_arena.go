@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build cgo && nukecgo
+
+package nuke
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// MallocSupported reports whether NewMallocArena is available, which requires building with cgo and the
+// nukecgo build tag.
+const MallocSupported = true
+
+// MallocArena is a monotonic arena whose buffers are allocated with C's malloc, so that the memory it hands out
+// can be passed to C libraries requiring malloc'd buffers. Being invisible to the garbage collector, it must only
+// hold POD types. Buffers are freed when the arena is reset releasing its memory, or when it is closed.
+type MallocArena struct {
+	*monotonicArena
+	closed bool
+}
+
+// NewMallocArena returns a MallocArena with the given number of buffers of bufferSize bytes, which are allocated
+// on first use. Close must be invoked before discarding the arena in order not to leak its buffers.
+func NewMallocArena(bufferSize, bufferCount int, opts ...Option) *MallocArena {
+	a := newMonotonicArena(bufferSize, 0, newOptions(opts))
+	a.malloced = true
+	a.addBuffers(bufferCount)
+	return &MallocArena{monotonicArena: a}
+}
+
+// Alloc satisfies the Arena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *MallocArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.checkOpen()
+	return a.monotonicArena.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *MallocArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.checkOpen()
+	return a.monotonicArena.AllocType(t, n)
+}
+
+// Reset satisfies the Arena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *MallocArena) Reset(release bool) {
+	a.checkOpen()
+	a.monotonicArena.Reset(release)
+}
+
+// Close frees every buffer of the arena, invalidating the memory allocated from it. Further use of the arena
+// panics, and closing it again returns ErrArenaClosed.
+func (a *MallocArena) Close() error {
+	if a.closed {
+		return ErrArenaClosed
+	}
+	a.monotonicArena.Reset(true)
+	a.closed = true
+	return nil
+}
+
+func (a *MallocArena) checkOpen() {
+	if a.closed {
+		panic(fmt.Errorf("%w: %T used after Close", ErrArenaClosed, a))
+	}
+}
+
+func mallocBuffer(size uintptr) unsafe.Pointer {
+	ptr := C.calloc(1, C.size_t(size))
+	if ptr == nil {
+		panic(fmt.Errorf("nuke: unable to malloc %d bytes", size))
+	}
+	return ptr
+}
+
+func freeBuffer(ptr unsafe.Pointer) {
+	C.free(ptr)
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build cgo && nukecgo
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMallocArena(t *testing.T) {
+	arena := NewMallocArena(4096, 1, WithGrowOnDemand())
+
+	s := MakeSlice[uint64](arena, 512, 512)
+	for i := range s {
+		require.Zero(t, s[i])
+		s[i] = uint64(i)
+	}
+	s2 := MakeSlice[byte](arena, 8192, 8192) // grows a malloc'd buffer
+	s2[8191] = 1
+
+	require.True(t, Owns(arena, unsafe.Pointer(&s[511])))
+	require.True(t, Owns(arena, unsafe.Pointer(&s2[8191])))
+	require.Len(t, arena.buffers, 2)
+	require.True(t, arena.buffers[1].malloced)
+
+	arena.Reset(false)
+	require.Zero(t, *New[uint64](arena))
+
+	require.NoError(t, arena.Close())
+	require.Nil(t, arena.buffers[0].ptr)
+	require.ErrorIs(t, arena.Close(), ErrArenaClosed)
+	require.PanicsWithError(t, "nuke: arena closed: *nuke.MallocArena used after Close", func() { New[int](arena) })
+	require.Panics(t, func() { arena.Reset(false) })
+}
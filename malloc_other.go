@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !(cgo && nukecgo)
+
+package nuke
+
+import "unsafe"
+
+// MallocSupported reports whether NewMallocArena is available, which requires building with cgo and the
+// nukecgo build tag.
+const MallocSupported = false
+
+func mallocBuffer(uintptr) unsafe.Pointer {
+	panic("nuke: malloc arenas require cgo and the nukecgo build tag")
+}
+
+func freeBuffer(unsafe.Pointer) {}
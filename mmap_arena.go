@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+// NewMmapArena creates a monotonic arena whose buffers are mapped with mmap rather than allocated from the heap,
+// which keeps large transient buffers off the Go heap, so that they neither count toward the GC pacing nor get
+// scanned. As a consequence, the arena must only hold POD types. Buffers are unmapped when the arena is reset
+// releasing its memory, hence Reset(true) must be invoked before discarding the arena in order not to leak them.
+// On platforms not supporting mmap, as reported by MmapSupported, buffers are allocated from the heap instead.
+func NewMmapArena(bufferSize, bufferCount int, opts ...Option) Arena {
+	a := newMonotonicArena(bufferSize, 0, newOptions(opts))
+	a.mapped = true
+	a.addBuffers(bufferCount)
+	return a
+}
+
+// NewOffHeapArena creates a mmap arena enforcing that it only holds POD types, which suits multi-gigabyte datasets
+// that must neither inflate the GC pacing nor get scanned. Allocating a type containing pointers panics with
+// ErrPointerType, regardless of the options, as the garbage collector would not see the memory it points to.
+// Buffers are mapped with VirtualAlloc on Windows. As with NewMmapArena, Reset(true) must be invoked before
+// discarding the arena in order not to leak its buffers.
+func NewOffHeapArena(bufferSize, bufferCount int, opts ...Option) Arena {
+	o := newOptions(opts)
+	policy := PointerPanic
+	o.pointers = &policy
+	a := newMonotonicArena(bufferSize, 0, o)
+	a.mapped = true
+	a.addBuffers(bufferCount)
+	return a
+}
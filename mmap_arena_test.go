@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"runtime/debug"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMmapArenaAllocate(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewMmapArena(4096, 1, WithGrowOnDemand()).(*monotonicArena)
+	defer arena.Reset(true)
+
+	s := MakeSlice[uint64](arena, 512, 512)
+	for i := range s {
+		s[i] = uint64(i)
+	}
+	s2 := MakeSlice[byte](arena, 8192, 8192) // grows a mapped buffer
+	s2[8191] = 1
+
+	require.True(t, Owns(arena, unsafe.Pointer(&s[511])))
+	require.True(t, Owns(arena, unsafe.Pointer(&s2[8191])))
+	require.Len(t, arena.buffers, 2)
+	require.True(t, arena.buffers[1].mapped)
+
+	arena.Reset(false)
+	require.Zero(t, s[511])
+	require.Zero(t, s2[8191])
+}
+
+func TestMmapArenaReleaseUnmapsBuffers(t *testing.T) {
+	arena := NewMmapArena(4096, 2).(*monotonicArena)
+
+	_ = New[int](arena)
+	require.NotNil(t, arena.buffers[0].ptr)
+
+	arena.Reset(true)
+	require.Nil(t, arena.buffers[0].ptr)
+
+	// Buffers are mapped again on demand.
+	*New[int](arena) = 1
+	require.NotNil(t, arena.buffers[0].ptr)
+	arena.Reset(true)
+}
+
+func TestMmapArenaDecommitOnReset(t *testing.T) {
+	arena := NewMmapArena(64*1024, 1, WithDecommitOnReset()).(*monotonicArena)
+	defer arena.Reset(true)
+
+	for i := 0; i < 3; i++ {
+		s := MakeSlice[byte](arena, 10_000, 10_000)
+		for j := range s {
+			require.Zero(t, s[j])
+			s[j] = 0xff
+		}
+		arena.Reset(false)
+		require.Zero(t, arena.buffers[0].offset)
+		require.NotNil(t, arena.buffers[0].ptr)
+	}
+}
+
+func TestOffHeapArenaRejectsPointers(t *testing.T) {
+	arena := NewOffHeapArena(4096, 1, WithPointerPolicy(PointerAllow))
+	defer arena.Reset(true)
+
+	*New[int64](arena) = 1
+	MakeSlice[[2]float64](arena, 16, 16)[15][1] = 1
+	require.Equal(t, uint64(8+16*16), arena.(StatsProvider).Stats().BytesInUse)
+
+	require.PanicsWithError(t, "nuke: type contains pointers: string", func() { New[string](arena) })
+	require.PanicsWithError(t, "nuke: type contains pointers: []uint8", func() { MakeSlice[[]byte](arena, 1, 1) })
+}
+
+func TestMmapArenaSeal(t *testing.T) {
+	if !MmapSupported {
+		t.Skip("mmap is not supported")
+	}
+	skipUnderASan(t)
+
+	arena := NewMmapArena(4096, 1).(*monotonicArena)
+	defer arena.Reset(true)
+
+	s := MakeSlice[uint64](arena, 8, 8)
+	s[7] = 42
+	require.NoError(t, arena.Seal())
+	require.Equal(t, uint64(42), s[7])
+	require.PanicsWithError(t, "nuke: arena sealed: unable to allocate from a sealed arena", func() {
+		_ = New[int](arena)
+	})
+
+	old := debug.SetPanicOnFault(true)
+	defer debug.SetPanicOnFault(old)
+	require.Panics(t, func() { s[7] = 0 })
+
+	require.NoError(t, arena.Unseal())
+	s[7] = 1
+	*New[int](arena) = 1
+
+	// Resetting unseals the arena.
+	require.NoError(t, arena.Seal())
+	arena.Reset(false)
+	require.Zero(t, s[7])
+}
+
+func TestMonotonicArenaSealUnsupported(t *testing.T) {
+	arena := NewMonotonicArena(4096, 1).(Sealer)
+	require.ErrorIs(t, arena.Seal(), errors.ErrUnsupported)
+}
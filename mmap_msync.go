@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin || freebsd || openbsd || dragonfly
+
+package nuke
+
+import "syscall"
+
+// sysMsync is the number of the msync system call.
+const sysMsync = syscall.SYS_MSYNC
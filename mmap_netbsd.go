@@ -0,0 +1,6 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+// sysMsync is the number of the __msync13 system call, which the syscall package does not define on NetBSD.
+const sysMsync = 277
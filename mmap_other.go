@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package nuke
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+)
+
+// MmapSupported reports whether NewMmapArena maps its buffers with mmap on the current platform.
+const MmapSupported = false
+
+// pageSize is the alignment of the buffers returned by mmapBuffer, which are not aligned beyond heap guarantees.
+const pageSize = 1
+
+func mmapBuffer(size uintptr) unsafe.Pointer {
+	// Fall back to heap buffers on platforms without mmap.
+	return unsafe.Pointer(unsafe.SliceData(make([]byte, size)))
+}
+
+func munmapBuffer(unsafe.Pointer, uintptr) {}
+
+func protectBuffer(unsafe.Pointer, uintptr, bool) error {
+	return errors.ErrUnsupported
+}
+
+func mmapFile(*os.File, int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func mmapFileCopy(*os.File, int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func msyncFile([]byte) error {
+	return errors.ErrUnsupported
+}
+
+func munmapFile([]byte) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package nuke
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// MmapSupported reports whether NewMmapArena maps its buffers with mmap on the current platform.
+const MmapSupported = true
+
+// pageSize is the alignment of the buffers returned by mmapBuffer.
+var pageSize = syscall.Getpagesize()
+
+func mmapBuffer(size uintptr) unsafe.Pointer {
+	b, err := syscall.Mmap(-1, 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic(fmt.Errorf("nuke: unable to map %d bytes: %w", size, err))
+	}
+	return unsafe.Pointer(unsafe.SliceData(b))
+}
+
+func munmapBuffer(ptr unsafe.Pointer, size uintptr) {
+	if err := syscall.Munmap(unsafe.Slice((*byte)(ptr), size)); err != nil {
+		panic(fmt.Errorf("nuke: unable to unmap %d bytes: %w", size, err))
+	}
+}
+
+// protectBuffer makes a buffer returned by mmapBuffer read-only, or writable again.
+func protectBuffer(ptr unsafe.Pointer, size uintptr, readOnly bool) error {
+	prot := syscall.PROT_READ | syscall.PROT_WRITE
+	if readOnly {
+		prot = syscall.PROT_READ
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_MPROTECT, uintptr(ptr), size, uintptr(prot)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// mmapFile maps the first size bytes of f in memory, so that writes to the mapping are carried over to the file.
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+// mmapFileCopy maps the first size bytes of f in memory copy-on-write, so that writes to the mapping are private
+// to it, whereas the pages it has not written to reflect the file.
+func mmapFileCopy(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+// msyncFile flushes a mapping returned by mmapFile to the file, waiting for the writes to complete.
+func msyncFile(b []byte) error {
+	_, _, errno := syscall.Syscall(sysMsync, uintptr(unsafe.Pointer(unsafe.SliceData(b))), uintptr(len(b)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func munmapFile(b []byte) error {
+	return syscall.Munmap(b)
+}
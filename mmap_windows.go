@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// MmapSupported reports whether NewMmapArena maps its buffers with mmap on the current platform,
+// which on Windows amounts to committing them with VirtualAlloc.
+const MmapSupported = true
+
+// pageSize is the alignment of the buffers returned by mmapBuffer.
+var pageSize = syscall.Getpagesize()
+
+const (
+	memCommit     = 0x1000
+	memReserve    = 0x2000
+	memRelease    = 0x8000
+	pageReadOnly  = 0x02
+	pageReadWrite = 0x04
+)
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procVirtualAlloc   = kernel32.NewProc("VirtualAlloc")
+	procVirtualFree    = kernel32.NewProc("VirtualFree")
+	procVirtualProtect = kernel32.NewProc("VirtualProtect")
+)
+
+func mmapBuffer(size uintptr) unsafe.Pointer {
+	addr, _, err := procVirtualAlloc.Call(0, size, memCommit|memReserve, pageReadWrite)
+	if addr == 0 {
+		panic(fmt.Errorf("nuke: unable to map %d bytes: %w", size, err))
+	}
+	return *(*unsafe.Pointer)(unsafe.Pointer(&addr)) // the memory is not managed by the Go runtime
+}
+
+func munmapBuffer(ptr unsafe.Pointer, size uintptr) {
+	if ok, _, err := procVirtualFree.Call(uintptr(ptr), 0, memRelease); ok == 0 {
+		panic(fmt.Errorf("nuke: unable to unmap %d bytes: %w", size, err))
+	}
+}
+
+// protectBuffer makes a buffer returned by mmapBuffer read-only, or writable again.
+func protectBuffer(ptr unsafe.Pointer, size uintptr, readOnly bool) error {
+	prot := uintptr(pageReadWrite)
+	if readOnly {
+		prot = pageReadOnly
+	}
+	var old uint32
+	if ok, _, err := procVirtualProtect.Call(uintptr(ptr), size, prot, uintptr(unsafe.Pointer(&old))); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// Mapping files is not supported, hence OpenFileArena returns errors.ErrUnsupported.
+func mmapFile(*os.File, int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func mmapFileCopy(*os.File, int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func msyncFile([]byte) error {
+	return errors.ErrUnsupported
+}
+
+func munmapFile([]byte) error {
+	return errors.ErrUnsupported
+}
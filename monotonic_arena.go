@@ -3,17 +3,47 @@
 package nuke
 
 import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"unsafe"
 )
 
 type monotonicArena struct {
-	buffers []*monotonicBuffer
+	buffers    []*monotonicBuffer
+	oversized  []*monotonicBuffer // dedicated buffers holding a single oversized allocation each
+	current    int                // index of the first buffer allocations are served from
+	bufferSize int
+	size       uintptr // overall size of the buffers
+	mapped     bool    // buffers are mapped with mmap rather than allocated from the heap
+	malloced   bool    // buffers are allocated with C's malloc rather than from the heap
+	external   bool    // the arena only serves allocations from caller owned memory, as set by NewExternalArena
+	sealed     bool    // mapped buffers are read-only, as set by Seal
+	opts       options
+	counters   arenaCounters
+	hooks      resetHooks
+	quarantine []quarantinedBuffer // buffers reclaimed by previous resets, as set by WithQuarantine
+}
+
+type quarantinedBuffer struct {
+	buf   *monotonicBuffer
+	until uint64 // number of resets releasing the buffer
 }
 
 type monotonicBuffer struct {
-	ptr    unsafe.Pointer
-	offset uintptr
-	size   uintptr
+	ptr      unsafe.Pointer
+	base     unsafe.Pointer // start of the memory backing the buffer, which ptr is aligned within
+	offset   uintptr
+	size     uintptr
+	align    uintptr // alignment of the start of the buffer
+	fixed    bool    // memory is owned by the caller, hence never released
+	mapped   bool    // memory is mapped with mmap, hence unmapped when released
+	malloced bool    // memory is allocated with C's malloc, hence freed when released
+	decommit bool    // pages of mapped memory are returned to the OS rather than zeroed out on reset
+	lazy     bool    // memory is zeroed out as it is handed out rather than on reset
+	poison   bool    // memory is filled with PoisonByte on reset, and zeroed out lazily
+	dirty    uintptr // number of leading bytes holding data from before the last reset, if zeroed lazily
 }
 
 func newMonotonicBuffer(size int) *monotonicBuffer {
@@ -22,8 +52,24 @@ func newMonotonicBuffer(size int) *monotonicBuffer {
 
 func (s *monotonicBuffer) alloc(size, alignment uintptr) (unsafe.Pointer, bool) {
 	if s.ptr == nil {
-		buf := make([]byte, s.size) // allocate monotonic buffer lazily
-		s.ptr = unsafe.Pointer(unsafe.SliceData(buf))
+		if size > s.size {
+			return nil, false // do not allocate a buffer the allocation cannot fit
+		}
+		pad := s.padding()
+		switch {
+		case s.mapped:
+			s.base = mmapBuffer(s.size + pad)
+		case s.malloced:
+			s.base = mallocBuffer(s.size + pad)
+		default:
+			buf := make([]byte, s.size+pad) // allocate monotonic buffer lazily
+			s.base = unsafe.Pointer(unsafe.SliceData(buf))
+		}
+		s.ptr = s.base
+		if s.align > 1 {
+			s.ptr = unsafe.Add(s.base, (s.align-uintptr(s.base)%s.align)%s.align)
+		}
+		asanPoison(s.base, s.size+pad)
 	}
 	alignOffset := uintptr(0)
 	for alignedPtr := uintptr(s.ptr) + s.offset; alignedPtr%alignment != 0; alignedPtr++ {
@@ -35,26 +81,87 @@ func (s *monotonicBuffer) alloc(size, alignment uintptr) (unsafe.Pointer, bool)
 		return nil, false
 	}
 	ptr := unsafe.Pointer(uintptr(s.ptr) + s.offset + alignOffset)
+	s.asanUnpoison(s.offset, allocSize)
+	if s.dirty > s.offset {
+		// Zero the memory left behind by previous allocations, which reset did not clear.
+		clear(unsafe.Slice((*byte)(unsafe.Add(s.ptr, s.offset)), min(s.dirty, s.offset+allocSize)-s.offset))
+	}
 	s.offset += allocSize
+	if s.offset >= s.dirty {
+		s.dirty = 0
+	}
 
 	return ptr, true
 }
 
 func (s *monotonicBuffer) reset(release bool) {
+	raceReclaim(s.ptr, s.offset)
+	if release && !s.fixed && s.ptr != nil {
+		asanUnpoison(s.base, s.size+s.padding())
+		if s.mapped {
+			munmapBuffer(s.base, s.size+s.padding())
+		} else if s.malloced {
+			freeBuffer(s.base)
+		} else if s.poison {
+			poisonMemory(s.ptr, s.offset) // dangling pointers keep the memory alive
+		}
+		s.ptr, s.base, s.offset, s.dirty = nil, nil, 0, 0
+		return
+	}
 	if s.offset == 0 {
 		return
 	}
+	s.asanUnpoison(0, s.size)
+	switch {
+	case s.poison:
+		poisonMemory(s.ptr, s.offset)
+		s.dirty = max(s.dirty, s.offset)
+	case s.mapped && s.decommit:
+		decommitBuffer(s.ptr, max(s.offset, s.dirty))
+		s.dirty = 0
+	case s.lazy:
+		s.dirty = max(s.dirty, s.offset)
+	default:
+		s.zeroOutBuffer()
+	}
+	s.asanPoison(0, s.size)
 	s.offset = 0
+}
 
-	if release {
-		s.ptr = nil
-	} else {
-		s.zeroOutBuffer()
+// padding returns the number of extra bytes the buffer needs to be allocated with for its start to be aligned.
+func (s *monotonicBuffer) padding() uintptr {
+	if s.align <= 1 || (s.mapped && uintptr(pageSize)%s.align == 0) {
+		return 0 // mappings are page aligned
+	}
+	return s.align - 1
+}
+
+// asanPoison marks n bytes of the buffer starting at off as unaddressable for AddressSanitizer. Fixed buffers are
+// left alone, as the caller may still access the memory it owns directly.
+func (s *monotonicBuffer) asanPoison(off, n uintptr) {
+	if !s.fixed {
+		asanPoison(unsafe.Add(s.ptr, off), n)
+	}
+}
+
+// asanUnpoison marks n bytes of the buffer starting at off as addressable for AddressSanitizer.
+func (s *monotonicBuffer) asanUnpoison(off, n uintptr) {
+	if !s.fixed {
+		asanUnpoison(unsafe.Add(s.ptr, off), n)
+	}
+}
+
+// poisonMemory fills n bytes starting at ptr with PoisonByte.
+func poisonMemory(ptr unsafe.Pointer, n uintptr) {
+	b := unsafe.Slice((*byte)(ptr), n)
+	for i := range b {
+		b[i] = PoisonByte
 	}
 }
 
+// zeroOutBuffer zeroes out the allocated part of the buffer, the rest of it never having been handed out.
 func (s *monotonicBuffer) zeroOutBuffer() {
-	b := unsafe.Slice((*byte)(s.ptr), s.size)
+	b := unsafe.Slice((*byte)(s.ptr), s.offset)
 
 	// This piece of code will be translated into a runtime.memclrNoHeapPointers
 	// invocation by the compiler, which is an assembler optimized implementation.
@@ -70,28 +177,282 @@ func (s *monotonicBuffer) availableBytes() uintptr {
 }
 
 // NewMonotonicArena creates a new monotonic arena with a specified number of buffers and a buffer size.
-func NewMonotonicArena(bufferSize, bufferCount int) Arena {
-	a := &monotonicArena{}
-	for i := 0; i < bufferCount; i++ {
-		a.buffers = append(a.buffers, newMonotonicBuffer(bufferSize))
+func NewMonotonicArena(bufferSize, bufferCount int, opts ...Option) Arena {
+	return newMonotonicArena(bufferSize, bufferCount, newOptions(opts))
+}
+
+func newMonotonicArena(bufferSize, bufferCount int, opts options) *monotonicArena {
+	a := &monotonicArena{
+		bufferSize: bufferSize,
+		opts:       opts,
 	}
+	a.addBuffers(bufferCount)
 	return a
 }
 
+// addBuffers appends the given number of buffers, which are allocated on first use.
+func (a *monotonicArena) addBuffers(n int) {
+	for i := 0; i < n; i++ {
+		buf := a.newBuffer(a.nextBufferSize(0, 1))
+		a.buffers = append(a.buffers, buf)
+		a.size += buf.size
+	}
+}
+
+// newBuffer returns a buffer of the given size configured as set by the arena options.
+func (a *monotonicArena) newBuffer(size int) *monotonicBuffer {
+	buf := newMonotonicBuffer(size)
+	buf.align = uintptr(a.opts.bufferAlignment)
+	buf.mapped, buf.decommit = a.mapped, a.opts.decommit
+	buf.malloced = a.malloced
+	buf.lazy = a.opts.lazyZeroing || a.opts.poison
+	buf.poison = a.opts.poison
+	return buf
+}
+
 // Alloc satisfies the Arena interface.
 func (a *monotonicArena) Alloc(size, alignment uintptr) unsafe.Pointer {
-	for i := 0; i < len(a.buffers); i++ {
-		ptr, ok := a.buffers[i].alloc(size, alignment)
-		if ok {
-			return ptr
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *monotonicArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *monotonicArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	// Buffers preceding the current one are skipped, so that allocating does not get slower as the arena fills.
+	// The current buffer only moves forward once an allocation succeeds, hence a large allocation not fitting
+	// any buffer does not prevent the remaining space from being used.
+	raceAlloc(unsafe.Pointer(a))
+	a.checkUnsealed()
+	oversized := !a.external && a.opts.oversizedThreshold > 0 && size > uintptr(a.opts.oversizedThreshold)
+	if !oversized {
+		for i := a.current; i < len(a.buffers); i++ {
+			if ptr, ok := a.allocFrom(a.buffers[i], size, alignment); ok {
+				a.current = i
+				return ptr, true
+			}
 		}
+		if a.opts.growOnDemand && !a.external && a.canGrow(a.nextBufferSize(size, alignment)) {
+			return a.grow(size, alignment), true
+		}
+	} else if a.opts.maxBytes <= 0 || a.size+size+alignment-1 <= uintptr(a.opts.maxBytes) {
+		return a.allocOversized(size, alignment), true
+	}
+	action := a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t})
+	if a.external && action != ExhaustedPanic {
+		action = ExhaustedReturnNil // neither growing nor falling back to the heap
+	}
+	switch action {
+	case ExhaustedGrow:
+		if oversized {
+			return a.allocOversized(size, alignment), true
+		}
+		return a.grow(size, alignment), true
+
+	case ExhaustedReturnNil:
+		return nil, false
+
+	case ExhaustedPanic:
+		panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+	default:
+		a.counters.heapFallbacks++
+		traceFallback(size)
+		return nil, true
+	}
+}
+
+// extend grows the allocation of oldSize bytes at ptr to newSize bytes in place, which is only possible if it is
+// the last allocation served from the current buffer, and the buffer has room for it.
+func (a *monotonicArena) extend(ptr unsafe.Pointer, oldSize, newSize uintptr) bool {
+	raceAlloc(unsafe.Pointer(a))
+	a.checkUnsealed()
+	if len(a.buffers) == 0 {
+		return false
+	}
+	buf := a.buffers[a.current]
+	if buf.ptr == nil || uintptr(buf.ptr)+buf.offset != uintptr(ptr)+oldSize || buf.availableBytes() < newSize-oldSize {
+		return false
 	}
-	return nil
+	_, ok := a.allocFrom(buf, newSize-oldSize, 1)
+	return ok
+}
+
+// canGrow reports whether a buffer of the given size can be appended without exceeding the configured limits.
+func (a *monotonicArena) canGrow(size int) bool {
+	if a.opts.maxBuffers > 0 && len(a.buffers) >= a.opts.maxBuffers {
+		return false
+	}
+	return a.opts.maxBytes <= 0 || a.size+uintptr(size) <= uintptr(a.opts.maxBytes)
+}
+
+// grow appends a new buffer and serves the allocation from it.
+func (a *monotonicArena) grow(size, alignment uintptr) unsafe.Pointer {
+	buf := a.newBuffer(a.nextBufferSize(size, alignment))
+	traceGrowth(buf.size)
+	a.buffers = append(a.buffers, buf)
+	a.size += buf.size
+	a.current = len(a.buffers) - 1
+	ptr, _ := a.allocFrom(buf, size, alignment)
+	return ptr
+}
+
+// allocOversized serves the allocation from a dedicated buffer, which is released on Reset,
+// so that the buffers of the arena remain sized for the common case.
+func (a *monotonicArena) allocOversized(size, alignment uintptr) unsafe.Pointer {
+	buf := a.newBuffer(int(size + alignment - 1))
+	traceGrowth(buf.size)
+	a.oversized = append(a.oversized, buf)
+	a.size += buf.size
+	ptr, _ := a.allocFrom(buf, size, alignment)
+	return ptr
+}
+
+// nextBufferSize returns the size of the buffer to grow the arena with,
+// which fits the allocation regardless of the base pointer alignment.
+func (a *monotonicArena) nextBufferSize(size, alignment uintptr) int {
+	bufferSize := a.bufferSize
+	if n := len(a.buffers); n > 0 && a.opts.growthFactor > 1 {
+		bufferSize = int(float64(a.buffers[n-1].size) * a.opts.growthFactor)
+	}
+	return max(bufferSize, int(size+alignment-1))
+}
+
+func (a *monotonicArena) allocFrom(buf *monotonicBuffer, size, alignment uintptr) (unsafe.Pointer, bool) {
+	offset := buf.offset
+	ptr, ok := buf.alloc(size, alignment)
+	if ok {
+		a.counters.allocated(uint64(buf.offset - offset))
+	}
+	return ptr, ok
 }
 
 // Reset satisfies the Arena interface.
 func (a *monotonicArena) Reset(release bool) {
+	defer traceReset().End()
+	if err := a.Unseal(); err != nil {
+		panic(err)
+	}
+	a.hooks.run()
+	used := len(a.buffers)
+	if a.opts.partialRelease {
+		for used > 0 && a.buffers[used-1].offset == 0 {
+			used--
+		}
+	}
+	resets := a.counters.resets + 1 // including this one
+	a.releaseQuarantine(release, resets)
+	for i, s := range a.buffers {
+		if a.opts.quarantine > 0 && !release && !s.fixed && s.offset > 0 {
+			// The buffer is replaced by a fresh one, allocated on first use.
+			s.reset(false)
+			a.quarantine = append(a.quarantine, quarantinedBuffer{buf: s, until: resets + uint64(a.opts.quarantine)})
+			a.buffers[i] = a.newBuffer(int(s.size))
+			continue
+		}
+		s.reset(release || i >= used)
+	}
+	for i, s := range a.oversized {
+		s.reset(true)
+		a.size -= s.size
+		a.oversized[i] = nil
+	}
+	a.oversized = a.oversized[:0]
+	a.current = 0
+	a.counters.reset()
+	raceReset(unsafe.Pointer(a))
+}
+
+// releaseQuarantine releases the buffers whose quarantine is over after the given number of resets,
+// or all of them if release is set.
+func (a *monotonicArena) releaseQuarantine(release bool, resets uint64) {
+	n := 0
+	for _, q := range a.quarantine {
+		if release || q.until <= resets {
+			q.buf.reset(true)
+			continue
+		}
+		a.quarantine[n] = q
+		n++
+	}
+	clear(a.quarantine[n:])
+	a.quarantine = a.quarantine[:n]
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *monotonicArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *monotonicArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *monotonicArena) Stats() Stats {
+	s := a.counters.stats()
+	s.Buffers = len(a.buffers) + len(a.oversized)
+	for _, buf := range a.buffers {
+		if buf.ptr != nil {
+			s.BytesAllocated += uint64(buf.size)
+		}
+	}
+	for _, buf := range a.oversized {
+		if buf.ptr != nil {
+			s.BytesAllocated += uint64(buf.size)
+		}
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *monotonicArena) Owns(ptr unsafe.Pointer) bool {
 	for _, s := range a.buffers {
-		s.reset(release)
+		if within(s.ptr, s.size, ptr) {
+			return true
+		}
 	}
+	for _, s := range a.oversized {
+		if within(s.ptr, s.size, ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dump satisfies the Dumper interface.
+func (a *monotonicArena) Dump(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "MonotonicArena: %d buffers, %d oversized, %d/%d bytes in use\n",
+		len(a.buffers), len(a.oversized), a.counters.bytesInUse, a.size)
+	for i, buf := range a.buffers {
+		current := ""
+		if i == a.current {
+			current = " (current)"
+		}
+		if buf.ptr == nil {
+			fmt.Fprintf(&b, "  buffer %d: unallocated, %d bytes%s\n", i, buf.size, current)
+		} else {
+			fmt.Fprintf(&b, "  buffer %d: offset %d/%d%s\n", i, buf.offset, buf.size, current)
+		}
+	}
+	for i, buf := range a.oversized {
+		fmt.Fprintf(&b, "  oversized buffer %d: offset %d/%d\n", i, buf.offset, buf.size)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (a *monotonicArena) bufferSizeHint() int {
+	return a.bufferSize
+}
+
+func (a *monotonicArena) resetCount() uint64 {
+	return a.counters.resets
 }
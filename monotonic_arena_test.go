@@ -3,8 +3,11 @@
 package nuke
 
 import (
+	"bytes"
 	"fmt"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
@@ -21,7 +24,7 @@ func TestMonotonicArenaAllocateObject(t *testing.T) {
 	}
 
 	for i := 0; i < 1_000; i++ {
-		require.True(t, isMonotonicArenaPtr(arena, unsafe.Pointer(refs[i])))
+		require.True(t, Owns(arena, unsafe.Pointer(refs[i])))
 	}
 }
 
@@ -32,11 +35,11 @@ func TestMonotonicArenaSendObjectToHeap(t *testing.T) {
 	arena := NewMonotonicArena(2*int(unsafe.Sizeof(x)), 1) // 2 ints room
 
 	// Send the first two ints to the arena
-	require.True(t, isMonotonicArenaPtr(arena, unsafe.Pointer(New[int](arena))))
-	require.True(t, isMonotonicArenaPtr(arena, unsafe.Pointer(New[int](arena))))
+	require.True(t, Owns(arena, unsafe.Pointer(New[int](arena))))
+	require.True(t, Owns(arena, unsafe.Pointer(New[int](arena))))
 
 	// Send last one to the heap
-	require.False(t, isMonotonicArenaPtr(arena, unsafe.Pointer(New[int](arena))))
+	require.False(t, Owns(arena, unsafe.Pointer(New[int](arena))))
 }
 
 func TestMonotonicArenaReset(t *testing.T) {
@@ -93,20 +96,334 @@ func TestMonotonicArenaMultipleTypes(t *testing.T) {
 	require.True(t, *p == nil)
 }
 
-func isMonotonicArenaPtr(a Arena, ptr unsafe.Pointer) bool {
-	ma := a.(*monotonicArena)
-	for _, s := range ma.buffers {
-		if s.ptr == nil {
-			break
-		}
-		beginPtr := uintptr(s.ptr)
-		endPtr := uintptr(s.ptr) + s.size
+func TestMonotonicArenaStrictMode(t *testing.T) {
+	var x int
+	arena := NewMonotonicArena(2*int(unsafe.Sizeof(x)), 1, WithStrictMode()) // 2 ints room
+
+	require.NotNil(t, New[int](arena))
+	require.NotNil(t, New[int](arena))
+
+	// Arena is full, so a strict arena must not fall back to the heap
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() { _ = New[int](arena) })
+
+	arena.Reset(false)
+
+	// Pointer-containing types are rejected
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { _ = New[*int](arena) })
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { _ = MakeSlice[string](arena, 0, 1) })
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { _ = New[struct{ m map[int]int }](arena) })
+
+	// Pointer-free composite types are accepted
+	require.NotNil(t, New[struct{ a, b int32 }](arena))
+	require.NotNil(t, New[[1]int](arena))
+}
+
+func TestMonotonicArenaOnExhausted(t *testing.T) {
+	var x int
+	var action ExhaustedAction
+	var exhaustions []Exhaustion
+
+	arena := NewMonotonicArena(int(unsafe.Sizeof(x)), 1, WithOnExhausted(func(e Exhaustion) ExhaustedAction {
+		exhaustions = append(exhaustions, e)
+		return action
+	}))
+	require.True(t, Owns(arena, unsafe.Pointer(New[int](arena))))
+	require.Empty(t, exhaustions)
+
+	action = ExhaustedFallback
+	require.False(t, Owns(arena, unsafe.Pointer(New[int](arena))))
+
+	action = ExhaustedReturnNil
+	require.Nil(t, New[int](arena))
+	require.Nil(t, MakeSlice[int](arena, 0, 4))
+	require.Nil(t, arena.Alloc(8, 8))
+
+	action = ExhaustedPanic
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() { _ = New[int](arena) })
+
+	action = ExhaustedGrow
+	s := MakeSlice[int](arena, 64, 64)
+	require.True(t, Owns(arena, unsafe.Pointer(&s[63])))
+	require.True(t, Owns(arena, unsafe.Pointer(New[int](arena))))
+
+	require.Len(t, exhaustions, 7)
+	require.Equal(t, Exhaustion{Size: unsafe.Sizeof(x), Alignment: unsafe.Alignof(x), Type: reflect.TypeOf(x)}, exhaustions[0])
+	require.Equal(t, Exhaustion{Size: 4 * unsafe.Sizeof(x), Alignment: unsafe.Alignof(x), Type: reflect.TypeOf(x)}, exhaustions[2])
+	require.Equal(t, Exhaustion{Size: 8, Alignment: 8}, exhaustions[3])
+}
+
+func TestMonotonicArenaStats(t *testing.T) {
+	arena := NewMonotonicArena(64, 2)
+	require.Equal(t, Stats{Buffers: 2}, arena.(StatsProvider).Stats())
+
+	_ = New[byte](arena)
+	_ = New[int64](arena) // 7 bytes of alignment padding
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 0, 128) // heap fallback
+
+	require.Equal(t, Stats{
+		BytesAllocated: 128,
+		BytesInUse:     80,
+		Buffers:        2,
+		HighWaterMark:  80,
+		HeapFallbacks:  1,
+	}, arena.(StatsProvider).Stats())
+
+	arena.Reset(false)
+	_ = New[int64](arena)
+
+	require.Equal(t, Stats{
+		BytesAllocated: 128,
+		BytesInUse:     8,
+		Buffers:        2,
+		HighWaterMark:  80,
+		HeapFallbacks:  1,
+		Resets:         1,
+	}, NewConcurrentArena(arena).(StatsProvider).Stats())
+}
+
+func TestMonotonicArenaCurrentBuffer(t *testing.T) {
+	arena := NewMonotonicArena(64, 3).(*monotonicArena)
+
+	_ = MakeSlice[byte](arena, 60, 60)
+	_ = MakeSlice[byte](arena, 8, 8) // does not fit the first buffer
+	require.Equal(t, 1, arena.current)
+
+	// Allocations not fitting any buffer leave the current one untouched.
+	_ = MakeSlice[byte](arena, 128, 128)
+	require.Equal(t, 1, arena.current)
+
+	_ = MakeSlice[byte](arena, 60, 60)
+	require.Equal(t, 2, arena.current)
+	require.Equal(t, uintptr(60), arena.buffers[2].offset)
+
+	arena.Reset(false)
+	require.Zero(t, arena.current)
+}
+
+func TestMonotonicArenaGrowOnDemand(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithGrowOnDemand(), WithMaxBuffers(3), WithMaxBytes(1024)).(*monotonicArena)
+
+	for i := 0; i < 3; i++ {
+		require.True(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(MakeSlice[byte](arena, 64, 64)))))
+	}
+	require.Len(t, arena.buffers, 3)
+
+	// The buffer count limit has been reached.
+	require.False(t, Owns(arena, unsafe.Pointer(New[byte](arena))))
+	require.Equal(t, uint64(1), arena.Stats().HeapFallbacks)
+
+	// Grown buffers are retained across resets.
+	arena.Reset(false)
+	require.Len(t, arena.buffers, 3)
+}
+
+func TestMonotonicArenaGrowOnDemandMaxBytes(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithGrowOnDemand(), WithMaxBytes(256), WithStrictMode()).(*monotonicArena)
+
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 128, 128) // grows a buffer fitting the allocation
+	require.Len(t, arena.buffers, 2)
+	require.Equal(t, uint64(192), arena.Stats().BytesAllocated)
+
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() {
+		_ = MakeSlice[byte](arena, 128, 128)
+	})
+}
+
+func TestMonotonicArenaBufferGrowthFactor(t *testing.T) {
+	arena := NewMonotonicArena(64, 3, WithBufferGrowthFactor(2), WithGrowOnDemand()).(*monotonicArena)
+
+	_ = MakeSlice[byte](arena, 200, 200) // served from the third buffer
+	require.Equal(t, 2, arena.current)
+	require.Equal(t, uint64(256), arena.Stats().BytesAllocated)
+
+	_ = MakeSlice[byte](arena, 100, 100) // grows a fourth buffer
+	_ = MakeSlice[byte](arena, 1024, 1024)
+
+	var sizes []uintptr
+	for _, buf := range arena.buffers {
+		sizes = append(sizes, buf.size)
+	}
+	require.Equal(t, []uintptr{64, 128, 256, 512, 1024}, sizes)
+}
+
+func TestMonotonicArenaLazyZeroing(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewMonotonicArena(64, 1, WithLazyZeroing()).(*monotonicArena)
+
+	s := MakeSlice[byte](arena, 32, 32)
+	for i := range s {
+		s[i] = 0xff
+	}
+	arena.Reset(false)
 
-		if uintptr(ptr) >= beginPtr && uintptr(ptr) < endPtr {
-			return true
+	// Memory is left untouched until handed out again.
+	require.Equal(t, byte(0xff), s[31])
+	require.Equal(t, uintptr(32), arena.buffers[0].dirty)
+
+	s2 := MakeSlice[byte](arena, 16, 16)
+	require.Equal(t, make([]byte, 16), s2)
+	require.Equal(t, byte(0xff), s[16])
+
+	i := New[int64](arena)
+	require.Zero(t, *i)
+	_ = MakeSlice[byte](arena, 8, 8)
+	require.Zero(t, arena.buffers[0].dirty)
+	require.Zero(t, s[31])
+}
+
+func TestMonotonicArenaPoisonOnReset(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewMonotonicArena(64, 1, WithPoisonOnReset()).(*monotonicArena)
+
+	s := MakeSlice[byte](arena, 32, 32)
+	arena.Reset(false)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 32), s)
+
+	// Memory is zeroed as it is handed out again
+	require.Equal(t, make([]byte, 16), MakeSlice[byte](arena, 16, 16))
+	require.Zero(t, s[15])
+	require.Equal(t, byte(PoisonByte), s[16])
+
+	s = MakeSlice[byte](arena, 48, 48)
+	arena.Reset(true)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 48), s)
+}
+
+func TestMonotonicArenaQuarantine(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewMonotonicArena(64, 2, WithQuarantine(2), WithPoisonOnReset()).(*monotonicArena)
+
+	s := MakeSlice[byte](arena, 32, 32)
+	first := arena.buffers[0]
+	arena.Reset(false)
+	require.Len(t, arena.quarantine, 1)
+	require.NotSame(t, first, arena.buffers[0])
+	require.Nil(t, arena.buffers[1].ptr) // unused buffers are not quarantined
+
+	// Fresh memory is handed out while the quarantined buffer remains poisoned
+	s2 := MakeSlice[byte](arena, 32, 32)
+	require.False(t, Owns(arena, unsafe.Pointer(&s[0])))
+	require.Equal(t, make([]byte, 32), s2)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 32), s)
+
+	arena.Reset(false)
+	require.Len(t, arena.quarantine, 2)
+	require.NotNil(t, first.ptr)
+
+	arena.Reset(false)
+	require.Len(t, arena.quarantine, 1)
+	require.Equal(t, arena.quarantine[0].buf.ptr, unsafe.Pointer(&s2[0]))
+	require.Nil(t, first.ptr) // released after two more resets
+
+	arena.Reset(true)
+	require.Empty(t, arena.quarantine)
+}
+
+func TestMonotonicArenaPointerPolicy(t *testing.T) {
+	type node struct {
+		next *node
+		v    int
+	}
+
+	arena := NewMonotonicArena(1024, 1)
+	require.True(t, Owns(arena, unsafe.Pointer(New[node](arena))))
+	require.Equal(t, uint64(1), arena.(StatsProvider).Stats().PointerAllocations)
+
+	arena = NewMonotonicArena(1024, 1, WithPointerPolicy(PointerFallback))
+	require.False(t, Owns(arena, unsafe.Pointer(New[node](arena))))
+	require.True(t, Owns(arena, unsafe.Pointer(New[int](arena))))
+	require.Equal(t, Stats{
+		BytesAllocated: 1024,
+		BytesInUse:     8,
+		Buffers:        1,
+		HighWaterMark:  8,
+		HeapFallbacks:  1,
+	}, arena.(StatsProvider).Stats())
+
+	arena = NewMonotonicArena(1024, 1, WithPointerPolicy(PointerPanic))
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { _ = New[node](arena) })
+
+	// An explicit policy takes precedence over strict mode.
+	arena = NewMonotonicArena(1024, 1, WithStrictMode(), WithPointerPolicy(PointerAllow))
+	require.NotPanics(t, func() { _ = MakeSlice[*int](arena, 0, 4) })
+}
+
+func TestMonotonicArenaBufferAlignment(t *testing.T) {
+	for _, arena := range []Arena{
+		NewMonotonicArena(100, 2, WithBufferAlignment(64), WithGrowOnDemand()),
+		NewMmapArena(100, 2, WithBufferAlignment(64), WithGrowOnDemand()),
+	} {
+		for i := 0; i < 3; i++ {
+			s := MakeSlice[byte](arena, 100, 100)
+			require.Zero(t, uintptr(unsafe.Pointer(&s[0]))%64)
 		}
+		require.Equal(t, uint64(300), arena.(StatsProvider).Stats().BytesInUse)
+		arena.Reset(true)
+	}
+}
+
+func TestMonotonicArenaPartialRelease(t *testing.T) {
+	arena := NewMonotonicArena(64, 4, WithPartialRelease())
+
+	// Burst using every buffer.
+	for i := 0; i < 4; i++ {
+		_ = MakeSlice[byte](arena, 64, 64)
 	}
-	return false
+	arena.Reset(false)
+	require.Equal(t, uint64(256), arena.(StatsProvider).Stats().BytesAllocated)
+
+	// Steady state only using the first two buffers.
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 8, 8)
+	arena.Reset(false)
+	require.Equal(t, uint64(128), arena.(StatsProvider).Stats().BytesAllocated)
+
+	arena.Reset(false)
+	require.Zero(t, arena.(StatsProvider).Stats().BytesAllocated)
+}
+
+func TestMonotonicArenaOversizedAllocations(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithOversizedThreshold(64)).(*monotonicArena)
+
+	s := MakeSlice[int64](arena, 100, 100)
+	require.True(t, Owns(arena, unsafe.Pointer(&s[99])))
+	require.Len(t, arena.oversized, 1)
+	require.True(t, Owns(arena, unsafe.Pointer(New[int64](arena))))
+	require.Equal(t, Stats{
+		BytesAllocated: 64 + 807,
+		BytesInUse:     808,
+		Buffers:        2,
+		HighWaterMark:  808,
+	}, arena.Stats())
+
+	// Oversized buffers are released on Reset.
+	arena.Reset(false)
+	require.Empty(t, arena.oversized)
+	require.Equal(t, uint64(64), arena.Stats().BytesAllocated)
+	require.Equal(t, uintptr(64), arena.size)
+}
+
+func TestMonotonicArenaOversizedMaxBytes(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithOversizedThreshold(64), WithMaxBytes(512), WithStrictMode())
+
+	_ = MakeSlice[byte](arena, 400, 400)
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() {
+		_ = MakeSlice[byte](arena, 100, 100)
+	})
+}
+
+func requirePanicsWithErrorIs(t *testing.T, target error, f func()) {
+	t.Helper()
+	defer func() {
+		err, _ := recover().(error)
+		require.ErrorIs(t, err, target)
+	}()
+	f()
 }
 
 func BenchmarkRuntimeNewObject(b *testing.B) {
@@ -229,3 +546,19 @@ func newArenaAllocator[T any](a Arena) allocator[T] {
 
 func (r *arenaAllocator[T]) new() *T                    { return New[T](r.a) }
 func (r *arenaAllocator[T]) makeSlice(len, cap int) []T { return MakeSlice[T](r.a, len, cap) }
+
+func TestMonotonicArenaDump(t *testing.T) {
+	arena := NewMonotonicArena(64, 3, WithOversizedThreshold(100))
+	_ = MakeSlice[byte](arena, 60, 60)
+	_ = MakeSlice[byte](arena, 8, 8)
+	_ = MakeSlice[byte](arena, 200, 200)
+
+	var b strings.Builder
+	require.NoError(t, arena.(Dumper).Dump(&b))
+	require.Equal(t, `MonotonicArena: 3 buffers, 1 oversized, 268/392 bytes in use
+  buffer 0: offset 60/64
+  buffer 1: offset 8/64 (current)
+  buffer 2: unallocated, 64 bytes
+  oversized buffer 0: offset 200/200
+`, b.String())
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukearena mirrors the API of the arena package of the GOEXPERIMENT=arenas experiment, backed by nuke
+// arenas, so that code written against it can switch to nuke by changing its imports:
+//
+//	import arena "github.com/ortuman/nuke/nukearena"
+//
+// As with the experiment, values of any type can be allocated, including those holding pointers, which are kept
+// where the garbage collector scans them.
+package nukearena
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+)
+
+// defaultSlabSize is the size of the first slab arenas allocate POD values from.
+const defaultSlabSize = 64 * 1024
+
+// Arena is a collection of values freed all at once. An Arena is not safe to be accessed concurrently from
+// multiple goroutines.
+type Arena struct {
+	a *nuke.SafeArena
+}
+
+// NewArena returns a new arena.
+func NewArena() *Arena {
+	return &Arena{a: nuke.NewSafeArena(defaultSlabSize)}
+}
+
+// Free releases the memory of the arena, invalidating every value allocated from it. Unlike with the experiment,
+// the arena can be used again afterwards.
+func (a *Arena) Free() {
+	a.a.Reset(true)
+}
+
+// Arena returns the nuke arena backing a, which can be passed to the rest of the helpers of the nuke package.
+func (a *Arena) Arena() nuke.Arena {
+	return a.a
+}
+
+// New allocates a zeroed value of type T from the arena and returns a pointer to it.
+func New[T any](a *Arena) *T {
+	return nuke.New[T](a.a)
+}
+
+// MakeSlice allocates a slice of type T with the given length and capacity from the arena.
+func MakeSlice[T any](a *Arena, len, cap int) []T {
+	return nuke.MakeSlice[T](a.a, len, cap)
+}
+
+// Clone makes a shallow copy of s on the heap, so that it is no longer bound to the arena it may have been
+// allocated from. s must be a pointer, a slice or a string, otherwise Clone panics. Unlike with the experiment,
+// values not allocated from an arena are copied as well.
+func Clone[T any](s T) T {
+	v := reflect.ValueOf(&s).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return s
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(v.Elem())
+		return c.Interface().(T)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return s
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Cap())
+		reflect.Copy(c.Slice(0, v.Cap()), v.Slice(0, v.Cap()))
+		return c.Interface().(T)
+
+	case reflect.String:
+		c := strings.Clone(v.String())
+		return *(*T)(unsafe.Pointer(&c))
+
+	default:
+		panic("nukearena: Clone only supports pointers, slices and strings, got " + v.Type().String())
+	}
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukearena
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	name string
+	next *node
+}
+
+func TestArena(t *testing.T) {
+	a := NewArena()
+	defer a.Free()
+
+	n := New[node](a)
+	n.name = "head"
+	n.next = New[node](a)
+	s := MakeSlice[int](a, 2, 8)
+	s[1] = 1
+	require.Len(t, s, 2)
+	require.Equal(t, 8, cap(s))
+	require.True(t, nuke.Owns(a.Arena(), unsafe.Pointer(&s[0])))
+
+	c := Clone(s)
+	require.Equal(t, s, c)
+	require.Equal(t, 8, cap(c))
+	require.False(t, nuke.Owns(a.Arena(), unsafe.Pointer(&c[0])))
+
+	cn := Clone(n)
+	require.NotSame(t, n, cn)
+	require.Equal(t, "head", cn.name)
+
+	type name string
+	str := nuke.SealString(MakeSlice[byte](a, 3, 3))
+	require.Equal(t, name(str), Clone(name(str)))
+	require.Panics(t, func() { Clone(42) })
+
+	a.Free()
+	*New[int](a) = 1
+}
@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukecbor decodes CBOR (RFC 8949) data items into trees allocated from a nuke arena.
+//
+// Decoded trees are laid out as a flat array of pointer-free nodes, along with a single buffer
+// holding the contents of every byte and text string, both allocated from the arena. Hence,
+// decoding a data item takes a handful of arena allocations regardless of its size, and every
+// Value becomes invalid as soon as the arena is reset.
+package nukecbor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+
+	"github.com/ortuman/nuke"
+)
+
+// MaxDepth is the maximum nesting depth of the data items Decode accepts.
+const MaxDepth = 512
+
+var (
+	// ErrMalformed is returned when the input is not well-formed CBOR.
+	ErrMalformed = errors.New("nukecbor: malformed data item")
+
+	// ErrMaxDepth is returned when the input nests data items deeper than MaxDepth.
+	ErrMaxDepth = errors.New("nukecbor: maximum nesting depth exceeded")
+)
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+
+	infoIndefinite = 31
+	breakCode      = 0xff
+)
+
+// node is a decoded data item. It holds no pointers, so that arrays of nodes can be safely allocated from any arena.
+type node struct {
+	kind Kind
+
+	// n is the number of elements of an array, the number of pairs of a map or the length of a string.
+	n uint32
+
+	// u is the argument of integers, simple values and tags, the bits of floats, or the offset
+	// of string contents within the data buffer.
+	u uint64
+
+	// end is the index of the node following the subtree rooted at this node.
+	end uint32
+}
+
+// Decode decodes the CBOR data item at the beginning of b, allocating the resulting tree from the provided arena.
+// It returns the decoded value along with the remaining bytes of b.
+func Decode(a nuke.Arena, b []byte) (Value, []byte, error) {
+	d := decoder{
+		a:     a,
+		b:     b,
+		nodes: nuke.MakeSlice[node](a, 0, min(len(b), 64)),
+	}
+	if err := d.decodeItem(0); err != nil {
+		return Value{}, b, err
+	}
+	return Value{nodes: d.nodes, data: d.data}, d.b[d.off:], nil
+}
+
+type decoder struct {
+	a     nuke.Arena
+	b     []byte
+	off   int
+	nodes []node
+	data  []byte
+}
+
+// head reads the initial byte of a data item along with its argument.
+func (d *decoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.off >= len(d.b) {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	ib := d.b[d.off]
+	d.off++
+	major, info = ib>>5, ib&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == infoIndefinite:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("%w: reserved additional information %d", ErrMalformed, info)
+	}
+	if len(d.b)-d.off < size {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	for _, c := range d.b[d.off : d.off+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.off += size
+	return major, info, arg, nil
+}
+
+func (d *decoder) push(n node) int {
+	d.nodes = nuke.SliceAppend(d.a, d.nodes, n)
+	return len(d.nodes) - 1
+}
+
+func (d *decoder) decodeItem(depth int) error {
+	if depth > MaxDepth {
+		return ErrMaxDepth
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	if info == infoIndefinite && (major == majorUint || major == majorNegInt || major == majorTag) {
+		return fmt.Errorf("%w: indefinite length not allowed for major type %d", ErrMalformed, major)
+	}
+
+	switch major {
+	case majorUint:
+		d.push(node{kind: Uint, u: arg})
+
+	case majorNegInt:
+		d.push(node{kind: NegInt, u: arg})
+
+	case majorBytes, majorText:
+		return d.decodeString(major, info, arg)
+
+	case majorArray, majorMap:
+		return d.decodeContainer(major, info, arg, depth)
+
+	case majorTag:
+		idx := d.push(node{kind: Tag, u: arg})
+		if err := d.decodeItem(depth + 1); err != nil {
+			return err
+		}
+		d.nodes[idx].end = uint32(len(d.nodes))
+		return nil
+
+	case majorSimple:
+		return d.decodeSimple(info, arg)
+	}
+	d.nodes[len(d.nodes)-1].end = uint32(len(d.nodes))
+	return nil
+}
+
+func (d *decoder) decodeString(major, info byte, arg uint64) error {
+	kind := Bytes
+	if major == majorText {
+		kind = Text
+	}
+	if d.data == nil {
+		// String contents can never exceed the input size, so that the buffer never needs to grow.
+		d.data = nuke.MakeSlice[byte](d.a, 0, len(d.b)-d.off)
+	}
+	start := len(d.data)
+
+	if info != infoIndefinite {
+		if err := d.appendChunk(arg); err != nil {
+			return err
+		}
+	} else {
+		for {
+			if d.off >= len(d.b) {
+				return io.ErrUnexpectedEOF
+			}
+			if d.b[d.off] == breakCode {
+				d.off++
+				break
+			}
+			chunkMajor, chunkInfo, chunkArg, err := d.head()
+			if err != nil {
+				return err
+			}
+			if chunkMajor != major || chunkInfo == infoIndefinite {
+				return fmt.Errorf("%w: invalid indefinite length string chunk", ErrMalformed)
+			}
+			if err := d.appendChunk(chunkArg); err != nil {
+				return err
+			}
+		}
+	}
+	if kind == Text && !utf8.Valid(d.data[start:]) {
+		return fmt.Errorf("%w: invalid UTF-8 text string", ErrMalformed)
+	}
+	d.push(node{kind: kind, n: uint32(len(d.data) - start), u: uint64(start), end: uint32(len(d.nodes) + 1)})
+	return nil
+}
+
+func (d *decoder) appendChunk(n uint64) error {
+	if n > uint64(len(d.b)-d.off) {
+		return io.ErrUnexpectedEOF
+	}
+	d.data = append(d.data, d.b[d.off:d.off+int(n)]...)
+	d.off += int(n)
+	return nil
+}
+
+func (d *decoder) decodeContainer(major, info byte, arg uint64, depth int) error {
+	kind, itemsPerEntry := Array, uint64(1)
+	if major == majorMap {
+		kind, itemsPerEntry = Map, 2
+	}
+	idx := d.push(node{kind: kind})
+
+	var count uint64
+	if info != infoIndefinite {
+		// Every data item takes at least one byte, which bounds any sane length.
+		if arg > uint64(len(d.b)-d.off)/itemsPerEntry {
+			return io.ErrUnexpectedEOF
+		}
+		for count = 0; count < arg*itemsPerEntry; count++ {
+			if err := d.decodeItem(depth + 1); err != nil {
+				return err
+			}
+		}
+	} else {
+		for {
+			if d.off >= len(d.b) {
+				return io.ErrUnexpectedEOF
+			}
+			if d.b[d.off] == breakCode {
+				d.off++
+				break
+			}
+			if err := d.decodeItem(depth + 1); err != nil {
+				return err
+			}
+			count++
+		}
+		if count%itemsPerEntry != 0 {
+			return fmt.Errorf("%w: map with an odd number of items", ErrMalformed)
+		}
+	}
+	if count/itemsPerEntry > math.MaxUint32 {
+		return fmt.Errorf("%w: too many elements", ErrMalformed)
+	}
+	d.nodes[idx].n = uint32(count / itemsPerEntry)
+	d.nodes[idx].end = uint32(len(d.nodes))
+	return nil
+}
+
+func (d *decoder) decodeSimple(info byte, arg uint64) error {
+	switch info {
+	case 20, 21:
+		d.push(node{kind: Bool, u: uint64(info - 20)})
+	case 22:
+		d.push(node{kind: Null})
+	case 23:
+		d.push(node{kind: Undefined})
+	case 24:
+		if arg < 32 {
+			return fmt.Errorf("%w: invalid simple value encoding", ErrMalformed)
+		}
+		d.push(node{kind: Simple, u: arg})
+	case 25:
+		d.push(node{kind: Float, u: math.Float64bits(float16ToFloat64(uint16(arg)))})
+	case 26:
+		d.push(node{kind: Float, u: math.Float64bits(float64(math.Float32frombits(uint32(arg))))})
+	case 27:
+		d.push(node{kind: Float, u: arg})
+	case infoIndefinite:
+		return fmt.Errorf("%w: unexpected break", ErrMalformed)
+	default:
+		d.push(node{kind: Simple, u: arg})
+	}
+	d.nodes[len(d.nodes)-1].end = uint32(len(d.nodes))
+	return nil
+}
+
+func float16ToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	default:
+		return sign * math.Ldexp(mant+1024, exp-25)
+	}
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukecbor
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeScalars(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1, nuke.WithStrictMode())
+
+	// Test vectors from RFC 8949, appendix A
+	for _, tc := range []struct {
+		in   string
+		kind Kind
+		want any
+	}{
+		{"00", Uint, int64(0)},
+		{"17", Uint, int64(23)},
+		{"1818", Uint, int64(24)},
+		{"1903e8", Uint, int64(1000)},
+		{"1b000000e8d4a51000", Uint, int64(1000000000000)},
+		{"20", NegInt, int64(-1)},
+		{"3903e7", NegInt, int64(-1000)},
+		{"f90000", Float, 0.0},
+		{"f93c00", Float, 1.0},
+		{"f97bff", Float, 65504.0},
+		{"f90001", Float, 5.960464477539063e-8},
+		{"f9c400", Float, -4.0},
+		{"f97c00", Float, math.Inf(1)},
+		{"f9fc00", Float, math.Inf(-1)},
+		{"fa47c35000", Float, 100000.0},
+		{"fb3ff199999999999a", Float, 1.1},
+		{"f4", Bool, false},
+		{"f5", Bool, true},
+		{"f6", Null, nil},
+		{"f7", Undefined, nil},
+		{"f0", Simple, uint64(16)},
+		{"f8ff", Simple, uint64(255)},
+		{"40", Bytes, []byte{}},
+		{"4401020304", Bytes, []byte{1, 2, 3, 4}},
+		{"60", Text, ""},
+		{"6161", Text, "a"},
+		{"62c3bc", Text, "ü"},
+		{"5f42010243030405ff", Bytes, []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", Text, "streaming"},
+	} {
+		v := decodeHex(t, arena, tc.in)
+		require.Equal(t, tc.kind, v.Kind(), tc.in)
+
+		switch want := tc.want.(type) {
+		case int64:
+			got, ok := v.Int()
+			require.True(t, ok, tc.in)
+			require.Equal(t, want, got, tc.in)
+		case float64:
+			require.Equal(t, want, v.Float(), tc.in)
+		case bool:
+			require.Equal(t, want, v.Bool(), tc.in)
+		case uint64:
+			require.Equal(t, want, v.Uint(), tc.in)
+		case []byte:
+			require.Equal(t, want, v.Bytes(), tc.in)
+		case string:
+			require.Equal(t, want, v.Text(), tc.in)
+		}
+	}
+
+	require.True(t, math.IsNaN(decodeHex(t, arena, "f97e00").Float()))
+
+	v := decodeHex(t, arena, "1bffffffffffffffff")
+	require.Equal(t, uint64(math.MaxUint64), v.Uint())
+	_, ok := v.Int()
+	require.False(t, ok)
+}
+
+func TestDecodeContainers(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1, nuke.WithStrictMode())
+
+	for _, in := range []string{"8301820203820405", "9f018202039f0405ffff", "83018202039f0405ff"} {
+		v := decodeHex(t, arena, in)
+		require.Equal(t, Array, v.Kind())
+		require.Equal(t, 3, v.Len())
+		one, _ := v.Index(0).Int()
+		require.Equal(t, int64(1), one)
+		require.Equal(t, 2, v.Index(2).Len())
+		five, _ := v.Index(2).Index(1).Int()
+		require.Equal(t, int64(5), five)
+		require.Equal(t, Invalid, v.Index(3).Kind())
+	}
+
+	for _, in := range []string{"a26161016162820203", "bf61610161629f0203ffff"} {
+		v := decodeHex(t, arena, in)
+		require.Equal(t, Map, v.Kind())
+		require.Equal(t, 2, v.Len())
+
+		k, val := v.Entry(1)
+		require.Equal(t, "b", k.Text())
+		require.Equal(t, 2, val.Len())
+
+		a, ok := v.Lookup("a")
+		require.True(t, ok)
+		one, _ := a.Int()
+		require.Equal(t, int64(1), one)
+
+		_, ok = v.Lookup("c")
+		require.False(t, ok)
+	}
+
+	v := decodeHex(t, arena, "c074323031332d30332d32315432303a30343a30305a")
+	require.Equal(t, Tag, v.Kind())
+	require.Equal(t, uint64(0), v.Uint())
+	require.Equal(t, "2013-03-21T20:04:00Z", v.Content().Text())
+
+	// Iteration over keys and values
+	v = decodeHex(t, arena, "a201020304")
+	var items []int64
+	for it := v.Items(); ; {
+		item, ok := it.Next()
+		if !ok {
+			break
+		}
+		n, _ := item.Int()
+		items = append(items, n)
+	}
+	require.Equal(t, []int64{1, 2, 3, 4}, items)
+}
+
+func TestDecodeRest(t *testing.T) {
+	arena := nuke.NewMonotonicArena(1024, 1)
+
+	v, rest, err := Decode(arena, []byte{0x61, 'x', 0x01, 0x02})
+	require.NoError(t, err)
+	require.Equal(t, "x", v.Text())
+	require.Equal(t, []byte{0x01, 0x02}, rest)
+}
+
+func TestDecodeArenaResidency(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1)
+
+	in, _ := hex.DecodeString("a26161016162820203")
+	v, _, err := Decode(arena, in)
+	require.NoError(t, err)
+
+	// Strings are copied out of the input
+	k, _ := v.Entry(0)
+	in[3] = 'z'
+	require.Equal(t, "a", k.Text())
+}
+
+func TestDecodeErrors(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1)
+
+	for _, tc := range []struct {
+		in  string
+		err error
+	}{
+		{"", io.ErrUnexpectedEOF},
+		{"18", io.ErrUnexpectedEOF},
+		{"62c3", io.ErrUnexpectedEOF},
+		{"83", io.ErrUnexpectedEOF},
+		{"9f01", io.ErrUnexpectedEOF},
+		{"9b00000000ffffffff00", io.ErrUnexpectedEOF},
+		{"1c", ErrMalformed},
+		{"ff", ErrMalformed},
+		{"1f", ErrMalformed},
+		{"f801", ErrMalformed},
+		{"5f6161ff", ErrMalformed},
+		{"5f5f4101ffff", ErrMalformed},
+		{"bf01ff", ErrMalformed},
+		{"62c328", ErrMalformed},
+		{strings.Repeat("81", MaxDepth+1) + "00", ErrMaxDepth},
+	} {
+		b, err := hex.DecodeString(tc.in)
+		require.NoError(t, err)
+		_, _, err = Decode(arena, b)
+		require.ErrorIs(t, err, tc.err, tc.in)
+	}
+}
+
+func decodeHex(t *testing.T, a nuke.Arena, s string) Value {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	v, rest, err := Decode(a, b)
+	require.NoError(t, err, s)
+	require.Empty(t, rest, s)
+	return v
+}
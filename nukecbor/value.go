@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukecbor
+
+import (
+	"math"
+
+	"github.com/ortuman/nuke"
+)
+
+// Kind is the kind of a decoded data item.
+type Kind uint8
+
+const (
+	// Invalid is the kind of the zero Value.
+	Invalid Kind = iota
+
+	// Uint is the kind of unsigned integers (major type 0).
+	Uint
+
+	// NegInt is the kind of negative integers (major type 1).
+	NegInt
+
+	// Bytes is the kind of byte strings (major type 2).
+	Bytes
+
+	// Text is the kind of UTF-8 text strings (major type 3).
+	Text
+
+	// Array is the kind of arrays of data items (major type 4).
+	Array
+
+	// Map is the kind of maps of pairs of data items (major type 5).
+	Map
+
+	// Tag is the kind of tagged data items (major type 6).
+	Tag
+
+	// Bool is the kind of the false and true simple values.
+	Bool
+
+	// Null is the kind of the null simple value.
+	Null
+
+	// Undefined is the kind of the undefined simple value.
+	Undefined
+
+	// Float is the kind of half, single and double precision floating point numbers.
+	Float
+
+	// Simple is the kind of unassigned simple values.
+	Simple
+)
+
+var kindNames = [...]string{
+	Invalid:   "invalid",
+	Uint:      "uint",
+	NegInt:    "negint",
+	Bytes:     "bytes",
+	Text:      "text",
+	Array:     "array",
+	Map:       "map",
+	Tag:       "tag",
+	Bool:      "bool",
+	Null:      "null",
+	Undefined: "undefined",
+	Float:     "float",
+	Simple:    "simple",
+}
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Value is a decoded data item. Accessors return the zero value of their result type
+// when invoked on a value of a different kind.
+type Value struct {
+	nodes []node
+	data  []byte
+	idx   uint32
+}
+
+func (v Value) node() node {
+	if int(v.idx) >= len(v.nodes) {
+		return node{}
+	}
+	return v.nodes[v.idx]
+}
+
+func (v Value) at(idx uint32) Value {
+	return Value{nodes: v.nodes, data: v.data, idx: idx}
+}
+
+// Kind returns the kind of the data item.
+func (v Value) Kind() Kind {
+	return v.node().kind
+}
+
+// Uint returns the value of an unsigned integer, the number of a tag or an unassigned simple value.
+func (v Value) Uint() uint64 {
+	switch n := v.node(); n.kind {
+	case Uint, Tag, Simple:
+		return n.u
+	}
+	return 0
+}
+
+// Int returns the value of an integer, reporting false if it does not fit an int64.
+func (v Value) Int() (int64, bool) {
+	switch n := v.node(); n.kind {
+	case Uint:
+		return int64(n.u), n.u <= math.MaxInt64
+	case NegInt:
+		return -1 - int64(n.u), n.u <= math.MaxInt64
+	}
+	return 0, false
+}
+
+// Float returns the value of a floating point number.
+func (v Value) Float() float64 {
+	if n := v.node(); n.kind == Float {
+		return math.Float64frombits(n.u)
+	}
+	return 0
+}
+
+// Bool returns the value of a boolean.
+func (v Value) Bool() bool {
+	n := v.node()
+	return n.kind == Bool && n.u != 0
+}
+
+// Bytes returns the contents of a byte or text string, allocated from the arena.
+func (v Value) Bytes() []byte {
+	switch n := v.node(); n.kind {
+	case Bytes, Text:
+		return v.data[n.u : n.u+uint64(n.n) : n.u+uint64(n.n)]
+	}
+	return nil
+}
+
+// Text returns the contents of a byte or text string, allocated from the arena.
+func (v Value) Text() string {
+	return nuke.SealString(v.Bytes())
+}
+
+// Len returns the number of elements of an array, the number of pairs of a map, or the length of a string.
+func (v Value) Len() int {
+	switch n := v.node(); n.kind {
+	case Array, Map, Bytes, Text:
+		return int(n.n)
+	}
+	return 0
+}
+
+// Content returns the data item enclosed by a tag.
+func (v Value) Content() Value {
+	if v.Kind() != Tag {
+		return Value{}
+	}
+	return v.at(v.idx + 1)
+}
+
+// Index returns the i-th element of an array.
+func (v Value) Index(i int) Value {
+	if v.Kind() != Array || i < 0 || i >= v.Len() {
+		return Value{}
+	}
+	it := v.Items()
+	for ; i > 0; i-- {
+		it.Next()
+	}
+	item, _ := it.Next()
+	return item
+}
+
+// Entry returns the key and value of the i-th pair of a map.
+func (v Value) Entry(i int) (key, value Value) {
+	if v.Kind() != Map || i < 0 || i >= v.Len() {
+		return Value{}, Value{}
+	}
+	it := v.Items()
+	for ; i > 0; i-- {
+		it.Next()
+		it.Next()
+	}
+	key, _ = it.Next()
+	value, _ = it.Next()
+	return key, value
+}
+
+// Lookup returns the value of the first pair of a map whose key is the given text string.
+func (v Value) Lookup(key string) (Value, bool) {
+	if v.Kind() != Map {
+		return Value{}, false
+	}
+	it := v.Items()
+	for {
+		k, ok := it.Next()
+		if !ok {
+			return Value{}, false
+		}
+		val, _ := it.Next()
+		if k.Kind() == Text && k.Text() == key {
+			return val, true
+		}
+	}
+}
+
+// Items returns an iterator over the elements of an array, or over the keys and values of a map,
+// which are yielded alternately.
+func (v Value) Items() Iter {
+	switch n := v.node(); n.kind {
+	case Array, Map:
+		return Iter{v: v.at(v.idx + 1), end: n.end}
+	}
+	return Iter{}
+}
+
+// Iter iterates over the data items enclosed by an array or map.
+type Iter struct {
+	v   Value
+	end uint32
+}
+
+// Next returns the next data item, reporting false once the iteration is over.
+func (it *Iter) Next() (Value, bool) {
+	if it.v.idx >= it.end {
+		return Value{}, false
+	}
+	v := it.v
+	it.v = v.at(v.node().end)
+	return v, true
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukecsv reads CSV records into memory allocated from a nuke arena.
+//
+// Every record slice, along with the contents of its fields, is allocated from the arena, so that
+// reading a file takes a single heap allocation per record, made internally by encoding/csv, regardless
+// of its number of fields. Records remain valid until the arena is reset, which is typically done once
+// per file or per batch of records.
+package nukecsv
+
+import (
+	"encoding/csv"
+	"io"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+)
+
+// Reader reads records from a CSV encoded file. It wraps a csv.Reader, whose fields can be set to
+// customize the parsing, except for ReuseRecord, which must remain enabled.
+type Reader struct {
+	*csv.Reader
+	a nuke.Arena
+}
+
+// NewReader returns a new Reader reading from r and allocating records from the provided arena, or from the heap
+// if it is nil. Record slices are allocated from the arena regardless of its PointerPolicy, even though the strings
+// they hold are pointers: this is sound because the records are only allocated from the arena along with the
+// contents of their fields, hence they only point to memory the arena keeps alive for as long as they are valid.
+func NewReader(a nuke.Arena, r io.Reader) *Reader {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	return &Reader{Reader: cr, a: a}
+}
+
+// Read reads one record from r, with the same semantics as csv.Reader.Read.
+// The returned record and its fields are allocated from the arena and remain valid until the arena is reset.
+func (r *Reader) Read() ([]string, error) {
+	record, err := r.Reader.Read()
+	if record == nil {
+		return nil, err
+	}
+	return r.copyRecord(record), err
+}
+
+// ReadAll reads all the remaining records from r, with the same semantics as csv.Reader.ReadAll.
+// Every record is allocated from the arena, whereas the returned slice of records is allocated on the heap.
+func (r *Reader) ReadAll() ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func (r *Reader) copyRecord(record []string) []string {
+	size := 0
+	for _, field := range record {
+		size += len(field)
+	}
+
+	// Field headers are only allocated from the arena along with the contents they point to,
+	// as arena memory is not scanned by the garbage collector.
+	data, fields := alloc[byte](r.a, size), []string(nil)
+	if data != nil || size == 0 {
+		fields = alloc[string](r.a, len(record))
+	}
+	if data == nil {
+		data = make([]byte, size)
+	}
+	if fields == nil {
+		fields = make([]string, len(record))
+	}
+
+	for i, field := range record {
+		n := copy(data, field)
+		fields[i] = nuke.SealString(data[:n:n])
+		data = data[n:]
+	}
+	return fields
+}
+
+// alloc allocates a slice of n elements from the arena, bypassing its pointer policy as NewReader explains,
+// or returns nil if the arena is nil or exhausted.
+func alloc[T any](a nuke.Arena, n int) []T {
+	if a == nil || n == 0 {
+		return nil
+	}
+	var x T
+	ptr := a.Alloc(unsafe.Sizeof(x)*uintptr(n), unsafe.Alignof(x))
+	if ptr == nil {
+		return nil
+	}
+	return unsafe.Slice((*T)(ptr), n)
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukeflatbuffers lets FlatBuffers builders obtain their buffers from a nuke arena rather than from the heap,
+// which ties the lifetime of the messages they build to the arena, as zero-copy RPC servers need.
+package nukeflatbuffers
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/ortuman/nuke"
+)
+
+// NewBuilder returns a FlatBuffers builder whose buffer of size bytes is allocated from a. Messages larger than size
+// grow the buffer on the heap, as FlatBuffers does not let the allocation of the buffer be customized, hence size
+// should fit the largest message expected. The builder can be reused by means of its Reset method until the arena
+// is reset, which invalidates the buffer along with the messages built into it.
+func NewBuilder(a nuke.Arena, size int) *flatbuffers.Builder {
+	b := flatbuffers.NewBuilder(0)
+	b.Bytes = nuke.MakeSlice[byte](a, size, size)
+	b.Reset() // makes the builder write from the end of the buffer
+	return b
+}
+
+// FinishedBytes returns a copy of the message finished by b allocated from a, so that it remains valid once b is
+// reset and reused, until the arena is reset.
+func FinishedBytes(a nuke.Arena, b *flatbuffers.Builder) []byte {
+	msg := b.FinishedBytes()
+	c := nuke.MakeSlice[byte](a, len(msg), len(msg))
+	if c == nil {
+		c = make([]byte, len(msg)) // the arena is exhausted
+	}
+	copy(c, msg)
+	return c
+}
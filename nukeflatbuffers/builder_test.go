@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukeflatbuffers
+
+import (
+	"testing"
+	"unsafe"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+// buildGreeting builds a table holding a single string, along the lines of the code flatc generates.
+func buildGreeting(b *flatbuffers.Builder, greeting string) {
+	s := b.CreateString(greeting)
+	b.StartObject(1)
+	b.PrependUOffsetTSlot(0, s, 0)
+	b.Finish(b.EndObject())
+}
+
+func readGreeting(buf []byte) string {
+	var t flatbuffers.Table
+	t.Bytes = buf
+	t.Pos = flatbuffers.GetUOffsetT(buf)
+	if o := flatbuffers.UOffsetT(t.Offset(4)); o != 0 {
+		return string(t.ByteVector(o + t.Pos))
+	}
+	return ""
+}
+
+func TestBuilder(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1)
+	b := NewBuilder(arena, 1024)
+
+	buildGreeting(b, "hello")
+	msg := b.FinishedBytes()
+	require.True(t, nuke.Owns(arena, unsafe.Pointer(&msg[0])))
+	require.Equal(t, "hello", readGreeting(msg))
+
+	kept := FinishedBytes(arena, b)
+	b.Reset()
+	buildGreeting(b, "world")
+	require.Equal(t, "hello", readGreeting(kept))
+	require.Equal(t, "world", readGreeting(b.FinishedBytes()))
+	require.True(t, nuke.Owns(arena, unsafe.Pointer(&b.FinishedBytes()[0])))
+}
+
+func TestFinishedBytesExhaustedArena(t *testing.T) {
+	b := flatbuffers.NewBuilder(0)
+	buildGreeting(b, "hello")
+
+	arena := nuke.NewMonotonicArena(16, 1, nuke.WithMaxBytes(16),
+		nuke.WithOnExhausted(func(nuke.Exhaustion) nuke.ExhaustedAction { return nuke.ExhaustedReturnNil }))
+	kept := FinishedBytes(arena, b)
+	require.False(t, nuke.Owns(arena, unsafe.Pointer(&kept[0])))
+	require.Equal(t, "hello", readGreeting(kept))
+}
+
+func TestBuilderGrowsOnHeap(t *testing.T) {
+	arena := nuke.NewMonotonicArena(64*1024, 1)
+	b := NewBuilder(arena, 16)
+
+	buildGreeting(b, "a greeting longer than the buffer")
+	msg := b.FinishedBytes()
+	require.False(t, nuke.Owns(arena, unsafe.Pointer(&msg[0])))
+	require.Equal(t, "a greeting longer than the buffer", readGreeting(msg))
+}
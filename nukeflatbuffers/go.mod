@@ -0,0 +1,17 @@
+module github.com/ortuman/nuke/nukeflatbuffers
+
+go 1.21.7
+
+replace github.com/ortuman/nuke => ../
+
+require (
+	github.com/google/flatbuffers v24.3.25+incompatible
+	github.com/ortuman/nuke v0.0.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukegrpc provides gRPC server interceptors attaching a pooled nuke arena to the context of every RPC.
+//
+// Handlers retrieve the arena by means of nuke.ExtractContextArena, and the arena is reset and returned
+// to the pool as soon as the handler completes, even if it panics.
+package nukegrpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ortuman/nuke"
+	"google.golang.org/grpc"
+)
+
+// arenaPool is a pool of arenas which are reset before being reused.
+type arenaPool struct {
+	p sync.Pool
+}
+
+func newArenaPool(newArena func() nuke.Arena) *arenaPool {
+	return &arenaPool{p: sync.Pool{New: func() any { return newArena() }}}
+}
+
+func (p *arenaPool) get() nuke.Arena {
+	return p.p.Get().(nuke.Arena)
+}
+
+func (p *arenaPool) put(a nuke.Arena) {
+	a.Reset(false)
+	p.p.Put(a)
+}
+
+// UnaryServerInterceptor returns a server interceptor that attaches an arena obtained from a pool
+// to the context of every unary RPC. Arenas are created by newArena whenever the pool is empty.
+//
+// Reply messages are serialized by gRPC after the interceptor returns, at which point the arena has
+// already been reset. Hence, replies must not reference memory allocated from the arena.
+func UnaryServerInterceptor(newArena func() nuke.Arena) grpc.UnaryServerInterceptor {
+	pool := newArenaPool(newArena)
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		arena := pool.get()
+		defer pool.put(arena)
+
+		return handler(nuke.InjectContextArena(ctx, arena), req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor that attaches an arena obtained from a pool
+// to the context of every streaming RPC. Arenas are created by newArena whenever the pool is empty.
+//
+// Messages sent through the stream are serialized before SendMsg returns, so they can freely
+// reference memory allocated from the arena.
+func StreamServerInterceptor(newArena func() nuke.Arena) grpc.StreamServerInterceptor {
+	pool := newArenaPool(newArena)
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		arena := pool.get()
+		defer pool.put(arena)
+
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          nuke.InjectContextArena(ss.Context(), arena),
+		})
+	}
+}
+
+// serverStream wraps a grpc.ServerStream to override its context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context satisfies the grpc.ServerStream interface.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukegrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// trackingArena records the number of times it has been reset.
+type trackingArena struct {
+	nuke.Arena
+	resets int
+}
+
+func (a *trackingArena) Reset(release bool) {
+	a.resets++
+	a.Arena.Reset(release)
+}
+
+func newTrackingArena(arenas *[]*trackingArena) func() nuke.Arena {
+	return func() nuke.Arena {
+		a := &trackingArena{Arena: nuke.NewMonotonicArena(1024, 1)}
+		*arenas = append(*arenas, a)
+		return a
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var arenas []*trackingArena
+	interceptor := UnaryServerInterceptor(newTrackingArena(&arenas))
+
+	errHandler := errors.New("handler error")
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		arena := nuke.ExtractContextArena(ctx)
+		require.NotNil(t, arena)
+		require.Equal(t, 0, arenas[0].resets)
+		return req, errHandler
+	})
+	require.Equal(t, "req", resp)
+	require.ErrorIs(t, err, errHandler)
+	require.Equal(t, 1, totalResets(arenas))
+
+	// The arena is reset on panic as well
+	require.Panics(t, func() {
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) {
+			panic("boom")
+		})
+	})
+	require.Equal(t, 2, totalResets(arenas))
+}
+
+func totalResets(arenas []*trackingArena) int {
+	var n int
+	for _, a := range arenas {
+		n += a.resets
+	}
+	return n
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var arenas []*trackingArena
+	interceptor := StreamServerInterceptor(newTrackingArena(&arenas))
+
+	type ctxKey struct{}
+	ss := &mockServerStream{ctx: context.WithValue(context.Background(), ctxKey{}, "value")}
+
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{}, func(_ any, stream grpc.ServerStream) error {
+		require.NotNil(t, nuke.ExtractContextArena(stream.Context()))
+		require.Equal(t, "value", stream.Context().Value(ctxKey{}))
+		require.Equal(t, 0, arenas[0].resets)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, totalResets(arenas))
+}
@@ -0,0 +1,6 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package messagetest exercises the code generated by nukegen messages for the messages of messages.proto.
+package messagetest
+
+//go:generate go run github.com/ortuman/nuke/cmd/nukegen messages -types Order
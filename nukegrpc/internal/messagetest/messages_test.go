@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package messagetest
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func newOrder() *Order {
+	priority := int32(-3)
+	return &Order{
+		Id:         1 << 40,
+		Symbol:     "NUKE",
+		Side:       Side_SIDE_SELL,
+		Delta:      -42,
+		Price:      101.25,
+		Flags:      0xdeadbeef,
+		Urgent:     true,
+		Token:      []byte{1, 2, 3},
+		Quantities: []int32{1, -1, 1 << 20},
+		Tags:       []string{"a", "", "ü"},
+		Buyer:      &Party{Name: "acme", Account: 7},
+		Fills: []*Fill{
+			{Quantity: 10, Price: 1.5, Ids: []uint64{1, 2}, Weights: []float32{0.5}, Offsets: []int32{-1, 1}},
+			{Chunks: [][]byte{{0xff}, {}}},
+		},
+		Priority: &priority,
+		Parent:   &Order{Id: 1, Symbol: "parent", Sides: []Side{Side_SIDE_BUY}},
+		Stamp:    -1,
+		Ratio:    0.25,
+		Skew:     -1 << 30,
+		Venue:    -7,
+		Sides:    []Side{Side_SIDE_BUY, Side_SIDE_SELL},
+	}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	arena := nuke.NewSafeArena(4096)
+	want := newOrder()
+	b, err := proto.Marshal(want)
+	require.NoError(t, err)
+
+	got, err := UnmarshalOrder(arena, b)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(want, got), "got %v", got)
+
+	// The whole message tree lives in the arena
+	for _, p := range []unsafe.Pointer{
+		unsafe.Pointer(got),
+		unsafe.Pointer(unsafe.StringData(got.Symbol)),
+		unsafe.Pointer(&got.Token[0]),
+		unsafe.Pointer(&got.Quantities[0]),
+		unsafe.Pointer(got.Buyer),
+		unsafe.Pointer(got.Fills[1]),
+		unsafe.Pointer(&got.Fills[0].Ids[0]),
+		unsafe.Pointer(got.Priority),
+		unsafe.Pointer(got.Parent),
+	} {
+		require.True(t, nuke.Owns(arena, p))
+	}
+
+	// The decoded messages are usable by the protobuf runtime
+	again, err := proto.Marshal(got)
+	require.NoError(t, err)
+	require.Equal(t, b, again)
+}
+
+func TestUnmarshalMatchesProtoUnmarshal(t *testing.T) {
+	arena := nuke.NewMonotonicArena(4096, 1, nuke.WithPointerPolicy(nuke.PointerFallback))
+	b, err := proto.Marshal(newOrder())
+	require.NoError(t, err)
+
+	// Unknown fields are retained, messages occurring twice are merged, and unpacked repeated scalars are accepted
+	b = protowire.AppendTag(b, 99, protowire.BytesType)
+	b = protowire.AppendString(b, "unknown")
+	b = protowire.AppendTag(b, 11, protowire.BytesType)
+	b = protowire.AppendBytes(b, protowire.AppendVarint(protowire.AppendTag(nil, 2, protowire.VarintType), 8))
+	b = protowire.AppendTag(b, 9, protowire.VarintType)
+	b = protowire.AppendVarint(b, 5)
+
+	want := &Order{}
+	require.NoError(t, proto.Unmarshal(b, want))
+	got, err := UnmarshalOrder(arena, b)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(want, got), "got %v", got)
+	require.Equal(t, want.ProtoReflect().GetUnknown(), got.ProtoReflect().GetUnknown())
+	require.Equal(t, "acme", got.Buyer.Name)
+	require.Equal(t, uint32(8), got.Buyer.Account)
+
+	// Strings live in the arena, unlike messages, which the pointer policy keeps on the heap
+	require.True(t, nuke.Owns(arena, unsafe.Pointer(unsafe.StringData(got.Symbol))))
+	require.False(t, nuke.Owns(arena, unsafe.Pointer(got.Buyer)))
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	arena := nuke.NewSafeArena(1024)
+	b, err := proto.Marshal(newOrder())
+	require.NoError(t, err)
+
+	_, err = UnmarshalOrder(arena, b[:len(b)-1])
+	require.Error(t, err)
+
+	invalid := protowire.AppendString(protowire.AppendTag(nil, 2, protowire.BytesType), "\xff")
+	_, err = UnmarshalOrder(arena, invalid)
+	require.ErrorIs(t, err, errNukeInvalidUTF8)
+
+	var nested []byte
+	for i := 0; i < protowire.DefaultRecursionLimit; i++ {
+		nested = protowire.AppendBytes(protowire.AppendTag(nil, 14, protowire.BytesType), nested)
+	}
+	_, err = UnmarshalOrder(arena, nested)
+	require.ErrorIs(t, err, errNukeRecursionLimit)
+}
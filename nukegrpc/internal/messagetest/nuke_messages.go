@@ -0,0 +1,413 @@
+// Code generated by nukegen messages; DO NOT EDIT.
+
+package messagetest
+
+import (
+	"errors"
+	"math"
+	"unicode/utf8"
+
+	"github.com/ortuman/nuke"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var errNukeRecursionLimit = errors.New("nuke: exceeded maximum recursion depth")
+var errNukeInvalidUTF8 = errors.New("nuke: string field contains invalid UTF-8")
+
+// nukeNew allocates a message or a scalar from the arena, falling back to the heap once it is exhausted.
+func nukeNew[T any](a nuke.Arena) *T {
+	if p := nuke.New[T](a); p != nil {
+		return p
+	}
+	return new(T) // the arena is exhausted
+}
+
+// nukeBytes copies b to the arena.
+func nukeBytes(a nuke.Arena, b []byte) []byte {
+	return nuke.SliceAppend(a, []byte(nil), b...)
+}
+
+// UnmarshalOrder decodes the protobuf wire encoding of Order held by b into a message tree allocated from the arena.
+// Messages hold pointers, which the arena must keep visible to the GC, as SafeArena or the PointerFallback policy do.
+func UnmarshalOrder(a nuke.Arena, b []byte) (*Order, error) {
+	m := nukeNew[Order](a)
+	if err := nukeUnmarshalOrder(a, b, m, protowire.DefaultRecursionLimit); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func nukeUnmarshalOrder(a nuke.Arena, b []byte, m *Order, depth int) error {
+	if depth == 0 {
+		return errNukeRecursionLimit
+	}
+	for len(b) > 0 {
+		field := b
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Id = v
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if !utf8.Valid(v) {
+				return errNukeInvalidUTF8
+			}
+			m.Symbol = nuke.SealString(nukeBytes(a, v))
+		case num == 3 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Side = Side(v)
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Delta = protowire.DecodeZigZag(v)
+		case num == 5 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Price = math.Float64frombits(v)
+		case num == 6 && typ == protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Flags = v
+		case num == 7 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Urgent = protowire.DecodeBool(v)
+		case num == 8 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Token = nukeBytes(a, v)
+		case num == 9 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				packed = packed[n:]
+				m.Quantities = nuke.SliceAppend(a, m.Quantities, int32(v))
+			}
+		case num == 9 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Quantities = nuke.SliceAppend(a, m.Quantities, int32(v))
+		case num == 10 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if !utf8.Valid(v) {
+				return errNukeInvalidUTF8
+			}
+			m.Tags = nuke.SliceAppend(a, m.Tags, nuke.SealString(nukeBytes(a, v)))
+		case num == 11 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if m.Buyer == nil {
+				m.Buyer = nukeNew[Party](a)
+			}
+			if err := nukeUnmarshalParty(a, v, m.Buyer, depth-1); err != nil {
+				return err
+			}
+		case num == 12 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			e := nukeNew[Fill](a)
+			if err := nukeUnmarshalFill(a, v, e, depth-1); err != nil {
+				return err
+			}
+			m.Fills = nuke.SliceAppend(a, m.Fills, e)
+		case num == 13 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Priority = nukeNew[int32](a)
+			*m.Priority = int32(v)
+		case num == 14 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if m.Parent == nil {
+				m.Parent = nukeNew[Order](a)
+			}
+			if err := nukeUnmarshalOrder(a, v, m.Parent, depth-1); err != nil {
+				return err
+			}
+		case num == 15 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Stamp = int64(v)
+		case num == 16 && typ == protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Ratio = math.Float32frombits(v)
+		case num == 17 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Skew = int32(protowire.DecodeZigZag(v & math.MaxUint32))
+		case num == 18 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Venue = int64(v)
+		case num == 19 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				packed = packed[n:]
+				m.Sides = nuke.SliceAppend(a, m.Sides, Side(v))
+			}
+		case num == 19 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Sides = nuke.SliceAppend(a, m.Sides, Side(v))
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.unknownFields = nuke.SliceAppend(a, m.unknownFields, field[:len(field)-len(b)]...)
+		}
+	}
+	return nil
+}
+
+// UnmarshalParty decodes the protobuf wire encoding of Party held by b into a message tree allocated from the arena.
+// Messages hold pointers, which the arena must keep visible to the GC, as SafeArena or the PointerFallback policy do.
+func UnmarshalParty(a nuke.Arena, b []byte) (*Party, error) {
+	m := nukeNew[Party](a)
+	if err := nukeUnmarshalParty(a, b, m, protowire.DefaultRecursionLimit); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func nukeUnmarshalParty(a nuke.Arena, b []byte, m *Party, depth int) error {
+	if depth == 0 {
+		return errNukeRecursionLimit
+	}
+	for len(b) > 0 {
+		field := b
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			if !utf8.Valid(v) {
+				return errNukeInvalidUTF8
+			}
+			m.Name = nuke.SealString(nukeBytes(a, v))
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Account = uint32(v)
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.unknownFields = nuke.SliceAppend(a, m.unknownFields, field[:len(field)-len(b)]...)
+		}
+	}
+	return nil
+}
+
+// UnmarshalFill decodes the protobuf wire encoding of Fill held by b into a message tree allocated from the arena.
+// Messages hold pointers, which the arena must keep visible to the GC, as SafeArena or the PointerFallback policy do.
+func UnmarshalFill(a nuke.Arena, b []byte) (*Fill, error) {
+	m := nukeNew[Fill](a)
+	if err := nukeUnmarshalFill(a, b, m, protowire.DefaultRecursionLimit); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func nukeUnmarshalFill(a nuke.Arena, b []byte, m *Fill, depth int) error {
+	if depth == 0 {
+		return errNukeRecursionLimit
+	}
+	for len(b) > 0 {
+		field := b
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Quantity = int64(v)
+		case num == 2 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Price = math.Float64frombits(v)
+		case num == 3 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeFixed64(packed)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				packed = packed[n:]
+				m.Ids = nuke.SliceAppend(a, m.Ids, v)
+			}
+		case num == 3 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Ids = nuke.SliceAppend(a, m.Ids, v)
+		case num == 4 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeFixed32(packed)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				packed = packed[n:]
+				m.Weights = nuke.SliceAppend(a, m.Weights, math.Float32frombits(v))
+			}
+		case num == 4 && typ == protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Weights = nuke.SliceAppend(a, m.Weights, math.Float32frombits(v))
+		case num == 5 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			for len(packed) > 0 {
+				v, n := protowire.ConsumeVarint(packed)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				packed = packed[n:]
+				m.Offsets = nuke.SliceAppend(a, m.Offsets, int32(protowire.DecodeZigZag(v&math.MaxUint32)))
+			}
+		case num == 5 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Offsets = nuke.SliceAppend(a, m.Offsets, int32(protowire.DecodeZigZag(v&math.MaxUint32)))
+		case num == 6 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.Chunks = nuke.SliceAppend(a, m.Chunks, nukeBytes(a, v))
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			m.unknownFields = nuke.SliceAppend(a, m.unknownFields, field[:len(field)-len(b)]...)
+		}
+	}
+	return nil
+}
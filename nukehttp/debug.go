@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukehttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ortuman/nuke"
+)
+
+// DebugHandler returns a handler reporting, as plain text, the arenas registered by means of nuke.Register,
+// along with their statistics, their recent resets and, for those implementing nuke.Dumper, their memory layout.
+// The arena query parameter restricts the report to the arena registered under that name. The handler is
+// usually mounted on /debug/nuke, next to those of net/http/pprof. As nuke.Register only accepts arenas that are
+// safe for concurrent use, they are inspected under their own synchronization while in use.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := nuke.Registered()
+		if name := r.URL.Query().Get("arena"); name != "" {
+			if _, ok := nuke.Lookup(name); !ok {
+				http.Error(w, fmt.Sprintf("arena %q not registered", name), http.StatusNotFound)
+				return
+			}
+			names = []string{name}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d arenas registered\n", len(names))
+		for _, name := range names {
+			if a, ok := nuke.Lookup(name); ok {
+				writeArena(&b, name, a)
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(b.String()))
+	})
+}
+
+func writeArena(b *strings.Builder, name string, a nuke.Arena) {
+	fmt.Fprintf(b, "\narena %s (%T)\n", name, a)
+	if sp, ok := a.(nuke.StatsProvider); ok {
+		s := sp.Stats()
+		fmt.Fprintf(b, "  bytes allocated: %d\n", s.BytesAllocated)
+		fmt.Fprintf(b, "  bytes in use: %d\n", s.BytesInUse)
+		fmt.Fprintf(b, "  high water mark: %d\n", s.HighWaterMark)
+		fmt.Fprintf(b, "  buffers: %d\n", s.Buffers)
+		fmt.Fprintf(b, "  heap fallbacks: %d\n", s.HeapFallbacks)
+		fmt.Fprintf(b, "  pointer allocations: %d\n", s.PointerAllocations)
+		fmt.Fprintf(b, "  resets: %d\n", s.Resets)
+	}
+	if resets := nuke.ResetHistory(name); len(resets) > 0 {
+		fmt.Fprintf(b, "  recent resets:\n")
+		for _, t := range resets {
+			fmt.Fprintf(b, "    %s\n", t.Format(time.RFC3339Nano))
+		}
+	}
+	if d, ok := a.(nuke.Dumper); ok {
+		var layout strings.Builder
+		if err := d.Dump(&layout); err == nil {
+			fmt.Fprintf(b, "  layout:\n")
+			for _, line := range strings.Split(strings.TrimSuffix(layout.String(), "\n"), "\n") {
+				fmt.Fprintf(b, "    %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	mono := nuke.NewConcurrentArena(nuke.NewMonotonicArena(1024, 2))
+	nuke.Register("debug-mono", mono)
+	defer nuke.Unregister("debug-mono")
+	_ = nuke.New[int64](mono)
+	mono.Reset(false)
+	_ = nuke.New[int32](mono)
+	require.Len(t, nuke.ResetHistory("debug-mono"), 1)
+
+	safe := nuke.NewConcurrentSafeArena(64)
+	nuke.Register("debug-safe", safe)
+	defer nuke.Unregister("debug-safe")
+
+	h := DebugHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/nuke", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	require.Contains(t, body, "arena debug-mono (*nuke.concurrentArena)\n  bytes allocated: 1024\n  bytes in use: 4\n")
+	require.Contains(t, body, "  resets: 1\n  recent resets:\n    ")
+	require.Contains(t, body, "  layout:\n    MonotonicArena: 2 buffers, 0 oversized, 4/2048 bytes in use\n"+
+		"      buffer 0: offset 4/1024 (current)\n      buffer 1: unallocated, 1024 bytes\n")
+	require.Contains(t, body, "arena debug-safe (*nuke.concurrentSafeArena)")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/nuke?arena=debug-safe", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, strings.HasPrefix(rec.Body.String(), "1 arenas registered\n\narena debug-safe"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/nuke?arena=missing", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Panics(t, func() { nuke.Register("debug-unsafe", nuke.NewSafeArena(64)) })
+}
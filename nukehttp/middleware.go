@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukehttp provides a net/http middleware injecting a pooled nuke arena into the context of every request.
+//
+// Handlers retrieve the arena by means of nuke.ExtractContextArena, and the arena is reset and returned
+// to the pool once the response has been written, even if the handler panics. Data that must outlive
+// the request has to be copied out of the arena beforehand, for which this package provides a few helpers.
+//
+// The package also provides a debug handler reporting the arenas registered by means of nuke.Register.
+package nukehttp
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/ortuman/nuke"
+)
+
+// Middleware returns a middleware that injects an arena obtained from a pool into the context of every request.
+// Arenas are created by newArena whenever the pool is empty.
+func Middleware(newArena func() nuke.Arena) func(http.Handler) http.Handler {
+	pool := &sync.Pool{New: func() any { return newArena() }}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			arena := pool.Get().(nuke.Arena)
+			defer func() {
+				arena.Reset(false)
+				pool.Put(arena)
+			}()
+			next.ServeHTTP(w, r.WithContext(nuke.InjectContextArena(r.Context(), arena)))
+		})
+	}
+}
+
+// Clone returns a heap-allocated shallow copy of the value pointed by p.
+// Pointers held by the value are copied as is, so they must not reference arena memory
+// if the copy is meant to outlive the request.
+func Clone[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	c := new(T)
+	*c = *p
+	return c
+}
+
+// CloneSlice returns a heap-allocated shallow copy of s.
+// Pointers held by the elements are copied as is, so they must not reference arena memory
+// if the copy is meant to outlive the request.
+func CloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// CloneString returns a heap-allocated copy of s.
+func CloneString(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return string(append(make([]byte, 0, len(s)), s...))
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+	"github.com/stretchr/testify/require"
+)
+
+type trackingArena struct {
+	nuke.Arena
+	resets int
+}
+
+func (a *trackingArena) Reset(release bool) {
+	a.resets++
+	a.Arena.Reset(release)
+}
+
+func TestMiddleware(t *testing.T) {
+	var arenas []*trackingArena
+	mw := Middleware(func() nuke.Arena {
+		a := &trackingArena{Arena: nuke.NewMonotonicArena(1024, 1)}
+		arenas = append(arenas, a)
+		return a
+	})
+
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arena := nuke.ExtractContextArena(r.Context())
+		require.NotNil(t, arena)
+
+		b := nuke.Appendf(arena, nil, "hello %s", r.URL.Query().Get("name"))
+		_, _ = w.Write(b)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=nuke", nil))
+	require.Equal(t, "hello nuke", rec.Body.String())
+	require.Equal(t, 1, totalResets(arenas))
+
+	// The arena is reset on panic as well
+	panicking := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	require.Panics(t, func() {
+		panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	require.Equal(t, 2, totalResets(arenas))
+}
+
+func totalResets(arenas []*trackingArena) int {
+	var n int
+	for _, a := range arenas {
+		n += a.resets
+	}
+	return n
+}
+
+func TestClone(t *testing.T) {
+	arena := nuke.NewMonotonicArena(1024, 1)
+
+	type pair struct{ a, b int }
+	p := nuke.New[pair](arena)
+	p.a, p.b = 1, 2
+	c := Clone(p)
+	require.NotSame(t, p, c)
+	require.Equal(t, *p, *c)
+	require.Nil(t, Clone[pair](nil))
+
+	s := nuke.SliceAppend(arena, nil, 1, 2, 3)
+	cs := CloneSlice(s)
+	require.Equal(t, s, cs)
+	require.NotSame(t, unsafe.SliceData(s), unsafe.SliceData(cs))
+	require.Nil(t, CloneSlice[int](nil))
+
+	str := nuke.FormatInt(arena, 12345, 10)
+	cstr := CloneString(str)
+	require.Equal(t, str, cstr)
+	require.NotSame(t, unsafe.StringData(str), unsafe.StringData(cstr))
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nukeprom provides a Prometheus collector reporting the usage statistics of nuke arenas.
+//
+// Every metric is labeled after the name the arena is reported under, so that the memory held by every
+// arena, along with its high water mark and heap fallbacks, can be broken down on existing dashboards.
+package nukeprom
+
+import (
+	"github.com/ortuman/nuke"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bytesAllocatedDesc = newDesc("bytes_allocated", "Bytes the arena holds to serve allocations from.")
+	bytesInUseDesc     = newDesc("bytes_in_use", "Bytes handed out by the arena since its last reset.")
+	buffersDesc        = newDesc("buffers", "Buffers backing the arena.")
+	highWaterMarkDesc  = newDesc("high_water_mark_bytes", "Maximum number of bytes the arena has had in use.")
+	heapFallbacksDesc  = newDesc("heap_fallbacks_total", "Allocations the arena could not satisfy, which fell back to the heap.")
+	pointerAllocsDesc  = newDesc("pointer_allocations_total", "Allocations of pointer types served from memory the GC does not scan.")
+	resetsDesc         = newDesc("resets_total", "Times the arena has been reset.")
+)
+
+func newDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName("nuke", "arena", name), help, []string{"arena"}, nil)
+}
+
+// Collector is a prometheus.Collector reporting the statistics of a set of arenas.
+// Arenas not implementing nuke.StatsProvider are reported with zero statistics.
+type Collector struct {
+	arenas func(f func(name string, a nuke.Arena))
+}
+
+// NewCollector returns a collector reporting the statistics of the provided arenas, keyed by name. As the arenas
+// are inspected from the goroutines serving scrapes while in use, they must be safe for concurrent use, such as
+// those returned by nuke.NewConcurrentArena, which also wraps any other arena.
+func NewCollector(arenas map[string]nuke.Arena) *Collector {
+	return &Collector{arenas: func(f func(string, nuke.Arena)) {
+		for name, a := range arenas {
+			f(name, a)
+		}
+	}}
+}
+
+// NewRegistryCollector returns a collector reporting the statistics of the arenas registered by means of
+// nuke.Register at the time of every collection, under the names they have been registered with. nuke.Register
+// only accepts arenas safe for concurrent use.
+func NewRegistryCollector() *Collector {
+	return &Collector{arenas: func(f func(string, nuke.Arena)) {
+		for _, name := range nuke.Registered() {
+			if a, ok := nuke.Lookup(name); ok {
+				f(name, a)
+			}
+		}
+	}}
+}
+
+// Describe satisfies the prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bytesAllocatedDesc
+	ch <- bytesInUseDesc
+	ch <- buffersDesc
+	ch <- highWaterMarkDesc
+	ch <- heapFallbacksDesc
+	ch <- pointerAllocsDesc
+	ch <- resetsDesc
+}
+
+// Collect satisfies the prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.arenas(func(name string, a nuke.Arena) {
+		var s nuke.Stats
+		if sp, ok := a.(nuke.StatsProvider); ok {
+			s = sp.Stats()
+		}
+		ch <- prometheus.MustNewConstMetric(bytesAllocatedDesc, prometheus.GaugeValue, float64(s.BytesAllocated), name)
+		ch <- prometheus.MustNewConstMetric(bytesInUseDesc, prometheus.GaugeValue, float64(s.BytesInUse), name)
+		ch <- prometheus.MustNewConstMetric(buffersDesc, prometheus.GaugeValue, float64(s.Buffers), name)
+		ch <- prometheus.MustNewConstMetric(highWaterMarkDesc, prometheus.GaugeValue, float64(s.HighWaterMark), name)
+		ch <- prometheus.MustNewConstMetric(heapFallbacksDesc, prometheus.CounterValue, float64(s.HeapFallbacks), name)
+		ch <- prometheus.MustNewConstMetric(pointerAllocsDesc, prometheus.CounterValue, float64(s.PointerAllocations), name)
+		ch <- prometheus.MustNewConstMetric(resetsDesc, prometheus.CounterValue, float64(s.Resets), name)
+	})
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nukeprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ortuman/nuke"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	arena := nuke.NewConcurrentArena(nuke.NewMonotonicArena(1024, 2))
+	_ = nuke.New[int64](arena)
+	_ = nuke.MakeSlice[byte](arena, 2048, 2048)
+	arena.Reset(false)
+	_ = nuke.New[*int](arena)
+
+	c := NewCollector(map[string]nuke.Arena{"parser": arena})
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP nuke_arena_bytes_allocated Bytes the arena holds to serve allocations from.
+# TYPE nuke_arena_bytes_allocated gauge
+nuke_arena_bytes_allocated{arena="parser"} 1024
+# HELP nuke_arena_bytes_in_use Bytes handed out by the arena since its last reset.
+# TYPE nuke_arena_bytes_in_use gauge
+nuke_arena_bytes_in_use{arena="parser"} 8
+# HELP nuke_arena_buffers Buffers backing the arena.
+# TYPE nuke_arena_buffers gauge
+nuke_arena_buffers{arena="parser"} 2
+# HELP nuke_arena_high_water_mark_bytes Maximum number of bytes the arena has had in use.
+# TYPE nuke_arena_high_water_mark_bytes gauge
+nuke_arena_high_water_mark_bytes{arena="parser"} 8
+# HELP nuke_arena_heap_fallbacks_total Allocations the arena could not satisfy, which fell back to the heap.
+# TYPE nuke_arena_heap_fallbacks_total counter
+nuke_arena_heap_fallbacks_total{arena="parser"} 1
+# HELP nuke_arena_pointer_allocations_total Allocations of pointer types served from memory the GC does not scan.
+# TYPE nuke_arena_pointer_allocations_total counter
+nuke_arena_pointer_allocations_total{arena="parser"} 1
+# HELP nuke_arena_resets_total Times the arena has been reset.
+# TYPE nuke_arena_resets_total counter
+nuke_arena_resets_total{arena="parser"} 1
+`)))
+}
+
+func TestRegistryCollector(t *testing.T) {
+	c := NewRegistryCollector()
+	require.Equal(t, 0, testutil.CollectAndCount(c))
+
+	nuke.Register("a", nuke.NewConcurrentArena(nuke.NewMonotonicArena(1024, 1)))
+	nuke.Register("b", nuke.NewConcurrentSafeArena(1024))
+	defer nuke.Unregister("a")
+	defer nuke.Unregister("b")
+	require.Equal(t, 2, testutil.CollectAndCount(c, "nuke_arena_resets_total"))
+}
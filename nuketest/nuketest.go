@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nuketest provides utilities for testing code that allocates from nuke arenas.
+package nuketest
+
+import "testing"
+
+// DefaultRuns is the number of times RequireNoAllocs invokes the measured function.
+const DefaultRuns = 100
+
+// RequireNoAllocs fails the test immediately if f performs any heap allocation,
+// as measured by testing.AllocsPerRun over DefaultRuns invocations.
+// Callers should warm up any arena used by f beforehand, so that lazily allocated buffers are not accounted.
+func RequireNoAllocs(tb testing.TB, f func()) {
+	tb.Helper()
+	if allocs := testing.AllocsPerRun(DefaultRuns, f); allocs != 0 {
+		tb.Fatalf("expected no heap allocations, got %v per run", allocs)
+	}
+}
+
+// RequireMaxAllocs fails the test immediately if f performs, on average, more than max heap allocations per run,
+// as measured by testing.AllocsPerRun over DefaultRuns invocations.
+func RequireMaxAllocs(tb testing.TB, max float64, f func()) {
+	tb.Helper()
+	if allocs := testing.AllocsPerRun(DefaultRuns, f); allocs > max {
+		tb.Fatalf("expected at most %v heap allocations per run, got %v", max, allocs)
+	}
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Command nukevet runs the analyzers of the nukevet module, reporting misuses of the nuke package.
+// It can be run on its own, or through go vet:
+//
+//	go vet -vettool=$(which nukevet) ./...
+package main
+
+import (
+	"github.com/ortuman/nuke/nukevet/escapecheck"
+	"github.com/ortuman/nuke/nukevet/podcheck"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(podcheck.Analyzer, escapecheck.Analyzer)
+}
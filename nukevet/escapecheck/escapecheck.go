@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package escapecheck defines an analyzer reporting memory allocated from an arena that escapes a function resetting
+// the arena, by being stored into a global variable, a struct field or a channel, all of which outlive the reset.
+// Values returned by a function deferring the reset of their arena are reported as well.
+//
+// The analysis is intra-procedural and conservative: it only follows values allocated by the nuke helpers within the
+// function, and variables directly assigned such values, from arenas identified by the same expression as the
+// receiver of the Reset or ResetWithStats call.
+package escapecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const nukePath = "github.com/ortuman/nuke"
+
+// allocators are the functions of the nuke package returning memory allocated from the arena passed first.
+var allocators = map[string]bool{
+	"New": true, "MakeSlice": true, "NewPOD": true, "MakePOD": true, "NewOfType": true, "MakeOfType": true,
+	"SliceAppend": true, "Appendf": true, "AppendInt": true, "AppendUint": true, "AppendFloat": true,
+	"AppendQuote": true, "FormatInt": true, "FormatUint": true, "FormatFloat": true, "Quote": true,
+}
+
+// Analyzer reports arena memory escaping a function that resets the arena.
+var Analyzer = &analysis.Analyzer{
+	Name:     "nukeescape",
+	Doc:      "report arena memory stored into globals, struct fields or channels by a function resetting the arena",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		if fn := n.(*ast.FuncDecl); fn.Body != nil {
+			newChecker(pass, fn.Body).check()
+		}
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass *analysis.Pass
+	body *ast.BlockStmt
+
+	resets   map[string]bool         // arenas reset by the function, by expression
+	deferred map[string]bool         // arenas whose reset is deferred
+	tracked  map[types.Object]string // variables holding arena memory, along with their arena
+}
+
+func newChecker(pass *analysis.Pass, body *ast.BlockStmt) *checker {
+	c := &checker{
+		pass:     pass,
+		body:     body,
+		resets:   make(map[string]bool),
+		deferred: make(map[string]bool),
+		tracked:  make(map[types.Object]string),
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.DeferStmt:
+			if arena, ok := resetArena(n.Call); ok {
+				c.resets[arena], c.deferred[arena] = true, true
+			}
+		case *ast.CallExpr:
+			if arena, ok := resetArena(n); ok {
+				c.resets[arena] = true
+			}
+		}
+		return true
+	})
+	return c
+}
+
+// resetArena returns the expression of the arena reset by call, if it resets one.
+func resetArena(call *ast.CallExpr) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Reset" && sel.Sel.Name != "ResetWithStats") || len(call.Args) != 1 {
+		return "", false
+	}
+	return types.ExprString(sel.X), true
+}
+
+func (c *checker) check() {
+	if len(c.resets) == 0 {
+		return
+	}
+	ast.Inspect(c.body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			c.assign(n.Tok, n.Lhs, n.Rhs)
+
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			c.assign(token.DEFINE, lhs, n.Values)
+
+		case *ast.SendStmt:
+			if arena := c.arenaOf(n.Value); arena != "" {
+				c.pass.Reportf(n.Pos(), "memory allocated from %s is sent on channel %s, which outlives the reset of %s",
+					arena, types.ExprString(n.Chan), arena)
+			}
+
+		case *ast.ReturnStmt:
+			for _, r := range n.Results {
+				if arena := c.arenaOf(r); arena != "" && c.deferred[arena] {
+					c.pass.Reportf(r.Pos(), "memory allocated from %s is returned after the deferred reset of %s", arena, arena)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (c *checker) assign(tok token.Token, lhs, rhs []ast.Expr) {
+	if len(lhs) != len(rhs) {
+		return
+	}
+	for i, l := range lhs {
+		arena := c.arenaOf(rhs[i])
+		if id, ok := l.(*ast.Ident); ok {
+			if obj := c.objectOf(id); obj != nil && c.local(obj) {
+				if arena != "" {
+					c.tracked[obj] = arena
+				} else {
+					delete(c.tracked, obj)
+				}
+				continue
+			}
+		}
+		if arena == "" || tok == token.DEFINE {
+			continue
+		}
+		c.escape(l, arena)
+	}
+}
+
+// escape reports arena memory stored into lhs if it outlives the function.
+func (c *checker) escape(lhs ast.Expr, arena string) {
+	root := rootIdent(lhs)
+	if root == nil {
+		return
+	}
+	obj := c.objectOf(root)
+	if obj == nil {
+		return
+	}
+	if v, ok := obj.(*types.Var); ok && v.Parent() == c.pass.Pkg.Scope() {
+		c.pass.Reportf(lhs.Pos(), "memory allocated from %s is stored in global %s, which outlives the reset of %s",
+			arena, types.ExprString(lhs), arena)
+		return
+	}
+	if !c.local(obj) && c.throughField(lhs) {
+		c.pass.Reportf(lhs.Pos(), "memory allocated from %s is stored in field %s, which outlives the reset of %s",
+			arena, types.ExprString(lhs), arena)
+	}
+}
+
+// throughField reports whether an assignment target goes through a struct field.
+func (c *checker) throughField(e ast.Expr) bool {
+	for {
+		switch x := ast.Unparen(e).(type) {
+		case *ast.SelectorExpr:
+			if s := c.pass.TypesInfo.Selections[x]; s != nil && s.Kind() == types.FieldVal {
+				return true
+			}
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return false
+		}
+	}
+}
+
+// arenaOf returns the expression of the arena the memory e refers to is allocated from, if any.
+func (c *checker) arenaOf(e ast.Expr) string {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		if obj := c.objectOf(e); obj != nil {
+			return c.tracked[obj]
+		}
+	case *ast.SliceExpr:
+		return c.arenaOf(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			switch x := ast.Unparen(e.X).(type) {
+			case *ast.SelectorExpr:
+				return c.arenaOf(x.X)
+			case *ast.IndexExpr:
+				return c.arenaOf(x.X)
+			}
+		}
+	case *ast.CallExpr:
+		fn, ok := typeutil.Callee(c.pass.TypesInfo, e).(*types.Func)
+		if ok && fn.Pkg() != nil && fn.Pkg().Path() == nukePath && allocators[fn.Name()] && len(e.Args) > 0 {
+			if arena := types.ExprString(e.Args[0]); c.resets[arena] {
+				return arena
+			}
+		}
+	}
+	return ""
+}
+
+func (c *checker) objectOf(id *ast.Ident) types.Object {
+	if obj := c.pass.TypesInfo.Defs[id]; obj != nil {
+		return obj
+	}
+	return c.pass.TypesInfo.Uses[id]
+}
+
+// local reports whether obj is declared within the function body, as opposed to parameters, receivers,
+// captured variables and globals.
+func (c *checker) local(obj types.Object) bool {
+	return obj.Pos() >= c.body.Pos() && obj.Pos() < c.body.End()
+}
+
+// rootIdent returns the variable an assignment target is rooted at.
+func rootIdent(e ast.Expr) *ast.Ident {
+	for {
+		switch x := ast.Unparen(e).(type) {
+		case *ast.Ident:
+			return x
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
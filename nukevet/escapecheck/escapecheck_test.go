@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package escapecheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
@@ -0,0 +1,70 @@
+package a
+
+import "github.com/ortuman/nuke"
+
+type request struct {
+	payload []byte
+	next    *request
+}
+
+type server struct {
+	last  *request
+	cache map[string]*request
+}
+
+var (
+	global  *request
+	globals []*request
+)
+
+func storeGlobal(a nuke.Arena) {
+	r := nuke.New[request](a)
+	global = r                        // want `memory allocated from a is stored in global global, which outlives the reset of a`
+	globals[0] = nuke.New[request](a) // want `memory allocated from a is stored in global globals\[0\], which outlives the reset of a`
+	a.Reset(false)
+}
+
+func (s *server) storeField(a nuke.Arena) {
+	r := nuke.New[request](a)
+	r.payload = nuke.MakeSlice[byte](a, 0, 16) // r is local, and dies along with the arena
+	s.last = r                                 // want `memory allocated from a is stored in field s.last, which outlives the reset of a`
+	s.cache["k"] = r                           // want `memory allocated from a is stored in field s.cache\["k"\], which outlives the reset of a`
+	a.Reset(true)
+}
+
+func send(a nuke.Arena, ch chan<- []byte) {
+	buf := nuke.MakeSlice[byte](a, 0, 16)
+	buf = nuke.AppendInt(a, buf, 42, 10)
+	ch <- buf[:2] // want `memory allocated from a is sent on channel ch, which outlives the reset of a`
+	a.Reset(false)
+}
+
+func deferred(a nuke.Arena) *request {
+	defer a.Reset(false)
+	return nuke.New[request](a) // want `memory allocated from a is returned after the deferred reset of a`
+}
+
+func closure(a nuke.Arena, r *request) {
+	func() {
+		r.next = nuke.New[request](a) // want `memory allocated from a is stored in field r.next, which outlives the reset of a`
+	}()
+	a.Reset(false)
+}
+
+func reassigned(a nuke.Arena) {
+	r := nuke.New[request](a)
+	r = new(request)
+	global = r
+	a.Reset(false)
+}
+
+func otherArena(a, b nuke.Arena) {
+	global = nuke.New[request](b)
+	a.Reset(false)
+}
+
+func noReset(a nuke.Arena, s *server) *request {
+	global = nuke.New[request](a)
+	s.last = global
+	return nuke.New[request](a)
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package podcheck defines an analyzer reporting calls to nuke.NewPOD and nuke.MakePOD whose type argument
+// statically contains pointers, which would be hidden from the GC.
+//
+// Types vouched for with nuke.RegisterPOD in the package being analyzed are not reported, nor are fields of type
+// unsafe.Pointer tagged with `nuke:"nogc"`. Type arguments that are type parameters cannot be checked statically,
+// hence they are left to the runtime checks of debug builds.
+package podcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const nukePath = "github.com/ortuman/nuke"
+
+// Analyzer reports NewPOD and MakePOD calls instantiated with types containing pointers.
+var Analyzer = &analysis.Analyzer{
+	Name:     "nukepod",
+	Doc:      "report nuke.NewPOD and nuke.MakePOD calls whose type argument contains pointers",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	var calls []*ast.CallExpr
+	vouched := make(map[string]bool)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		switch name, t := nukeCall(pass, call); name {
+		case "NewPOD", "MakePOD":
+			calls = append(calls, call)
+		case "RegisterPOD":
+			if t != nil {
+				vouched[types.TypeString(t, nil)] = true
+			}
+		}
+	})
+
+	for _, call := range calls {
+		name, t := nukeCall(pass, call)
+		if t == nil {
+			continue
+		}
+		var fields []string
+		if !pointerFields(t, "", vouched, func(path string, ft types.Type) {
+			fields = append(fields, fmt.Sprintf("field %s is a %s", path, qualified(pass, ft)))
+		}) {
+			continue
+		}
+		msg := fmt.Sprintf("nuke.%s instantiated with %s, which contains pointers hidden from the GC", name, qualified(pass, t))
+		if len(fields) > 0 {
+			msg += ": " + strings.Join(fields, "; ")
+		}
+		pass.Reportf(call.Pos(), "%s", msg)
+	}
+	return nil, nil
+}
+
+// nukeCall returns the name of the nuke function invoked by call, along with its first type argument,
+// which is nil if it is a type parameter.
+func nukeCall(pass *analysis.Pass, call *ast.CallExpr) (string, types.Type) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != nukePath {
+		return "", nil
+	}
+	id := calleeIdent(call.Fun)
+	if id == nil {
+		return "", nil
+	}
+	inst, ok := pass.TypesInfo.Instances[id]
+	if !ok || inst.TypeArgs.Len() == 0 {
+		return fn.Name(), nil
+	}
+	t := inst.TypeArgs.At(0)
+	if _, ok := t.(*types.TypeParam); ok {
+		return fn.Name(), nil
+	}
+	return fn.Name(), t
+}
+
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch e := fun.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	case *ast.IndexExpr:
+		return calleeIdent(e.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(e.X)
+	case *ast.ParenExpr:
+		return calleeIdent(e.X)
+	}
+	return nil
+}
+
+// pointerFields reports whether values of type t hold pointers, invoking f with the path and type of every
+// field holding them, as the runtime checks of the nuke package do.
+func pointerFields(t types.Type, path string, vouched map[string]bool, f func(path string, t types.Type)) bool {
+	if vouched[types.TypeString(t, nil)] {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() != types.String && u.Kind() != types.UnsafePointer && u.Kind() != types.UntypedNil {
+			return false
+		}
+
+	case *types.Array:
+		return u.Len() > 0 && pointerFields(u.Elem(), path+"[]", vouched, f)
+
+	case *types.Struct:
+		var found bool
+		for i := 0; i < u.NumFields(); i++ {
+			field := u.Field(i)
+			if untraced(field, u.Tag(i)) {
+				continue
+			}
+			fpath := field.Name()
+			if path != "" {
+				fpath = path + "." + field.Name()
+			}
+			if pointerFields(field.Type(), fpath, vouched, f) {
+				found = true
+			}
+		}
+		return found
+
+	case *types.TypeParam:
+		return false
+	}
+	if path != "" {
+		f(path, t)
+	}
+	return true
+}
+
+// untraced reports whether a field is an unsafe.Pointer exempted from the checks with the nuke:"nogc" tag.
+func untraced(field *types.Var, tag string) bool {
+	b, ok := field.Type().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.UnsafePointer && reflect.StructTag(tag).Get("nuke") == "nogc"
+}
+
+func qualified(pass *analysis.Pass, t types.Type) string {
+	return types.TypeString(t, types.RelativeTo(pass.Pkg))
+}
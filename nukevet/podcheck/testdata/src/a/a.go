@@ -0,0 +1,49 @@
+package a
+
+import (
+	"unsafe"
+
+	"github.com/ortuman/nuke"
+)
+
+type point struct {
+	X, Y float64
+}
+
+type named struct {
+	ID   int
+	Meta struct {
+		Names [2]string
+	}
+	Index map[string]int
+}
+
+type mapping struct {
+	Addr unsafe.Pointer `nuke:"nogc"`
+	Size uintptr
+}
+
+type handle struct {
+	p *point
+}
+
+func init() {
+	nuke.RegisterPOD[handle]()
+}
+
+func allocate(a nuke.Arena) {
+	_ = nuke.NewPOD[point](a)
+	_ = nuke.MakePOD[[4]int32](a, 1, 1)
+	_ = nuke.NewPOD[mapping](a)
+	_ = nuke.NewPOD[handle](a)
+	_ = nuke.NewPOD[[0]*int](a)
+	_ = nuke.New[named](a)
+
+	_ = nuke.NewPOD[named](a)         // want `nuke.NewPOD instantiated with named, which contains pointers hidden from the GC: field Meta.Names\[\] is a string; field Index is a map\[string\]int`
+	_ = nuke.MakePOD[string](a, 1, 1) // want `nuke.MakePOD instantiated with string, which contains pointers hidden from the GC`
+	_ = nuke.MakePOD[*point](a, 1, 1) // want `nuke.MakePOD instantiated with \*point`
+}
+
+func generic[T any](a nuke.Arena) *T {
+	return nuke.NewPOD[T](a)
+}
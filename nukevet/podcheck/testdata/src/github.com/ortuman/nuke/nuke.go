@@ -0,0 +1,12 @@
+// Package nuke stubs the functions of the nuke package the analyzer looks for.
+package nuke
+
+type Arena interface{}
+
+func NewPOD[T any](a Arena) *T { return new(T) }
+
+func MakePOD[T any](a Arena, len, cap int) []T { return make([]T, len, cap) }
+
+func RegisterPOD[T any]() {}
+
+func New[T any](a Arena) *T { return new(T) }
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Option configures the behavior of an arena at construction time.
+type Option func(*options)
+
+type options struct {
+	strict         bool
+	onExhausted    ExhaustedPolicy
+	typedSlots     int
+	typedSlabBytes int
+	maxBytes       int
+	shrink         ShrinkPolicy
+	growOnDemand   bool
+	maxBuffers     int
+	growthFactor   float64
+	decommit       bool
+	pointers       *PointerPolicy
+
+	bufferAlignment int
+	partialRelease  bool
+
+	oversizedThreshold int
+	freeLists          bool
+	lazyZeroing        bool
+	poison             bool
+	quarantine         int
+	preallocate        []reflect.Type
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithStrictMode configures the arena to panic instead of silently falling back to the heap
+// whenever an allocation cannot be satisfied, or when a type containing pointers is allocated from it.
+// It is mostly useful in tests, where capacity bugs and accidental non-POD types should not go unnoticed.
+// A policy installed by WithOnExhausted takes precedence over strict mode when the arena is exhausted.
+func WithStrictMode() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithPointerPolicy sets how a monotonic arena handles the allocation of types containing pointers.
+// It defaults to PointerAllow, or to PointerPanic in strict mode, over which it takes precedence.
+func WithPointerPolicy(policy PointerPolicy) Option {
+	return func(o *options) {
+		o.pointers = &policy
+	}
+}
+
+// WithOnExhausted installs a policy invoked whenever the arena cannot satisfy an allocation,
+// deciding what the arena should do about it.
+func WithOnExhausted(policy ExhaustedPolicy) Option {
+	return func(o *options) {
+		o.onExhausted = policy
+	}
+}
+
+// WithInitialTypedSlots sets the number of values the first slab of every typed slab group of a SafeArena holds.
+// Subsequent slabs double the size of the previous one.
+func WithInitialTypedSlots(n int) Option {
+	return func(o *options) {
+		o.typedSlots = n
+	}
+}
+
+// WithMaxBytes limits the overall size of the slabs a SafeArena acquires, or of the buffers a monotonic arena
+// grows to when WithGrowOnDemand is set, to n bytes. Once the limit is reached, allocations that do not fit
+// the existing slabs or buffers trigger the exhaustion policy instead of growing the arena.
+func WithMaxBytes(n int) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// WithGrowOnDemand makes a monotonic arena append a new buffer whenever an allocation does not fit the existing ones,
+// rather than triggering the exhaustion policy. Growth can be bounded by means of WithMaxBuffers and WithMaxBytes.
+func WithGrowOnDemand() Option {
+	return func(o *options) {
+		o.growOnDemand = true
+	}
+}
+
+// WithMaxBuffers limits the number of buffers a monotonic arena grows to when WithGrowOnDemand is set,
+// including the ones it is created with.
+func WithMaxBuffers(n int) Option {
+	return func(o *options) {
+		o.maxBuffers = n
+	}
+}
+
+// WithBufferGrowthFactor makes every buffer of a monotonic arena the given factor larger than the previous one,
+// starting from the configured buffer size, so that an arena with a small initial footprint is still able to
+// absorb occasional large allocations. Factors below 1 are ignored.
+func WithBufferGrowthFactor(factor float64) Option {
+	return func(o *options) {
+		o.growthFactor = factor
+	}
+}
+
+// WithDecommitOnReset makes an arena created by NewMmapArena return the used pages of its buffers to the OS
+// with madvise(MADV_DONTNEED) when reset without releasing its memory, rather than zeroing them out, so that
+// the resident set size drops between bursts while the address space is retained. It is only honored on Linux,
+// whereas other platforms keep zeroing out the buffers.
+func WithDecommitOnReset() Option {
+	return func(o *options) {
+		o.decommit = true
+	}
+}
+
+// WithBufferAlignment makes every buffer allocated by a monotonic arena, as well as every POD slab of a SafeArena,
+// start on an n-byte boundary, such as 64 for cache lines or os.Getpagesize() for pages, so that allocations
+// requiring such an alignment do not waste padding, and DMA or io_uring buffers can rely on it.
+// Memory is over-allocated by up to n-1 bytes per buffer whenever the allocator does not already guarantee it.
+func WithBufferAlignment(n int) Option {
+	return func(o *options) {
+		o.bufferAlignment = n
+	}
+}
+
+// WithPartialRelease makes a monotonic arena release, whenever it is reset without releasing its memory,
+// the buffers lying beyond the last one allocations were served from since the previous Reset, so that
+// the capacity used in the steady state is kept warm while the one acquired during bursts is returned.
+func WithPartialRelease() Option {
+	return func(o *options) {
+		o.partialRelease = true
+	}
+}
+
+// WithOversizedThreshold makes a SafeArena or a monotonic arena serve allocations larger than n bytes from
+// dedicated slabs or buffers, which are released on Reset, rather than growing the ones shared by the common case
+// or falling back to the heap. Setting it to the buffer size of a monotonic arena keeps allocations not fitting
+// a buffer within the arena lifetime.
+func WithOversizedThreshold(n int) Option {
+	return func(o *options) {
+		o.oversizedThreshold = n
+	}
+}
+
+// WithFreeLists makes a SafeArena keep a free list per type, which values handed back by Free are added to,
+// and which allocations of a single value take values from before allocating new memory. It fits long-lived arenas with object churn.
+func WithFreeLists() Option {
+	return func(o *options) {
+		o.freeLists = true
+	}
+}
+
+// WithLazyZeroing makes an arena zero its memory as it is handed out, rather than clearing every byte in use
+// on Reset, which pays off for arenas reusing a small fraction of their capacity each cycle. It applies to the
+// buffers of monotonic arenas, as well as to the POD slabs of a SafeArena, whose typed slabs are still cleared
+// on Reset, so that the GC can reclaim the values they reference.
+func WithLazyZeroing() Option {
+	return func(o *options) {
+		o.lazyZeroing = true
+	}
+}
+
+// PoisonByte is the value WithPoisonOnReset fills reclaimed memory with.
+const PoisonByte = 0xDD
+
+// WithPoisonOnReset is a debugging option making an arena fill the memory reclaimed by Reset with PoisonByte,
+// so that dangling pointers into the arena read obviously wrong values rather than zeros. Memory is then zeroed
+// as it is handed out again, as WithLazyZeroing does. It applies to the buffers of monotonic arenas, as well as to
+// the POD slabs of a SafeArena, whose typed slabs cannot hold invalid pointers the GC would trip over.
+func WithPoisonOnReset() Option {
+	return func(o *options) {
+		o.poison = true
+	}
+}
+
+// WithQuarantine is a debugging option making an arena quarantine the memory it reclaims on Reset for the given
+// number of subsequent resets, rather than handing it out again right away. Buffers and slabs that were in use are
+// replaced by fresh ones, and only released once the quarantine is over, so that dangling pointers keep reading the
+// zeroed or, along with WithPoisonOnReset, poisoned memory rather than valid-looking data allocated in the meantime.
+// It applies to the buffers of monotonic arenas, except those of fixed arenas, and to the slabs of a SafeArena,
+// which reports quarantined slabs as released. Quarantined memory is not accounted for by WithMaxBytes.
+func WithQuarantine(resets int) Option {
+	return func(o *options) {
+		o.quarantine = resets
+	}
+}
+
+// WithPreallocatedTypes makes a SafeArena allocate up front the first slab of the slab groups serving the given types.
+func WithPreallocatedTypes(types ...reflect.Type) Option {
+	return func(o *options) {
+		o.preallocate = append(o.preallocate, types...)
+	}
+}
+
+// WithShrinkPolicy installs the policy deciding how many slabs every slab group of a SafeArena
+// releases on Reset. It defaults to ShrinkBelowUsage(0.25, 1, 0).
+func WithShrinkPolicy(policy ShrinkPolicy) Option {
+	return func(o *options) {
+		o.shrink = policy
+	}
+}
+
+// ShrinkPolicy decides how many of the newest slabs of a slab group are released when the arena is reset.
+type ShrinkPolicy func(u SlabUsage) int
+
+// SlabUsage describes the usage a slab group made of its slabs since the previous Reset.
+type SlabUsage struct {
+	// Type is the element type of the slabs of a typed slab group, which is shared by every type of the same
+	// GC shape, or nil for the POD slab group.
+	Type reflect.Type
+
+	// SlabSizes holds the size in bytes of every slab of the group, from oldest to newest.
+	// It must not be retained after the policy returns.
+	SlabSizes []uint64
+
+	// BytesInUse is the number of bytes handed out from the group, including alignment padding.
+	BytesInUse uint64
+
+	// BytesAllocated is the overall size of the slabs of the group.
+	BytesAllocated uint64
+}
+
+// ShrinkBelowUsage returns a policy releasing up to maxSlabs of the newest slabs of every group whose usage
+// stayed below the given ratio of its capacity. The oldest slab is always retained, and slabs are only released
+// as long as the retained capacity stays above both minRetainedBytes and the number of bytes in use.
+func ShrinkBelowUsage(ratio float64, maxSlabs, minRetainedBytes int) ShrinkPolicy {
+	return func(u SlabUsage) int {
+		if float64(u.BytesInUse) >= ratio*float64(u.BytesAllocated) {
+			return 0
+		}
+		floor := max(uint64(minRetainedBytes), u.BytesInUse)
+		retained := u.BytesAllocated
+
+		var n int
+		for n < maxSlabs && n < len(u.SlabSizes)-1 {
+			size := u.SlabSizes[len(u.SlabSizes)-1-n]
+			if retained-size < floor {
+				break
+			}
+			retained -= size
+			n++
+		}
+		return n
+	}
+}
+
+// PointerPolicy decides how an arena handles the allocation of types containing pointers, whose referents are
+// not visible to the garbage collector when stored in memory it does not scan.
+type PointerPolicy int
+
+const (
+	// PointerAllow serves the allocation from the arena, counting it in Stats.PointerAllocations.
+	// It is only safe as long as the values only point to memory allocated from the same arena.
+	// This is the default policy of non-strict arenas.
+	PointerAllow PointerPolicy = iota
+
+	// PointerFallback makes the allocation fall back to Go's heap, counting it in Stats.HeapFallbacks.
+	PointerFallback
+
+	// PointerPanic makes the arena panic with ErrPointerType.
+	// This is the default policy of strict arenas.
+	PointerPanic
+)
+
+// pointerPolicy returns the policy to apply to the allocation of types containing pointers.
+func (o *options) pointerPolicy() PointerPolicy {
+	if o.pointers != nil {
+		return *o.pointers
+	}
+	if o.strict {
+		return PointerPanic
+	}
+	return PointerAllow
+}
+
+// allowPointers applies the pointer policy to an allocation of type t, reporting whether it can be served
+// from arena memory rather than falling back to the heap.
+func (o *options) allowPointers(t reflect.Type, c *arenaCounters) bool {
+	if !hasPointers(t) {
+		return true
+	}
+	switch o.pointerPolicy() {
+	case PointerFallback:
+		c.heapFallbacks++
+		tracePointerFallback(t)
+		return false
+
+	case PointerPanic:
+		panic(fmt.Errorf("%w: %s", ErrPointerType, t))
+
+	default:
+		c.pointerAllocs++
+		return true
+	}
+}
+
+// ExhaustedPolicy decides which action an arena takes when it cannot satisfy an allocation.
+type ExhaustedPolicy func(e Exhaustion) ExhaustedAction
+
+// Exhaustion describes an allocation an arena could not satisfy.
+type Exhaustion struct {
+	// Size is the number of bytes requested.
+	Size uintptr
+
+	// Alignment is the requested alignment.
+	Alignment uintptr
+
+	// Type is the type of the values being allocated,
+	// or nil if the allocation was requested through Arena.Alloc.
+	Type reflect.Type
+}
+
+// ExhaustedAction is the action an arena takes when it cannot satisfy an allocation.
+type ExhaustedAction int
+
+const (
+	// ExhaustedFallback makes the allocation fall back to Go's heap.
+	// This is the default action of non-strict arenas.
+	ExhaustedFallback ExhaustedAction = iota
+
+	// ExhaustedGrow makes the arena acquire additional memory to satisfy the allocation.
+	ExhaustedGrow
+
+	// ExhaustedReturnNil makes the allocation fail, so that New and MakeSlice return nil.
+	ExhaustedReturnNil
+
+	// ExhaustedPanic makes the arena panic with ErrArenaExhausted.
+	// This is the default action of strict arenas.
+	ExhaustedPanic
+)
+
+// exhausted returns the action to take for an allocation that could not be satisfied.
+func (o *options) exhausted(e Exhaustion) ExhaustedAction {
+	if o.onExhausted != nil {
+		return o.onExhausted(e)
+	}
+	if o.strict {
+		return ExhaustedPanic
+	}
+	return ExhaustedFallback
+}
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"cmp"
+	"unsafe"
+)
+
+// maxSkipLevel bounds the height of the nodes of an OrderedMap, which fits billions of entries.
+const maxSkipLevel = 24
+
+// OrderedMap is a map of ordered keys to values, implemented as a skip list whose nodes live in an arena, which
+// allows building per-query indexes supporting range scans without heap-allocated containers. Deleted nodes are
+// unlinked rather than reclaimed, as their memory is reclaimed along with the arena. As with any memory allocated
+// from the arena, the map must not be used anymore once the arena is reset. The nodes, and the links between them,
+// the arena cannot serve are allocated on the heap and kept reachable by the map, whereas keys and values pointing
+// to the heap, such as strings not allocated from the arena, must only be stored from arenas whose memory the GC
+// scans, such as safe arenas.
+// An OrderedMap is not safe to be accessed concurrently from multiple goroutines.
+type OrderedMap[K cmp.Ordered, V any] struct {
+	a     Arena
+	head  []*skipNode[K, V]
+	level int // number of levels in use
+	len   int
+	rnd   uint64
+	heap  heapRoots // nodes and links allocated on the heap
+}
+
+type skipNode[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	next  []*skipNode[K, V]
+}
+
+// NewOrderedMap returns an empty map allocating its nodes from a. If the arena is nil, nodes are allocated on the
+// heap.
+func NewOrderedMap[K cmp.Ordered, V any](a Arena) *OrderedMap[K, V] {
+	m := &OrderedMap[K, V]{a: a, level: 1, rnd: 0x9e3779b97f4a7c15}
+	if m.head = MakeSlice[*skipNode[K, V]](a, maxSkipLevel, maxSkipLevel); m.head == nil {
+		m.head = make([]*skipNode[K, V], maxSkipLevel) // the arena is exhausted
+	}
+	return m
+}
+
+// Len returns the number of entries of the map.
+func (m *OrderedMap[K, V]) Len() int {
+	return m.len
+}
+
+// findPrev fills prev with the last node preceding key at every level, nil standing for the head,
+// and returns the node holding key, if any.
+func (m *OrderedMap[K, V]) findPrev(key K, prev *[maxSkipLevel]*skipNode[K, V]) *skipNode[K, V] {
+	var n *skipNode[K, V]
+	for l := m.level - 1; l >= 0; l-- {
+		for next := m.nextOf(n, l); next != nil && next.key < key; next = m.nextOf(n, l) {
+			n = next
+		}
+		if prev != nil {
+			prev[l] = n
+		}
+	}
+	if next := m.nextOf(n, 0); next != nil && next.key == key {
+		return next
+	}
+	return nil
+}
+
+// nextOf returns the node following n at level l, n being nil for the head.
+func (m *OrderedMap[K, V]) nextOf(n *skipNode[K, V], l int) *skipNode[K, V] {
+	if n == nil {
+		return m.head[l]
+	}
+	return n.next[l]
+}
+
+func (m *OrderedMap[K, V]) setNext(n *skipNode[K, V], l int, next *skipNode[K, V]) {
+	if n == nil {
+		m.head[l] = next
+	} else {
+		n.next[l] = next
+	}
+}
+
+// Get returns the value of key, reporting false if it is not in the map.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if n := m.findPrev(key, nil); n != nil {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set sets the value of key, adding it to the map if missing.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	var prev [maxSkipLevel]*skipNode[K, V]
+	if n := m.findPrev(key, &prev); n != nil {
+		n.value = value
+		return
+	}
+	h := m.randomLevel()
+	for ; m.level < h; m.level++ {
+		prev[m.level] = nil
+	}
+	n := New[skipNode[K, V]](m.a)
+	if n == nil {
+		n = new(skipNode[K, V]) // the arena is exhausted
+	}
+	m.heap.keep(m.a, unsafe.Pointer(n))
+	n.key, n.value = key, value
+	if n.next = MakeSlice[*skipNode[K, V]](m.a, h, h); n.next == nil {
+		n.next = make([]*skipNode[K, V], h)
+	}
+	m.heap.keep(m.a, unsafe.Pointer(unsafe.SliceData(n.next)))
+	for l := 0; l < h; l++ {
+		n.next[l] = m.nextOf(prev[l], l)
+		m.setNext(prev[l], l, n)
+	}
+	m.len++
+}
+
+// Delete removes key from the map, reporting whether it was in it.
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	var prev [maxSkipLevel]*skipNode[K, V]
+	n := m.findPrev(key, &prev)
+	if n == nil {
+		return false
+	}
+	for l := range n.next {
+		m.setNext(prev[l], l, n.next[l])
+	}
+	m.heap.drop(unsafe.Pointer(n))
+	m.heap.drop(unsafe.Pointer(unsafe.SliceData(n.next)))
+	for m.level > 1 && m.head[m.level-1] == nil {
+		m.level--
+	}
+	m.len--
+	return true
+}
+
+// randomLevel returns the height of a new node, every level being reached with a probability of 1/4.
+func (m *OrderedMap[K, V]) randomLevel() int {
+	// xorshift64*
+	m.rnd ^= m.rnd >> 12
+	m.rnd ^= m.rnd << 25
+	m.rnd ^= m.rnd >> 27
+	r := m.rnd * 2685821657736338717
+	h := 1
+	for ; h < maxSkipLevel && r&3 == 0; r >>= 2 {
+		h++
+	}
+	return h
+}
+
+// Ascend invokes f for every entry of the map in increasing key order, until it returns false.
+func (m *OrderedMap[K, V]) Ascend(f func(key K, value V) bool) {
+	for n := m.head[0]; n != nil; n = n.next[0] {
+		if !f(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// Range invokes f for every entry whose key is within [from, to), in increasing key order, until it returns false.
+func (m *OrderedMap[K, V]) Range(from, to K, f func(key K, value V) bool) {
+	var prev [maxSkipLevel]*skipNode[K, V]
+	m.findPrev(from, &prev)
+	for n := m.nextOf(prev[0], 0); n != nil && n.key < to; n = n.next[0] {
+		if !f(n.key, n.value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1, WithGrowOnDemand())
+	m := NewOrderedMap[int, string](arena)
+
+	rnd := rand.New(rand.NewSource(1))
+	keys := rnd.Perm(1000)
+	for _, k := range keys {
+		m.Set(k, "v")
+	}
+	m.Set(500, "five hundred")
+	require.Equal(t, 1000, m.Len())
+	v, ok := m.Get(500)
+	require.True(t, ok)
+	require.Equal(t, "five hundred", v)
+	require.True(t, Owns(arena, unsafe.Pointer(m.head[0])))
+
+	for _, k := range keys[:500] {
+		require.True(t, m.Delete(k))
+	}
+	require.False(t, m.Delete(keys[0]))
+	require.Equal(t, 500, m.Len())
+	_, ok = m.Get(keys[0])
+	require.False(t, ok)
+
+	var got []int
+	m.Ascend(func(k int, _ string) bool {
+		got = append(got, k)
+		return true
+	})
+	want := append([]int(nil), keys[500:]...)
+	sort.Ints(want)
+	require.Equal(t, want, got)
+
+	var ranged []int
+	m.Range(100, 200, func(k int, _ string) bool {
+		ranged = append(ranged, k)
+		return len(ranged) < 10
+	})
+	require.Len(t, ranged, 10)
+	require.True(t, sort.IntsAreSorted(ranged))
+	require.GreaterOrEqual(t, ranged[0], 100)
+	for _, k := range want {
+		if k >= 100 {
+			require.Equal(t, k, ranged[0])
+			break
+		}
+	}
+}
+
+func TestOrderedMapHeap(t *testing.T) {
+	m := NewOrderedMap[string, int](nil)
+	m.Set("b", 2)
+	m.Set("a", 1)
+	var keys []string
+	m.Range("a", "z", func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	require.Equal(t, []string{"a", "b"}, keys)
+}
+
+func TestOrderedMapHeapNodesSurviveGC(t *testing.T) {
+	arena := NewMonotonicArena(256, 1)
+	m := NewOrderedMap[int, int](arena)
+	for i := 0; i < 32; i++ {
+		m.Set(i, -i)
+	}
+	m.Delete(16)
+
+	// The nodes allocated on the heap once the arena is exhausted are only linked from arena memory.
+	churnHeap()
+	i := 0
+	m.Ascend(func(key, value int) bool {
+		if i == 16 {
+			i++
+		}
+		require.Equal(t, i, key)
+		require.Equal(t, -i, value)
+		i++
+		return true
+	})
+	require.Equal(t, 32, i)
+}
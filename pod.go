@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// IsPlainOldData reports whether values of type T hold no pointers, hence whether they can be stored
+// in memory that is not scanned by the GC.
+func IsPlainOldData[T any]() bool {
+	return !hasPointers(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// CheckPlainOldData returns an error wrapping ErrPointerType describing every field of T holding
+// pointers, or nil if T is plain old data.
+func CheckPlainOldData[T any]() error {
+	return checkPOD(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// AssertPlainOldData panics with the error returned by CheckPlainOldData unless T is plain old data.
+func AssertPlainOldData[T any]() {
+	if err := CheckPlainOldData[T](); err != nil {
+		panic(err)
+	}
+}
+
+// IsPointerFree reports whether values of type t hold no pointers, as IsPlainOldData does for types
+// only known at runtime.
+func IsPointerFree(t reflect.Type) bool {
+	return !hasPointers(t)
+}
+
+// AssertPointerFreeValue panics with an error wrapping ErrPointerType unless the dynamic type of v is
+// plain old data, as AssertPlainOldData does for values only known at runtime. A nil v is pointer free.
+func AssertPointerFreeValue(v any) {
+	if v == nil {
+		return
+	}
+	if err := checkPOD(reflect.TypeOf(v)); err != nil {
+		panic(err)
+	}
+}
+
+// NewPOD allocates memory for a plain old data value of type T using the provided Arena, as New does, except that
+// memory is requested through Alloc even from typed arenas, skipping the classification of T. Hence T must hold no
+// pointers, which would be hidden from the GC otherwise. This is verified in debug builds, that is, when built with
+// the nukedebug tag or -race, in which case NewPOD panics with an error wrapping ErrPointerType on violation.
+// NewPOD falls back to Go's heap when the arena cannot satisfy the allocation. Unlike New, it does so even when the
+// exhaustion policy of the arena is ExhaustedReturnNil, as Alloc cannot tell that policy apart from a heap fallback,
+// hence NewPOD never returns nil.
+func NewPOD[T any](a Arena) *T {
+	if debugChecks {
+		assertPOD[T]()
+	}
+	if a != nil {
+		var x T
+		if ptr := a.Alloc(unsafe.Sizeof(x), unsafe.Alignof(x)); ptr != nil {
+			return (*T)(ptr)
+		}
+	}
+	return new(T)
+}
+
+// MakePOD creates a slice of plain old data values of type T with a given length and capacity using the provided
+// Arena, as MakeSlice does, with the same caveats as NewPOD. In particular, MakePOD never returns nil.
+func MakePOD[T any](a Arena, len, cap int) []T {
+	if debugChecks {
+		assertPOD[T]()
+	}
+	if a != nil {
+		var x T
+		if ptr := a.Alloc(unsafe.Sizeof(x)*uintptr(cap), unsafe.Alignof(x)); ptr != nil {
+			return unsafe.Slice((*T)(ptr), cap)[:len]
+		}
+	}
+	return make([]T, len, cap)
+}
+
+// assertPOD panics unless T is plain old data, only describing its fields when it is not.
+func assertPOD[T any]() {
+	if t := reflect.TypeOf((*T)(nil)).Elem(); hasPointers(t) {
+		panic(checkPOD(t))
+	}
+}
+
+func checkPOD(t reflect.Type) error {
+	if !hasPointers(t) {
+		return nil
+	}
+	var fields []string
+	pointerFields(t, "", func(path string, ft reflect.Type) {
+		fields = append(fields, fmt.Sprintf("field %s is a %s", path, ft))
+	})
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: %s", ErrPointerType, t)
+	}
+	return fmt.Errorf("%w: %s: %s", ErrPointerType, t, strings.Join(fields, "; "))
+}
+
+// pointerFields invokes f with the path and type of every field of t, located at path, that holds pointers,
+// descending into structs and arrays. Array elements are denoted by empty brackets.
+func pointerFields(t reflect.Type, path string, f func(path string, t reflect.Type)) {
+	if !hasPointers(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		pointerFields(t.Elem(), path+"[]", f)
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if untracedField(sf) {
+				continue
+			}
+			fpath := sf.Name
+			if path != "" {
+				fpath = path + "." + sf.Name
+			}
+			pointerFields(sf.Type, fpath, f)
+		}
+
+	default:
+		if path != "" {
+			f(path, t)
+		}
+	}
+}
+
+// RegisterPOD vouches for values of type T holding no pointers the GC must trace, even though T contains
+// pointer types, as when they only point into the same arena or hold opaque handles, so that arenas and the
+// plain old data checks treat T as pointer free. The responsibility for the safety of doing so lies entirely
+// with the caller. RegisterPOD must be invoked before values of T, or of types containing it, are allocated
+// or checked, typically from an init function.
+func RegisterPOD[T any]() {
+	vouchedTypes.Store(reflect.TypeOf((*T)(nil)).Elem(), struct{}{})
+	// Verdicts of the types containing T are stale now.
+	pointerVerdictsMu.Lock()
+	pointerVerdicts.Store(nil)
+	pointerVerdictsMu.Unlock()
+}
+
+var vouchedTypes sync.Map // reflect.Type -> struct{}
+
+// pointerVerdicts memoizes the results of hasPointers, as walking types is comparatively expensive.
+// The map is copied on write, since new types are rare, so that lookups on the allocation path
+// neither lock nor allocate.
+var (
+	pointerVerdicts   atomic.Pointer[map[reflect.Type]bool]
+	pointerVerdictsMu sync.Mutex
+)
+
+// hasPointers reports whether values of type t contain pointers that must be traced by the GC.
+func hasPointers(t reflect.Type) bool {
+	if v, ok := pointerVerdict(t); ok {
+		return v
+	}
+	v := walkPointers(t)
+	pointerVerdictsMu.Lock()
+	m := map[reflect.Type]bool{t: v}
+	if old := pointerVerdicts.Load(); old != nil {
+		maps.Copy(m, *old)
+	}
+	pointerVerdicts.Store(&m)
+	pointerVerdictsMu.Unlock()
+	return v
+}
+
+// pointerVerdict returns the memoized result of hasPointers for type t, if any.
+func pointerVerdict(t reflect.Type) (v, ok bool) {
+	if m := pointerVerdicts.Load(); m != nil {
+		v, ok = (*m)[t]
+	}
+	return v, ok
+}
+
+func walkPointers(t reflect.Type) bool {
+	if _, ok := vouchedTypes.Load(t); ok {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); !untracedField(f) && hasPointers(f.Type) {
+				return true
+			}
+		}
+		return false
+
+	default:
+		return true
+	}
+}
+
+// untracedTag is the struct tag marking unsafe.Pointer fields that point to memory not managed by the GC,
+// such as cgo handles or mmap addresses, which then do not prevent the struct from being plain old data.
+const untracedTag = "nogc"
+
+func untracedField(f reflect.StructField) bool {
+	return f.Type.Kind() == reflect.UnsafePointer && f.Tag.Get("nuke") == untracedTag
+}
+
+// gcShape identifies the memory layout of a type as seen by the GC. Values of types sharing the same shape
+// can be stored in memory allocated for one another without the GC noticing the difference.
+type gcShape struct {
+	size, align  uintptr
+	ptrMask      string // one bit per pointer-sized word, set for the words holding pointers
+	untracedMask string // one bit per pointer-sized word, set for the words holding untraced unsafe.Pointer fields
+}
+
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+// shapeOf returns the GC shape of type t. Untraced unsafe.Pointer fields are told apart from the integers sharing
+// their layout, as the runtime still sees them as pointers, hence types differing only in those do not share
+// a shape.
+func shapeOf(t reflect.Type) gcShape {
+	words := (t.Size() + ptrSize - 1) / ptrSize
+	ptrs, untraced := make([]byte, (words+7)/8), make([]byte, (words+7)/8)
+	markPointers(t, 0, ptrs, untraced)
+	return gcShape{size: t.Size(), align: uintptr(t.Align()), ptrMask: string(ptrs), untracedMask: string(untraced)}
+}
+
+// markPointers sets the bits of ptrs corresponding to the pointers held by a value of type t located at offset,
+// and those of untraced corresponding to its untraced unsafe.Pointer fields.
+func markPointers(t reflect.Type, offset uintptr, ptrs, untraced []byte) {
+	if !hasPointers(t) && !hasUntraced(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			markPointers(t.Elem(), offset+uintptr(i)*t.Elem().Size(), ptrs, untraced)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); untracedField(f) {
+				markWord(untraced, offset+f.Offset)
+			} else {
+				markPointers(f.Type, offset+f.Offset, ptrs, untraced)
+			}
+		}
+
+	case reflect.Interface:
+		markWord(ptrs, offset)
+		markWord(ptrs, offset+ptrSize)
+
+	default:
+		// Pointers, maps, channels and functions are a single pointer,
+		// whereas strings and slices start with one.
+		markWord(ptrs, offset)
+	}
+}
+
+// hasUntraced reports whether values of type t hold untraced unsafe.Pointer fields.
+func hasUntraced(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() > 0 && hasUntraced(t.Elem())
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); untracedField(f) || hasUntraced(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func markWord(mask []byte, offset uintptr) {
+	word := offset / ptrSize
+	mask[word/8] |= 1 << (word % 8)
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type podPoint struct {
+	X, Y float64
+	Tags [2]int32
+}
+
+type podNamed struct {
+	ID   int
+	Meta struct {
+		Score float32
+		Names [2]string
+	}
+	Index map[string]int
+}
+
+func TestIsPlainOldData(t *testing.T) {
+	require.True(t, IsPlainOldData[int]())
+	require.True(t, IsPlainOldData[podPoint]())
+	require.True(t, IsPlainOldData[[4]podPoint]())
+	require.True(t, IsPlainOldData[[0]*int]())
+
+	require.False(t, IsPlainOldData[string]())
+	require.False(t, IsPlainOldData[[]int]())
+	require.False(t, IsPlainOldData[podNamed]())
+}
+
+func TestCheckPlainOldData(t *testing.T) {
+	require.NoError(t, CheckPlainOldData[podPoint]())
+
+	err := CheckPlainOldData[podNamed]()
+	require.ErrorIs(t, err, ErrPointerType)
+	require.EqualError(t, err, "nuke: type contains pointers: nuke.podNamed: "+
+		"field Meta.Names[] is a string; field Index is a map[string]int")
+
+	require.EqualError(t, CheckPlainOldData[map[int]int](), "nuke: type contains pointers: map[int]int")
+}
+
+func TestAssertPlainOldData(t *testing.T) {
+	require.NotPanics(t, AssertPlainOldData[podPoint])
+	requirePanicsWithErrorIs(t, ErrPointerType, AssertPlainOldData[*podPoint])
+}
+
+func TestNewPOD(t *testing.T) {
+	arena := NewSafeArena(1024)
+
+	p := NewPOD[podPoint](arena)
+	p.X = 1
+	s := MakePOD[uint32](arena, 2, 4)
+	require.Len(t, s, 2)
+	require.Equal(t, 4, cap(s))
+	require.Equal(t, uint64(24+16), arena.Stats().BytesInUse)
+	require.Empty(t, arena.TypeStats()) // types are not classified
+
+	require.NotNil(t, NewPOD[podPoint](nil))
+	require.Len(t, MakePOD[byte](nil, 3, 3), 3)
+
+	// The exhaustion policy is not reported by Alloc, hence exhausted arenas fall back to the heap regardless
+	arena = NewSafeArena(64, WithMaxBytes(64), WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	require.Nil(t, MakeSlice[byte](arena, 128, 128))
+	require.Len(t, MakePOD[byte](arena, 128, 128), 128)
+	require.NotNil(t, NewPOD[[128]byte](arena))
+}
+
+func TestNewPODDebugChecks(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	if !debugChecks {
+		require.NotPanics(t, func() { _ = NewPOD[podNamed](arena) })
+		t.Skip("requires the nukedebug tag or -race")
+	}
+	require.PanicsWithError(t, CheckPlainOldData[podNamed]().Error(), func() { _ = NewPOD[podNamed](arena) })
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { _ = MakePOD[string](arena, 1, 1) })
+	require.NotPanics(t, func() { _ = MakePOD[podPoint](arena, 1, 1) })
+}
+
+func TestIsPointerFree(t *testing.T) {
+	require.True(t, IsPointerFree(reflect.TypeOf(podPoint{})))
+	require.False(t, IsPointerFree(reflect.TypeOf(podNamed{})))
+	require.False(t, IsPointerFree(reflect.TypeOf((*error)(nil)).Elem()))
+}
+
+func TestAssertPointerFreeValue(t *testing.T) {
+	require.NotPanics(t, func() { AssertPointerFreeValue(podPoint{}) })
+	require.NotPanics(t, func() { AssertPointerFreeValue(nil) })
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { AssertPointerFreeValue(podNamed{}) })
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { AssertPointerFreeValue(&podPoint{}) })
+}
+
+func TestHasPointersMemoized(t *testing.T) {
+	type memoized struct{ s []int }
+	typ := reflect.TypeOf(memoized{})
+
+	require.True(t, hasPointers(typ))
+	v, ok := pointerVerdict(typ)
+	require.True(t, ok)
+	require.True(t, v)
+
+	// Nested types are memoized along the way.
+	_, ok = pointerVerdict(reflect.TypeOf([]int(nil)))
+	require.True(t, ok)
+}
+
+func BenchmarkIsPlainOldData(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = IsPlainOldData[podNamed]()
+	}
+}
+
+func TestRegisterPOD(t *testing.T) {
+	type handle struct {
+		p *int
+		n int
+	}
+	type wrapper struct {
+		h   [2]handle
+		tag uint32
+	}
+	require.False(t, IsPlainOldData[wrapper]())
+
+	RegisterPOD[handle]()
+	require.True(t, IsPlainOldData[handle]())
+	require.True(t, IsPlainOldData[wrapper]())
+	require.NoError(t, CheckPlainOldData[wrapper]())
+
+	strict := NewMonotonicArena(1024, 1, WithStrictMode())
+	require.NotPanics(t, func() { _ = New[wrapper](strict) })
+
+	safe := NewSafeArena(1024)
+	require.True(t, safe.pod.owns(unsafe.Pointer(New[handle](safe))))
+	require.Empty(t, safe.typed)
+}
+
+func TestUntracedFields(t *testing.T) {
+	type mapping struct {
+		addr unsafe.Pointer `nuke:"nogc"`
+		size uintptr
+	}
+	type mixed struct {
+		m    mapping
+		name string         `nuke:"nogc"` // only unsafe.Pointer fields can be exempted
+		raw  unsafe.Pointer `nuke:"other"`
+	}
+	require.True(t, IsPlainOldData[mapping]())
+	require.True(t, IsPlainOldData[[4]mapping]())
+	require.EqualError(t, CheckPlainOldData[mixed](),
+		"nuke: type contains pointers: nuke.mixed: field name is a string; field raw is a unsafe.Pointer")
+
+	ptrs, untraced := make([]byte, 1), make([]byte, 1)
+	markPointers(reflect.TypeOf(mixed{}), 0, ptrs, untraced)
+	require.Equal(t, []byte{0b10100}, ptrs)
+	require.Equal(t, []byte{0b00001}, untraced)
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "sync/atomic"
+
+// resetCounter is implemented by arenas keeping track of the number of times they have been reset.
+type resetCounter interface {
+	resetCount() uint64
+}
+
+// wrappedResetCount returns the reset count of the arena wrapped by a wrapper, if it keeps track of its resets,
+// or else resets, the number of times the wrapper has reset it.
+func wrappedResetCount(a Arena, resets *atomic.Uint64) uint64 {
+	if rc, ok := a.(resetCounter); ok {
+		return rc.resetCount()
+	}
+	return resets.Load()
+}
+
+// Pool is a set of objects of type T allocated from an arena, which can be individually returned
+// to the pool to be reused by subsequent Get calls. The pool is emptied whenever the arena is reset.
+//
+// Objects are only recycled when the arena can report its resets, or when the arena is nil. Otherwise, objects
+// passed to Put are dropped. Every arena provided by this package reports its resets, whereas wrappers such as
+// CheckedArena report those of the arena they wrap, or else the resets made through them.
+//
+// A Pool is not safe to be accessed concurrently from multiple goroutines.
+type Pool[T any] struct {
+	a      Arena
+	free   []*T
+	resets uint64
+}
+
+// NewPool returns a new pool of objects of type T allocated from the provided arena.
+func NewPool[T any](a Arena) *Pool[T] {
+	p := &Pool[T]{a: a}
+	p.recyclable()
+	return p
+}
+
+// Get returns a zeroed object, either recycled from the pool or freshly allocated from the arena.
+func (p *Pool[T]) Get() *T {
+	p.recyclable()
+	if n := len(p.free); n > 0 {
+		x := p.free[n-1]
+		p.free[n-1] = nil
+		p.free = p.free[:n-1]
+
+		var zero T
+		*x = zero
+		return x
+	}
+	return New[T](p.a)
+}
+
+// Put returns an object obtained from Get to the pool.
+// The object must not be used after invoking this method.
+func (p *Pool[T]) Put(x *T) {
+	if x != nil && p.recyclable() {
+		p.free = append(p.free, x)
+	}
+}
+
+// Len returns the number of objects available for reuse.
+func (p *Pool[T]) Len() int {
+	p.recyclable()
+	return len(p.free)
+}
+
+// recyclable empties the pool if the arena has been reset since the last call,
+// and reports whether objects can be safely recycled.
+func (p *Pool[T]) recyclable() bool {
+	if p.a == nil {
+		return true
+	}
+	rc, ok := p.a.(resetCounter)
+	if !ok {
+		return false
+	}
+	if resets := rc.resetCount(); resets != p.resets {
+		clear(p.free)
+		p.free = p.free[:0]
+		p.resets = resets
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type poolTestStruct struct {
+	a, b int
+}
+
+func TestPoolRecyclesObjects(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	p := NewPool[poolTestStruct](arena)
+
+	x := p.Get()
+	require.True(t, Owns(arena, unsafe.Pointer(x)))
+	x.a, x.b = 1, 2
+
+	p.Put(x)
+	require.Equal(t, 1, p.Len())
+
+	// Recycled objects are handed out zeroed
+	y := p.Get()
+	require.Same(t, x, y)
+	require.Equal(t, poolTestStruct{}, *y)
+	require.Equal(t, 0, p.Len())
+
+	// Fresh objects are allocated once the pool is empty
+	require.NotSame(t, y, p.Get())
+}
+
+func TestPoolEmptiedOnReset(t *testing.T) {
+	for _, arena := range []Arena{
+		NewMonotonicArena(1024, 1),
+		NewConcurrentArena(NewMonotonicArena(1024, 1)),
+		NewConcurrentArena(&mockArena{}),
+	} {
+		p := NewPool[poolTestStruct](arena)
+		p.Put(p.Get())
+		require.Equal(t, 1, p.Len())
+
+		arena.Reset(false)
+		require.Equal(t, 0, p.Len())
+	}
+}
+
+func TestPoolUnknownResets(t *testing.T) {
+	p := NewPool[poolTestStruct](&mockArena{})
+	p.Put(p.Get())
+	require.Equal(t, 0, p.Len())
+
+	p = NewPool[poolTestStruct](nil)
+	p.Put(p.Get())
+	require.Equal(t, 1, p.Len())
+}
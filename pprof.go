@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"compress/gzip"
+	"io"
+	"runtime"
+	"time"
+)
+
+// WriteProfile writes the allocations sampled so far as a gzip-compressed pprof profile, reporting the estimated
+// number of objects and bytes allocated from every call stack, labeled with the allocated type if known, and with
+// the tag of the tagged arena the allocations came through, if any.
+func (a *ProfiledArena) WriteProfile(w io.Writer) error {
+	b := newProfileBuilder()
+	objects := b.valueType("alloc_objects", "count")
+	space := b.valueType("alloc_space", "bytes")
+	typeKey := b.str("type")
+	tagKey := b.str("tag")
+
+	a.mtx.Lock()
+	for k, s := range a.samples {
+		b.samples.message(2, func(m *protoBuffer) {
+			var locs []uint64
+			for _, pc := range k.stack {
+				if pc == 0 {
+					break
+				}
+				locs = append(locs, b.location(pc))
+			}
+			m.packed(1, locs)
+			m.packed(2, []uint64{s.count * a.rate, s.bytes * a.rate})
+			if k.t != nil {
+				m.message(3, func(l *protoBuffer) {
+					l.varintField(1, typeKey)
+					l.varintField(2, b.str(k.t.String()))
+				})
+			}
+			if k.tag != "" {
+				m.message(3, func(l *protoBuffer) {
+					l.varintField(1, tagKey)
+					l.varintField(2, b.str(k.tag))
+				})
+			}
+		})
+	}
+	a.mtx.Unlock()
+
+	var p protoBuffer
+	p.bytesField(1, objects)
+	p.bytesField(1, space)
+	p = append(p, b.samples...)
+	p = append(p, b.locations...)
+	p = append(p, b.functions...)
+	for _, s := range b.strings {
+		p.bytesField(6, []byte(s))
+	}
+	p.varintField(9, uint64(time.Now().UnixNano()))
+	p.bytesField(11, space)
+	p.varintField(12, a.rate)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(p); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// profileBuilder assembles the messages of a profile.proto message, as defined by github.com/google/pprof.
+type profileBuilder struct {
+	strings   []string
+	stringIDs map[string]uint64
+	locIDs    map[uintptr]uint64
+	funcIDs   map[string]uint64
+
+	samples, locations, functions protoBuffer
+}
+
+func newProfileBuilder() *profileBuilder {
+	return &profileBuilder{
+		strings:   []string{""},
+		stringIDs: map[string]uint64{"": 0},
+		locIDs:    make(map[uintptr]uint64),
+		funcIDs:   make(map[string]uint64),
+	}
+}
+
+// str returns the index of s in the string table.
+func (b *profileBuilder) str(s string) uint64 {
+	id, ok := b.stringIDs[s]
+	if !ok {
+		id = uint64(len(b.strings))
+		b.strings = append(b.strings, s)
+		b.stringIDs[s] = id
+	}
+	return id
+}
+
+func (b *profileBuilder) valueType(typ, unit string) []byte {
+	var m protoBuffer
+	m.varintField(1, b.str(typ))
+	m.varintField(2, b.str(unit))
+	return m
+}
+
+// location returns the identifier of the location of the return address pc,
+// holding a line for every function inlined at that address.
+func (b *profileBuilder) location(pc uintptr) uint64 {
+	if id, ok := b.locIDs[pc]; ok {
+		return id
+	}
+	id := uint64(len(b.locIDs) + 1)
+	b.locIDs[pc] = id
+
+	b.locations.message(4, func(m *protoBuffer) {
+		m.varintField(1, id)
+		m.varintField(3, uint64(pc))
+		frames := runtime.CallersFrames([]uintptr{pc})
+		for {
+			f, more := frames.Next()
+			fn := b.function(f)
+			m.message(4, func(l *protoBuffer) {
+				l.varintField(1, fn)
+				l.varintField(2, uint64(f.Line))
+			})
+			if !more {
+				break
+			}
+		}
+	})
+	return id
+}
+
+func (b *profileBuilder) function(f runtime.Frame) uint64 {
+	if id, ok := b.funcIDs[f.Function]; ok {
+		return id
+	}
+	id := uint64(len(b.funcIDs) + 1)
+	b.funcIDs[f.Function] = id
+
+	b.functions.message(5, func(m *protoBuffer) {
+		m.varintField(1, id)
+		m.varintField(2, b.str(f.Function))
+		m.varintField(3, b.str(f.Function))
+		m.varintField(4, b.str(f.File))
+	})
+	return id
+}
+
+// protoBuffer encodes protocol buffer messages.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protoBuffer) varintField(tag int, x uint64) {
+	b.varint(uint64(tag) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) bytesField(tag int, data []byte) {
+	b.varint(uint64(tag)<<3 | 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *protoBuffer) packed(tag int, xs []uint64) {
+	var m protoBuffer
+	for _, x := range xs {
+		m.varint(x)
+	}
+	b.bytesField(tag, m)
+}
+
+func (b *protoBuffer) message(tag int, f func(m *protoBuffer)) {
+	var m protoBuffer
+	f(&m)
+	b.bytesField(tag, m)
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// maxProfileDepth is the maximum number of stack frames recorded for every sampled allocation.
+const maxProfileDepth = 32
+
+// ProfiledArena wraps an arena, sampling one in every rate allocations along with their size, type and call
+// stack, which are otherwise invisible to the runtime memory profiler. The samples can be written as a pprof
+// profile to be analyzed by means of go tool pprof.
+type ProfiledArena struct {
+	a      Arena
+	rate   uint64
+	count  atomic.Uint64
+	resets atomic.Uint64
+
+	mtx     sync.Mutex
+	samples map[profileKey]*profileSample
+}
+
+type profileKey struct {
+	stack [maxProfileDepth]uintptr
+	t     reflect.Type // nil for untyped allocations
+	tag   string       // tag of the tagged arena the allocation came through, if any
+}
+
+type profileSample struct {
+	count, bytes uint64
+}
+
+// NewProfiledArena returns a profiled arena wrapping a, which samples one in every rate allocations.
+// A rate of 1 samples every allocation. The profiled arena is safe for concurrent use if a is.
+func NewProfiledArena(a Arena, rate int) *ProfiledArena {
+	return &ProfiledArena{a: a, rate: uint64(max(rate, 1)), samples: make(map[profileKey]*profileSample)}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *ProfiledArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.sample(nil, size, "")
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *ProfiledArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.sample(t, t.Size()*uintptr(n), "")
+	return allocType(a.a, t, n)
+}
+
+func (a *ProfiledArena) allocTagged(t reflect.Type, size, alignment uintptr, n int, tag string) (unsafe.Pointer, bool) {
+	a.sample(t, size*uintptr(n), tag)
+	if t == nil {
+		return a.a.Alloc(size, alignment), true
+	}
+	return allocType(a.a, t, n)
+}
+
+// sample records the allocation, along with the stack of the caller of the arena.
+func (a *ProfiledArena) sample(t reflect.Type, size uintptr, tag string) {
+	if a.count.Add(1)%a.rate != 0 {
+		return
+	}
+	k := profileKey{t: t, tag: tag}
+	skip := 3
+	if tag != "" {
+		skip++ // skip the tagged arena as well
+	}
+	runtime.Callers(skip, k.stack[:])
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	s := a.samples[k]
+	if s == nil {
+		s = &profileSample{}
+		a.samples[k] = s
+	}
+	s.count++
+	s.bytes += uint64(size)
+}
+
+// Reset satisfies the Arena interface. Samples are kept across resets.
+func (a *ProfiledArena) Reset(release bool) {
+	a.a.Reset(release)
+	a.resets.Add(1)
+}
+
+// Stats satisfies the StatsProvider interface.
+// It returns zero statistics if the underlying arena does not implement StatsProvider.
+func (a *ProfiledArena) Stats() Stats {
+	return statsOf(a.a)
+}
+
+// Owns satisfies the Owner interface.
+func (a *ProfiledArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *ProfiledArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.resets)
+}
+
+func (a *ProfiledArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiledArenaSampling(t *testing.T) {
+	arena := NewProfiledArena(NewMonotonicArena(64*1024, 1), 4)
+	for i := 0; i < 16; i++ {
+		_ = New[allocTestStruct](arena)
+	}
+	_ = arena.Alloc(100, 1)
+
+	var count, bytes uint64
+	for k, s := range arena.samples {
+		require.Equal(t, reflect.TypeOf(allocTestStruct{}), k.t)
+		count += s.count
+		bytes += s.bytes
+	}
+	require.Equal(t, uint64(4), count)
+	require.Equal(t, uint64(4*32), bytes)
+	require.Len(t, arena.samples, 1)
+
+	arena.Reset(false)
+	require.Len(t, arena.samples, 1)
+	require.Equal(t, arena.a.(StatsProvider).Stats(), arena.Stats())
+}
+
+func TestProfiledArenaWriteProfile(t *testing.T) {
+	arena := NewProfiledArena(NewMonotonicArena(64*1024, 1), 1)
+	_ = New[allocTestStruct](arena)
+	_ = MakeSlice[int](arena, 8, 8)
+	_ = arena.Alloc(100, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, arena.WriteProfile(&buf))
+
+	zr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	p, err := io.ReadAll(zr)
+	require.NoError(t, err)
+
+	// The string table references the sample types, the allocated types and the allocating functions.
+	for _, s := range []string{"alloc_space", "nuke.allocTestStruct", "int", "TestProfiledArenaWriteProfile", "profiled_arena_test.go"} {
+		require.Contains(t, string(p), s)
+	}
+}
+
+func TestProtoBuffer(t *testing.T) {
+	var b protoBuffer
+	b.varintField(1, 150)
+	b.packed(2, []uint64{3, 270})
+	b.message(3, func(m *protoBuffer) { m.bytesField(1, []byte("ab")) })
+	require.Equal(t, []byte{0x08, 0x96, 0x01, 0x12, 0x03, 0x03, 0x8e, 0x02, 0x1a, 0x04, 0x0a, 0x02, 'a', 'b'}, []byte(b))
+}
+
+func runtimeFuncName(pc uintptr) (string, bool) {
+	f := runtime.FuncForPC(pc - 1)
+	if f == nil {
+		return "", false
+	}
+	return f.Name(), true
+}
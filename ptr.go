@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Ptr is a checked pointer to a value of type T allocated from an arena. It records the generation of the arena,
+// that is, the number of times it had been reset, at allocation time, so that dereferencing it after the arena
+// is reset panics rather than silently reading or corrupting memory handed out to someone else.
+//
+// Pointers are only checked when the arena can report its resets, which every arena provided by this package does.
+// Wrappers, such as CheckedArena or the arenas returned by Tagged, report the resets of the arena they wrap if it
+// can, and otherwise those made through them, hence resetting a wrapped arena of another package directly does not
+// invalidate the pointers allocated through its wrapper. Pointers to values allocated from arenas that cannot report
+// their resets, or from a nil arena, are never invalidated.
+type Ptr[T any] struct {
+	p   *T
+	rc  resetCounter
+	gen uint64
+}
+
+// NewPtr allocates a value of type T from the provided arena as New does, and returns a checked pointer to it.
+func NewPtr[T any](a Arena) Ptr[T] {
+	return PtrTo(a, New[T](a))
+}
+
+// PtrTo returns a checked pointer to a value of type T previously allocated from the provided arena,
+// which becomes invalid as soon as the arena is reset.
+func PtrTo[T any](a Arena, p *T) Ptr[T] {
+	ptr := Ptr[T]{p: p}
+	if rc, ok := a.(resetCounter); ok {
+		ptr.rc, ptr.gen = rc, rc.resetCount()
+	}
+	return ptr
+}
+
+// Get returns the pointer to the value, panicking with ErrUseAfterReset if the arena has been reset
+// since the value was allocated.
+func (p Ptr[T]) Get() *T {
+	if p.rc != nil {
+		if gen := p.rc.resetCount(); gen != p.gen {
+			panic(fmt.Errorf("%w: *%s allocated at generation %d, arena is at generation %d",
+				ErrUseAfterReset, reflect.TypeOf(p.p).Elem(), p.gen, gen))
+		}
+	}
+	return p.p
+}
+
+// Valid reports whether the pointer can be dereferenced, that is, the arena has not been reset since the value
+// was allocated. The zero Ptr is valid, and holds a nil pointer.
+func (p Ptr[T]) Valid() bool {
+	return p.rc == nil || p.rc.resetCount() == p.gen
+}
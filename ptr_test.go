@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPtr(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+
+	p := NewPtr[int](arena)
+	*p.Get() = 42
+	require.True(t, p.Valid())
+	require.Equal(t, 42, *p.Get())
+
+	arena.Reset(false)
+	require.False(t, p.Valid())
+	require.PanicsWithError(t, "nuke: use after reset: *int allocated at generation 0, arena is at generation 1", func() {
+		_ = p.Get()
+	})
+
+	p = PtrTo(arena, New[int](arena))
+	require.True(t, p.Valid())
+	arena.Reset(true)
+	require.False(t, p.Valid())
+}
+
+func TestPtrUnchecked(t *testing.T) {
+	var zero Ptr[int]
+	require.True(t, zero.Valid())
+	require.Nil(t, zero.Get())
+
+	p := NewPtr[int](nil)
+	require.NotNil(t, p.Get())
+
+	// Arenas not reporting their resets cannot invalidate pointers
+	arena := allocHookArena{}
+	p = NewPtr[int](arena)
+	arena.Reset(false)
+	require.True(t, p.Valid())
+}
+
+func TestPtrInvalidatedOnReset(t *testing.T) {
+	wrappers := []struct {
+		name string
+		wrap func(a Arena) Arena
+	}{
+		{name: "unwrapped", wrap: func(a Arena) Arena { return a }},
+		{name: "checked", wrap: func(a Arena) Arena { return NewCheckedArena(a) }},
+		{name: "profiled", wrap: func(a Arena) Arena { return NewProfiledArena(a, 1) }},
+		{name: "instrumented", wrap: func(a Arena) Arena { return NewInstrumentedArena(a, Instrumentation{}) }},
+		{name: "tagged", wrap: func(a Arena) Arena { return Tagged(a, "tag") }},
+		{name: "guarded", wrap: func(a Arena) Arena { return NewGuardedArena(a) }},
+		{name: "threshold", wrap: func(a Arena) Arena { return NewThresholdArena(a, 1024) }},
+	}
+	for _, tc := range warmedArenas() {
+		for _, w := range wrappers {
+			t.Run(tc.name+"/"+w.name, func(t *testing.T) {
+				arena := w.wrap(tc.arena)
+				p := NewPtr[int](arena)
+				require.True(t, p.Valid())
+				arena.Reset(false)
+				require.False(t, p.Valid())
+
+				// Resetting the wrapped arena invalidates the pointers allocated through the wrapper.
+				p = NewPtr[int](arena)
+				tc.arena.Reset(false)
+				require.False(t, p.Valid())
+			})
+		}
+	}
+
+	// Wrappers count the resets made through them when the wrapped arena does not.
+	arena := NewCheckedArena(allocHookArena{})
+	p := NewPtr[int](arena)
+	arena.Reset(false)
+	require.False(t, p.Valid())
+}
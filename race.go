@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build race
+
+package nuke
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// raceReclaim reports size bytes starting at ptr as being written by the goroutine resetting the arena,
+// so that the race detector flags accesses through pointers handed out before the reset that are not
+// ordered with it.
+func raceReclaim(ptr unsafe.Pointer, size uintptr) {
+	if ptr != nil && size > 0 {
+		runtime.RaceWriteRange(ptr, int(size))
+	}
+}
+
+// raceReset marks the reset of the arena identified by addr as happening before the allocations that follow it.
+func raceReset(addr unsafe.Pointer) {
+	runtime.RaceReleaseMerge(addr)
+}
+
+// raceAlloc orders an allocation from the arena identified by addr after the last reset of the arena,
+// which reclaimed the memory being handed out again.
+func raceAlloc(addr unsafe.Pointer) {
+	runtime.RaceAcquire(addr)
+}
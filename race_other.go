@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !race
+
+package nuke
+
+import "unsafe"
+
+func raceReclaim(unsafe.Pointer, uintptr) {}
+
+func raceReset(unsafe.Pointer) {}
+
+func raceAlloc(unsafe.Pointer) {}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build race
+
+package nuke
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// raceChildEnv is set when running a test in a child process, whose races are reported to the parent.
+const raceChildEnv = "NUKE_RACE_CHILD"
+
+func TestRaceAcrossReset(t *testing.T) {
+	arenas := map[string]func() Arena{
+		"monotonic": func() Arena { return NewMonotonicArena(1024, 1, WithLazyZeroing()) },
+		"safe":      func() Arena { return NewSafeArena(1024, WithLazyZeroing()) },
+	}
+	if name := os.Getenv(raceChildEnv); name != "" {
+		arena := arenas[name[1:]]()
+		p := New[int](arena)
+		done := make(chan struct{})
+		go func() {
+			*p = 42
+			if name[0] == '+' {
+				close(done)
+			}
+		}()
+		if name[0] == '+' {
+			<-done
+		} else {
+			time.Sleep(100 * time.Millisecond) // does not order the write before the reset
+		}
+		arena.Reset(false)
+		_ = New[int](arena)
+		return
+	}
+
+	for name := range arenas {
+		t.Run(name, func(t *testing.T) {
+			out, err := runRaceChild(t, "-"+name)
+			require.Error(t, err)
+			require.Contains(t, out, "WARNING: DATA RACE")
+			require.Contains(t, out, "runtime.racewriterange")
+
+			// Writes ordered before the reset are fine
+			out, err = runRaceChild(t, "+"+name)
+			require.NoError(t, err, out)
+		})
+	}
+}
+
+func runRaceChild(t *testing.T, name string) (string, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRaceAcrossReset$")
+	cmd.Env = append(os.Environ(), raceChildEnv+"="+name)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
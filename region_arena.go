@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// RegionArena hands out regions, arenas drawing from shared slabs whose lifetimes are independent from one another:
+// every region can be reset or released on its own, and a slab is reclaimed once every region that allocated from
+// it has been reset or released. Regions can have child regions, which are reset and released along with their
+// parent. This fits mixed lifetimes, such as cache entries and requests, for which resetting a whole arena at once
+// is too coarse.
+//
+// Slabs are backed by byte slices, which the GC does not scan, hence regions are meant to hold POD types.
+// A RegionArena and its regions are not safe to be accessed concurrently from multiple goroutines.
+type RegionArena struct {
+	slabSize int
+	opts     options
+
+	current *regionSlab   // slab regions allocate from
+	free    []*regionSlab // reclaimed slabs, ready to be reused
+	slabs   int           // number of slabs, including free ones
+	bytes   uint64        // overall size of the slabs
+	inUse   uint64
+	regions []*Region
+}
+
+type regionSlab struct {
+	buf    []byte
+	offset uintptr
+	refs   int // number of live regions the slab holds memory of
+}
+
+// Region is an arena allocating from the slabs of a RegionArena, which can be reset, or released, independently
+// from the other regions. A released region must not be used anymore.
+type Region struct {
+	a        *RegionArena
+	name     string
+	parent   *Region
+	children []*Region
+	slabs    []*regionSlab // slabs holding memory of the region, which it holds a reference to
+	counters arenaCounters
+	hooks    resetHooks
+	released bool
+}
+
+// NewRegionArena returns a RegionArena whose slabs are slabSize bytes long. Allocations larger than slabSize are
+// served from dedicated slabs, which are dropped once reclaimed.
+func NewRegionArena(slabSize int, opts ...Option) *RegionArena {
+	return &RegionArena{slabSize: slabSize, opts: newOptions(opts)}
+}
+
+// NewRegion returns a new top-level region, identified by name in statistics.
+func (a *RegionArena) NewRegion(name string) *Region {
+	r := &Region{a: a, name: name}
+	a.regions = append(a.regions, r)
+	return r
+}
+
+// NewRegion returns a new child region of r, which is reset and released along with r.
+func (r *Region) NewRegion(name string) *Region {
+	r.checkLive()
+	c := &Region{a: r.a, name: name, parent: r}
+	r.children = append(r.children, c)
+	return c
+}
+
+// Name returns the name of the region.
+func (r *Region) Name() string {
+	return r.name
+}
+
+// Alloc satisfies the Arena interface.
+func (r *Region) Alloc(size, alignment uintptr) unsafe.Pointer {
+	return r.alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (r *Region) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !r.a.opts.allowPointers(t, &r.counters) {
+		return nil, true
+	}
+	return r.alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+func (r *Region) alloc(size, alignment uintptr) unsafe.Pointer {
+	r.checkLive()
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase)
+	}
+	a := r.a
+	s := a.current
+	if uintptr(a.slabSize) < size+alignment-1 {
+		s = a.newSlab(int(size + alignment - 1)) // dedicated to the allocation
+	} else if s == nil || !s.fits(size, alignment) {
+		// The current slab is reclaimed once the regions holding memory of it are reset.
+		s = a.nextSlab()
+		a.current = s
+	}
+	if n := len(r.slabs); n == 0 || r.slabs[n-1] != s {
+		r.slabs = append(r.slabs, s)
+		s.refs++
+	}
+	ptr, n := s.alloc(size, alignment)
+	r.counters.allocated(uint64(n))
+	a.inUse += uint64(n)
+	return ptr
+}
+
+func (s *regionSlab) fits(size, alignment uintptr) bool {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(s.buf)))
+	aligned := (base + s.offset + alignment - 1) / alignment * alignment
+	return aligned+size-base <= uintptr(len(s.buf))
+}
+
+// alloc bump allocates size bytes from the slab, which must fit them, returning the number of bytes it took.
+func (s *regionSlab) alloc(size, alignment uintptr) (unsafe.Pointer, uintptr) {
+	base := unsafe.Pointer(unsafe.SliceData(s.buf))
+	pad := (alignment - (uintptr(base)+s.offset)%alignment) % alignment
+	ptr := unsafe.Add(base, s.offset+pad)
+	s.offset += pad + size
+	return ptr, pad + size
+}
+
+// nextSlab returns a reclaimed slab, or a new one if there is none.
+func (a *RegionArena) nextSlab() *regionSlab {
+	if n := len(a.free); n > 0 {
+		s := a.free[n-1]
+		a.free[n-1] = nil
+		a.free = a.free[:n-1]
+		return s
+	}
+	return a.newSlab(a.slabSize)
+}
+
+func (a *RegionArena) newSlab(size int) *regionSlab {
+	traceGrowth(uintptr(size))
+	a.slabs++
+	a.bytes += uint64(size)
+	return &regionSlab{buf: make([]byte, size)}
+}
+
+// reclaim zeroes out a slab no region holds memory of, making it available for reuse,
+// unless it is a dedicated slab, which is dropped.
+func (a *RegionArena) reclaim(s *regionSlab) {
+	raceReclaim(unsafe.Pointer(unsafe.SliceData(s.buf)), s.offset)
+	clear(s.buf[:s.offset])
+	s.offset = 0
+	switch {
+	case s == a.current:
+		// Keep allocating from the start of the slab.
+	case len(s.buf) == a.slabSize:
+		a.free = append(a.free, s)
+	default:
+		a.slabs--
+		a.bytes -= uint64(len(s.buf))
+	}
+}
+
+// Reset satisfies the Arena interface. The memory allocated from the region, as well as from its child regions,
+// which are reset too, becomes invalid, and the slabs no other region holds memory of are reclaimed.
+// The release argument is ignored, as slabs are shared.
+func (r *Region) Reset(bool) {
+	r.checkLive()
+	r.reset()
+}
+
+func (r *Region) reset() {
+	defer traceReset().End()
+	r.hooks.run()
+	for _, c := range r.children {
+		c.reset()
+	}
+	for i, s := range r.slabs {
+		if s.refs--; s.refs == 0 {
+			r.a.reclaim(s)
+		}
+		r.slabs[i] = nil
+	}
+	r.slabs = r.slabs[:0]
+	r.a.inUse -= r.counters.bytesInUse
+	r.counters.reset()
+	raceReset(unsafe.Pointer(r))
+}
+
+// Release resets the region, as well as its child regions, and detaches it from its parent. Using the region
+// after releasing it panics with ErrArenaClosed, whereas releasing it again is a no-op.
+func (r *Region) Release() {
+	if r.released {
+		return
+	}
+	r.reset()
+	r.markReleased()
+	siblings := &r.a.regions
+	if r.parent != nil {
+		siblings = &r.parent.children
+	}
+	for i, c := range *siblings {
+		if c == r {
+			*siblings = append((*siblings)[:i], (*siblings)[i+1:]...)
+			break
+		}
+	}
+}
+
+func (r *Region) markReleased() {
+	r.released = true
+	for _, c := range r.children {
+		c.markReleased()
+	}
+	r.children = nil
+}
+
+func (r *Region) checkLive() {
+	if r.released {
+		panic(fmt.Errorf("%w: region %q used after Release", ErrArenaClosed, r.name))
+	}
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (r *Region) OnReset(f func()) {
+	r.hooks.add(f)
+}
+
+func (r *Region) watchResets(f func()) {
+	r.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface, describing the memory the region allocated.
+// Buffers is the number of slabs holding memory of the region.
+func (r *Region) Stats() Stats {
+	s := r.counters.stats()
+	s.Buffers = len(r.slabs)
+	for _, slab := range r.slabs {
+		s.BytesAllocated += uint64(len(slab.buf))
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface, reporting pointers into the slabs the region holds memory of,
+// which may be shared with other regions.
+func (r *Region) Owns(ptr unsafe.Pointer) bool {
+	for _, s := range r.slabs {
+		if within(unsafe.Pointer(unsafe.SliceData(s.buf)), s.offset, ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Region) resetCount() uint64 {
+	return r.counters.resets
+}
+
+// Stats returns the statistics of the slabs of the arena: BytesAllocated and Buffers account for every slab,
+// including reclaimed ones, whereas BytesInUse accounts for the memory of the live regions.
+func (a *RegionArena) Stats() Stats {
+	return Stats{BytesAllocated: a.bytes, BytesInUse: a.inUse, Buffers: a.slabs}
+}
+
+// Regions returns the live top-level regions.
+func (a *RegionArena) Regions() []*Region {
+	return append([]*Region(nil), a.regions...)
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionArenaSharedSlabs(t *testing.T) {
+	arena := NewRegionArena(64)
+	cache, req := arena.NewRegion("cache"), arena.NewRegion("request")
+
+	c1 := New[[4]uint64](cache)
+	r1 := New[[4]uint64](req)
+	require.Equal(t, unsafe.Add(unsafe.Pointer(c1), 32), unsafe.Pointer(r1)) // both regions share the slab
+	r2 := New[[4]uint64](req)                                                // takes a new slab
+	require.Equal(t, Stats{BytesAllocated: 128, BytesInUse: 96, Buffers: 2}, arena.Stats())
+	require.Equal(t, 2, req.Stats().Buffers)
+
+	// Resetting the request region reclaims the current slab only, as the first one holds cached memory too.
+	r2[0] = 1
+	req.Reset(false)
+	require.Zero(t, r2[0])
+	require.Equal(t, uint64(32), arena.Stats().BytesInUse)
+	require.Equal(t, unsafe.Pointer(r2), unsafe.Pointer(New[[4]uint64](req)))
+
+	// Releasing the cache region as well makes the first slab reusable.
+	c1[3] = 1
+	cache.Release()
+	require.Zero(t, c1[3])
+	require.Panics(t, func() { New[int](cache) })
+	cache.Release()
+	require.Equal(t, []*Region{req}, arena.Regions())
+
+	_ = New[[4]uint64](req)
+	require.Equal(t, unsafe.Pointer(c1), unsafe.Pointer(New[[4]uint64](req)))
+	require.Equal(t, Stats{BytesAllocated: 128, BytesInUse: 96, Buffers: 2}, arena.Stats())
+}
+
+func TestRegionArenaChildRegions(t *testing.T) {
+	arena := NewRegionArena(1024)
+	parent := arena.NewRegion("session")
+	child := parent.NewRegion("request")
+	grandchild := child.NewRegion("scratch")
+
+	_ = New[int](parent)
+	p := New[int](grandchild)
+	require.True(t, Owns(grandchild, unsafe.Pointer(p)))
+	require.Equal(t, "scratch", grandchild.Name())
+
+	// Resetting a region resets its children.
+	parent.Reset(false)
+	require.Zero(t, arena.Stats().BytesInUse)
+	require.Equal(t, uint64(1), grandchild.Stats().Resets)
+	require.False(t, Owns(grandchild, unsafe.Pointer(p)))
+
+	// Releasing a region releases its children, and detaches it from its parent.
+	_ = New[int](grandchild)
+	child.Release()
+	require.Empty(t, parent.children)
+	require.PanicsWithError(t, `nuke: arena closed: region "scratch" used after Release`, func() { New[int](grandchild) })
+	require.Zero(t, arena.Stats().BytesInUse)
+}
+
+func TestRegionArenaDedicatedSlabs(t *testing.T) {
+	arena := NewRegionArena(64)
+	r := arena.NewRegion("r")
+
+	s := MakeSlice[byte](r, 100, 100)
+	require.True(t, Owns(r, unsafe.Pointer(&s[99])))
+	require.Equal(t, 1, arena.Stats().Buffers)
+	_ = New[int](r)
+
+	r.Release()
+	require.Equal(t, Stats{BytesAllocated: 64, Buffers: 1}, arena.Stats())
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// resetHistorySize is the number of recent resets kept for every registered arena.
+const resetHistorySize = 16
+
+// registry holds the arenas registered by name, for them to be reported by the package-wide publishers.
+var registry struct {
+	mtx     sync.RWMutex
+	arenas  map[string]Arena
+	history map[string]*resetHistory
+}
+
+// resetHistory records the times of the recent resets of an arena.
+type resetHistory struct {
+	mtx        sync.Mutex
+	times      [resetHistorySize]time.Time
+	n          int
+	registered bool
+}
+
+func (h *resetHistory) record() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.registered {
+		h.times[h.n%resetHistorySize] = time.Now()
+		h.n++
+	}
+}
+
+// Register registers the arena under the given name, so that it is reported along with the rest of registered
+// arenas by PublishRegistryExpvar and the debug handler of the nukehttp package. As those inspect the arena from
+// the goroutines serving the reports, the arena must be safe for concurrent use, such as those returned by
+// NewConcurrentArena, which also wraps any other arena. It panics if the arena is not safe for concurrent use,
+// or if the name is already registered.
+func Register(name string, a Arena) {
+	if !isConcurrencySafe(a) {
+		panic(fmt.Sprintf("nuke: arena %q of type %T is not safe for concurrent use", name, a))
+	}
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+	if _, ok := registry.arenas[name]; ok {
+		panic(fmt.Sprintf("nuke: arena %q already registered", name))
+	}
+	if registry.arenas == nil {
+		registry.arenas = make(map[string]Arena)
+		registry.history = make(map[string]*resetHistory)
+	}
+	registry.arenas[name] = a
+	if rw, ok := a.(resetWatcher); ok {
+		h := &resetHistory{registered: true}
+		rw.watchResets(h.record)
+		registry.history[name] = h
+	}
+}
+
+// Unregister removes the arena registered under the given name, if any.
+func Unregister(name string) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+	delete(registry.arenas, name)
+	if h := registry.history[name]; h != nil {
+		// The arena cannot stop being watched, hence the history merely stops recording.
+		h.mtx.Lock()
+		h.registered = false
+		h.mtx.Unlock()
+		delete(registry.history, name)
+	}
+}
+
+// Lookup returns the arena registered under the given name.
+func Lookup(name string) (Arena, bool) {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+	a, ok := registry.arenas[name]
+	return a, ok
+}
+
+// ResetHistory returns the times of the most recent resets of the arena registered under the given name,
+// the oldest first. Resets are only recorded for arenas implemented by this package.
+func ResetHistory(name string) []time.Time {
+	registry.mtx.RLock()
+	h := registry.history[name]
+	registry.mtx.RUnlock()
+	if h == nil {
+		return nil
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	times := make([]time.Time, 0, min(h.n, resetHistorySize))
+	for i := max(h.n-resetHistorySize, 0); i < h.n; i++ {
+		times = append(times, h.times[i%resetHistorySize])
+	}
+	return times
+}
+
+// Registered returns the sorted names of the registered arenas.
+func Registered() []string {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+	names := make([]string, 0, len(registry.arenas))
+	for name := range registry.arenas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// statsOf returns the statistics of the arena, or zero statistics if it does not implement StatsProvider.
+func statsOf(a Arena) Stats {
+	if sp, ok := a.(StatsProvider); ok {
+		return sp.Stats()
+	}
+	return Stats{}
+}
+
+// concurrencySafeArena is implemented by the arenas of this package that are safe for concurrent use,
+// which wrappers forward to the arena they wrap.
+type concurrencySafeArena interface {
+	concurrencySafe() bool
+}
+
+// isConcurrencySafe reports whether the arena is known to be safe for concurrent use.
+func isConcurrencySafe(a Arena) bool {
+	cs, ok := a.(concurrencySafeArena)
+	return ok && cs.concurrencySafe()
+}
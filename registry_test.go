@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	a, b := NewConcurrentArena(NewMonotonicArena(1024, 1)), NewConcurrentSafeArena(1024)
+	Register("registry-b", b)
+	Register("registry-a", a)
+	defer Unregister("registry-a")
+	defer Unregister("registry-b")
+
+	require.Subset(t, Registered(), []string{"registry-a", "registry-b"})
+	got, ok := Lookup("registry-a")
+	require.True(t, ok)
+	require.Same(t, a, got)
+
+	require.Panics(t, func() { Register("registry-a", b) })
+	require.Panics(t, func() { Register("registry-unsafe", NewSafeArena(1024)) })
+	require.Panics(t, func() { Register("registry-unsafe", NewCheckedArena(NewMonotonicArena(1024, 1))) })
+	Register("registry-checked", NewCheckedArena(a))
+	Unregister("registry-checked")
+	require.NotContains(t, Registered(), "registry-unsafe")
+
+	Unregister("registry-a")
+	_, ok = Lookup("registry-a")
+	require.False(t, ok)
+	require.NotContains(t, Registered(), "registry-a")
+}
+
+func TestResetHistory(t *testing.T) {
+	arena := NewConcurrentArena(NewMonotonicArena(1024, 1))
+	Register("history", arena)
+	require.Empty(t, ResetHistory("history"))
+
+	start := time.Now()
+	for i := 0; i < resetHistorySize+3; i++ {
+		arena.Reset(false)
+	}
+	history := ResetHistory("history")
+	require.Len(t, history, resetHistorySize)
+	require.False(t, history[0].Before(start))
+	for i := 1; i < len(history); i++ {
+		require.False(t, history[i].Before(history[i-1]))
+	}
+
+	Unregister("history")
+	require.Nil(t, ResetHistory("history"))
+
+	// Registering the arena again starts a new history.
+	Register("history", arena)
+	defer Unregister("history")
+	arena.Reset(false)
+	require.Len(t, ResetHistory("history"), 1)
+	require.Nil(t, ResetHistory("unregistered"))
+}
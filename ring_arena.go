@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// RingArena is an arena allocating from a circular buffer, whose write head wraps around to the start
+// of the buffer once it reaches the end, implicitly invalidating the oldest allocations. It suits streaming
+// pipelines only needing the most recent allocations to remain valid, which do not have to reset the arena.
+// As memory is reused without resetting the arena, it is zeroed out as it is handed out.
+//
+// A guarded ring arena additionally keeps track of the allocations still in use, which are the ones made
+// since the mark last passed to Release, and panics with ErrRingOverwrite rather than overwriting them.
+//
+// A RingArena is not safe to be accessed concurrently from multiple goroutines.
+type RingArena struct {
+	ptr   unsafe.Pointer
+	size  uintptr
+	guard bool
+	opts  options
+
+	// head and tail are absolute positions, which keep increasing as the head wraps around,
+	// so that the bytes in use are the ones between both.
+	head, tail uint64
+
+	counters arenaCounters
+	hooks    resetHooks
+}
+
+// RingMark records the position of the write head of a RingArena.
+type RingMark struct {
+	pos uint64
+}
+
+// NewRingArena creates a new ring arena backed by a buffer of size bytes, which is guarded against overwriting
+// allocations still in use if guard is set. Allocations larger than the buffer trigger the exhaustion policy.
+func NewRingArena(size int, guard bool, opts ...Option) *RingArena {
+	return &RingArena{
+		size:  uintptr(size),
+		guard: guard,
+		opts:  newOptions(opts),
+	}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *RingArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *RingArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *RingArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	if a.ptr == nil {
+		buf := make([]byte, a.size) // allocate the buffer lazily
+		a.ptr = unsafe.Pointer(unsafe.SliceData(buf))
+	}
+	start := a.head
+	offset := uintptr(start % uint64(a.size))
+	pad := (alignment - (uintptr(a.ptr)+offset)%alignment) % alignment
+	if offset+pad+size > a.size {
+		// Wrap around, skipping the end of the buffer.
+		start += uint64(a.size - offset)
+		offset = 0
+		pad = (alignment - uintptr(a.ptr)%alignment) % alignment
+	}
+	if pad+size > a.size {
+		switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+		case ExhaustedReturnNil:
+			return nil, false
+
+		case ExhaustedPanic:
+			panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+		default:
+			a.counters.heapFallbacks++
+			traceFallback(size)
+			return nil, true
+		}
+	}
+	end := start + uint64(pad+size)
+	if a.guard && end-a.tail > uint64(a.size) {
+		panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrRingOverwrite, size))
+	}
+	a.head = end
+	a.counters.bytesInUse = min(a.head-a.tail, uint64(a.size))
+	a.counters.highWaterMark = max(a.counters.highWaterMark, a.counters.bytesInUse)
+
+	clear(unsafe.Slice((*byte)(unsafe.Add(a.ptr, offset)), pad+size))
+	return unsafe.Add(a.ptr, offset+pad), true
+}
+
+// Mark returns the current position of the write head.
+func (a *RingArena) Mark() RingMark {
+	return RingMark{pos: a.head}
+}
+
+// Release marks every allocation made before the mark was returned by Mark as no longer in use,
+// allowing a guarded arena to overwrite them. It is a no-op for marks preceding previously released ones.
+func (a *RingArena) Release(m RingMark) {
+	a.tail = min(max(a.tail, m.pos), a.head)
+	a.counters.bytesInUse = min(a.head-a.tail, uint64(a.size))
+}
+
+// Reset satisfies the Arena interface.
+func (a *RingArena) Reset(release bool) {
+	defer traceReset().End()
+	a.hooks.run()
+	if release {
+		a.ptr = nil
+	}
+	a.head, a.tail = 0, 0
+	a.counters.reset()
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *RingArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *RingArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. BytesInUse is the number of bytes allocated since the mark
+// last passed to Release, bounded by the size of the buffer.
+func (a *RingArena) Stats() Stats {
+	s := a.counters.stats()
+	s.Buffers = 1
+	if a.ptr != nil {
+		s.BytesAllocated = uint64(a.size)
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *RingArena) Owns(ptr unsafe.Pointer) bool {
+	return within(a.ptr, a.size, ptr)
+}
+
+func (a *RingArena) resetCount() uint64 {
+	return a.counters.resets
+}
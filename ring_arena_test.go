@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingArenaWrapsAround(t *testing.T) {
+	arena := NewRingArena(64, false)
+
+	first := MakeSlice[byte](arena, 24, 24)
+	copy(first, "first")
+	second := MakeSlice[byte](arena, 24, 24)
+	copy(second, "second")
+
+	// Does not fit the end of the buffer, hence it overwrites the first allocation.
+	third := MakeSlice[byte](arena, 24, 24)
+	require.Equal(t, unsafe.Pointer(&first[0]), unsafe.Pointer(&third[0]))
+	require.Equal(t, make([]byte, 24), third)
+	require.Equal(t, "second", string(second[:6]))
+
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		BytesInUse:     64,
+		Buffers:        1,
+		HighWaterMark:  64,
+	}, arena.Stats())
+}
+
+func TestRingArenaAlignment(t *testing.T) {
+	arena := NewRingArena(64, false)
+
+	_ = New[byte](arena)
+	i := New[int64](arena)
+	require.Zero(t, uintptr(unsafe.Pointer(i))%8)
+	require.Equal(t, uint64(16), arena.Stats().BytesInUse)
+}
+
+func TestRingArenaGuard(t *testing.T) {
+	arena := NewRingArena(64, true)
+
+	_ = MakeSlice[byte](arena, 32, 32)
+	m := arena.Mark()
+	_ = MakeSlice[byte](arena, 32, 32)
+
+	requirePanicsWithErrorIs(t, ErrRingOverwrite, func() {
+		_ = New[byte](arena)
+	})
+
+	// Releasing the first allocation makes room for the next ones.
+	arena.Release(m)
+	require.Equal(t, uint64(32), arena.Stats().BytesInUse)
+	_ = MakeSlice[byte](arena, 32, 32)
+	requirePanicsWithErrorIs(t, ErrRingOverwrite, func() {
+		_ = New[byte](arena)
+	})
+
+	arena.Reset(false)
+	require.NotNil(t, MakeSlice[byte](arena, 64, 64))
+}
+
+func TestRingArenaOversizedAllocation(t *testing.T) {
+	arena := NewRingArena(64, true, WithStrictMode())
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() {
+		_ = MakeSlice[byte](arena, 65, 65)
+	})
+}
@@ -0,0 +1,634 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+const (
+	// defaultTypedSlots is the default number of values the first slab of a typed slab group holds.
+	defaultTypedSlots = 64
+
+	// typedSlabShare is the fraction of the expected size of an arena created by NewSafeArenaOfSize
+	// the first slab of every typed slab group takes, the POD slab group taking half of it.
+	typedSlabShare = 16
+)
+
+// defaultShrinkPolicy releases one slab of the groups whose usage stayed below a quarter of their capacity.
+var defaultShrinkPolicy = ShrinkBelowUsage(0.25, 1, 0)
+
+// byteType is the element type of the slabs backing the POD slab group.
+var byteType = reflect.TypeOf(byte(0))
+
+// zeroSizedBase is the address returned for zero-sized allocations.
+var zeroSizedBase uintptr
+
+// SafeArena is an arena that can hold values of any type, including types containing pointers.
+// Values of pointer-free types, as well as raw allocations requested through Alloc, are served from
+// a POD slab group backed by byte slices, which the GC does not need to scan. Any other type is served
+// from a typed slab group, backed by slices of a type sharing its GC shape, that is, its size, alignment and
+// pointer layout, so that the GC traces every pointer stored in the arena. Slab groups grow on demand, doubling the size of their newest slab.
+//
+// A SafeArena is not safe to be accessed concurrently from multiple goroutines.
+type SafeArena struct {
+	opts options
+
+	pod      *slabGroup
+	typed    map[gcShape]*slabGroup
+	counters arenaCounters
+	hooks    resetHooks
+
+	// slabBytes is the size of every slab of the arena, which WithMaxBytes limits.
+	slabBytes uintptr
+
+	// types caches the slab group serving each type, sparing the classification of types on every allocation.
+	types map[reflect.Type]*safeType
+
+	// lastType and last short-circuit the lookup of consecutive allocations of the same type.
+	lastType reflect.Type
+	last     *safeType
+}
+
+// safeType holds the slab group serving a type, along with its allocation statistics.
+type safeType struct {
+	group *slabGroup
+	stats TypeStats
+	free  []unsafe.Pointer // values handed back by Free, zeroed and ready to be reused
+}
+
+// TypeStats holds the allocation statistics of a type since the last Reset.
+type TypeStats struct {
+	// Objects is the number of values allocated.
+	Objects uint64
+
+	// Bytes is the number of bytes allocated, excluding alignment padding.
+	Bytes uint64
+}
+
+// slabGroup is a list of slabs holding values of the same element type.
+type slabGroup struct {
+	elem      reflect.Type
+	slots     int // number of elements of the first slab
+	slabs     []safeSlab
+	current   int        // index of the slab allocations are served from
+	oversized []safeSlab // one-shot slabs holding a single oversized allocation each
+
+	sizes  []uint64 // scratch space for the SlabUsage passed to the shrink policy
+	lazy   bool     // whether slabs are zeroed as they are handed out rather than on Reset
+	poison bool     // whether slabs are filled with PoisonByte on Reset
+	align  uintptr  // alignment of the start of the slabs beyond that of the element type
+
+	quarantine  int // number of resets slabs in use are quarantined for, as set by WithQuarantine
+	quarantined []quarantinedSlab
+}
+
+type quarantinedSlab struct {
+	slab  safeSlab
+	until uint64 // number of resets releasing the slab
+}
+
+type safeSlab struct {
+	mem    reflect.Value // addressable slice backing the slab, which keeps it reachable and typed for the GC
+	ptr    unsafe.Pointer
+	offset uintptr
+	size   uintptr
+	dirty  uintptr // number of leading bytes holding data from before the last Reset, if zeroed lazily
+}
+
+// NewSafeArena creates a new safe arena whose POD slab group starts with a slab of podSlabSize bytes.
+// The first slab of every typed slab group holds as many values as set by WithInitialTypedSlots.
+// Unless limited by WithMaxBytes, the arena grows as needed to satisfy every allocation.
+func NewSafeArena(podSlabSize int, opts ...Option) *SafeArena {
+	o := newOptions(opts)
+	if o.typedSlots <= 0 && o.typedSlabBytes <= 0 {
+		o.typedSlots = defaultTypedSlots
+	}
+	if o.shrink == nil {
+		o.shrink = defaultShrinkPolicy
+	}
+	a := &SafeArena{
+		opts: o,
+		pod: &slabGroup{
+			elem:       byteType,
+			slots:      podSlabSize,
+			lazy:       o.lazyZeroing || o.poison,
+			poison:     o.poison,
+			align:      uintptr(o.bufferAlignment),
+			quarantine: o.quarantine,
+		},
+		typed: make(map[gcShape]*slabGroup),
+		types: make(map[reflect.Type]*safeType),
+	}
+	a.Preallocate(o.preallocate...)
+	return a
+}
+
+// NewSafeArenaOfSize creates a new safe arena expected to hold about expectedBytes bytes at once, deriving
+// the size of the first slab of every slab group from it: the POD slab group starts with half of it,
+// whereas typed slab groups start with a sixteenth of it each, unless WithInitialTypedSlots is provided.
+func NewSafeArenaOfSize(expectedBytes int, opts ...Option) *SafeArena {
+	derived := func(o *options) {
+		o.typedSlabBytes = max(expectedBytes/typedSlabShare, 1)
+	}
+	return NewSafeArena(max(expectedBytes/2, 1), append([]Option{derived}, opts...)...)
+}
+
+// Preallocate classifies the given types and allocates the first slab of the slab groups serving them,
+// so that their first allocations do not pay for it. It is mostly useful in latency-sensitive paths,
+// and to restore the types preallocated by WithPreallocatedTypes after memory has been released.
+func (a *SafeArena) Preallocate(types ...reflect.Type) {
+	for _, t := range types {
+		if g := a.typeOf(t).group; len(g.slabs) == 0 {
+			a.slabBytes += g.grow(g.slots)
+		}
+	}
+}
+
+// Alloc satisfies the Arena interface. The allocated memory is not scanned by the GC,
+// hence it must not be used to hold pointers.
+func (a *SafeArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(a.pod, size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *SafeArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	st := a.typeOf(t)
+	size := t.Size() * uintptr(n)
+	if n == 1 && len(st.free) > 0 {
+		ptr := st.free[len(st.free)-1]
+		st.free[len(st.free)-1] = nil
+		st.free = st.free[:len(st.free)-1]
+		st.stats.Objects++
+		st.stats.Bytes += uint64(size)
+		return ptr, true
+	}
+	ptr, ok := a.alloc(st.group, size, uintptr(t.Align()), t)
+	if ptr != nil {
+		st.stats.Objects += uint64(n)
+		st.stats.Bytes += uint64(size)
+	}
+	return ptr, ok
+}
+
+func (a *SafeArena) free(t reflect.Type, ptr unsafe.Pointer) {
+	if !a.opts.freeLists || t.Size() == 0 {
+		return
+	}
+	// Zeroing the value right away drops the references it holds, so that the GC can reclaim them.
+	reflect.NewAt(t, ptr).Elem().SetZero()
+	st := a.typeOf(t)
+	st.free = append(st.free, ptr)
+}
+
+// typeOf returns the cached classification of type t, creating its slab group on first use.
+func (a *SafeArena) typeOf(t reflect.Type) *safeType {
+	if t == a.lastType {
+		return a.last
+	}
+	st := a.types[t]
+	if st == nil {
+		st = &safeType{group: a.pod}
+		if hasPointers(t) {
+			// Types sharing the same GC shape share the same slab group too, whose slabs are typed after the first of them.
+			shape := shapeOf(t)
+			st.group = a.typed[shape]
+			if st.group == nil {
+				slots := a.opts.typedSlots
+				if slots <= 0 {
+					slots = max(a.opts.typedSlabBytes/int(t.Size()), 1)
+				}
+				st.group = &slabGroup{elem: t, slots: slots, quarantine: a.opts.quarantine}
+				a.typed[shape] = st.group
+			}
+		}
+		a.types[t] = st
+	}
+	a.lastType, a.last = t, st
+	return st
+}
+
+func (a *SafeArena) alloc(g *slabGroup, size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	raceAlloc(unsafe.Pointer(a))
+	oversized := a.opts.oversizedThreshold > 0 && size > uintptr(a.opts.oversizedThreshold)
+	if !oversized {
+		// The current slab only moves forward once an allocation succeeds, so that a failed attempt
+		// does not give up on the room left in the slabs it could not fit in.
+		for i := g.current; i < len(g.slabs); i++ {
+			if ptr, ok := a.allocFrom(&g.slabs[i], size, alignment); ok {
+				g.current = i
+				return ptr, true
+			}
+		}
+	}
+
+	// Make sure a new POD slab fits the allocation regardless of the base pointer alignment,
+	// whereas typed slabs are always aligned for their element type.
+	need := size
+	if g == a.pod {
+		need += alignment - 1
+	}
+	want := g.nextSlots(need)
+	if oversized {
+		want = g.slotsFor(need)
+	}
+	slots := want
+	if limit := uintptr(a.opts.maxBytes); limit > 0 && a.slabBytes+uintptr(slots)*g.elem.Size() > limit {
+		// Shrink the new slab to the remaining budget, as long as the allocation still fits.
+		slots = int((limit - min(limit, a.slabBytes)) / g.elem.Size())
+		if uintptr(slots)*g.elem.Size() < need {
+			switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+			case ExhaustedGrow:
+				slots = want
+
+			case ExhaustedReturnNil:
+				return nil, false
+
+			case ExhaustedPanic:
+				panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+			default:
+				a.counters.heapFallbacks++
+				traceFallback(size)
+				return nil, true
+			}
+		}
+	}
+	if oversized {
+		// Oversized allocations get a slab of their own, released on Reset, so that the slabs
+		// of the group remain sized for the common case.
+		g.oversized = append(g.oversized, g.newSlab(slots))
+		asanPoison(g.oversized[len(g.oversized)-1].ptr, g.oversized[len(g.oversized)-1].size)
+		a.slabBytes += g.oversized[len(g.oversized)-1].size
+		traceGrowth(g.oversized[len(g.oversized)-1].size)
+		ptr, _ := a.allocFrom(&g.oversized[len(g.oversized)-1], size, alignment)
+		return ptr, true
+	}
+	grown := g.grow(slots)
+	a.slabBytes += grown
+	traceGrowth(grown)
+	ptr, _ := a.allocFrom(&g.slabs[g.current], size, alignment)
+	return ptr, true
+}
+
+func (a *SafeArena) allocFrom(s *safeSlab, size, alignment uintptr) (unsafe.Pointer, bool) {
+	alignOffset := (alignment - (uintptr(s.ptr)+s.offset)%alignment) % alignment
+	if s.size-s.offset < size+alignOffset {
+		return nil, false
+	}
+	ptr := unsafe.Add(s.ptr, s.offset+alignOffset)
+	asanUnpoison(unsafe.Add(s.ptr, s.offset), size+alignOffset)
+	if s.dirty > s.offset {
+		// Zero the memory left behind by previous allocations, which Reset did not clear.
+		clear(unsafe.Slice((*byte)(unsafe.Add(s.ptr, s.offset)), min(s.dirty, s.offset+size+alignOffset)-s.offset))
+	}
+	s.offset += size + alignOffset
+	if s.offset >= s.dirty {
+		s.dirty = 0
+	}
+	a.counters.allocated(uint64(size + alignOffset))
+	return ptr, true
+}
+
+// nextSlots returns the number of elements of the next slab able to hold at least size bytes,
+// doubling the size of the newest slab.
+func (g *slabGroup) nextSlots(size uintptr) int {
+	elemSize := g.elem.Size()
+	slots := g.slots
+	if n := len(g.slabs); n > 0 {
+		slots = 2 * int(g.slabs[n-1].size/elemSize)
+	}
+	return max(slots, g.slotsFor(size))
+}
+
+// slotsFor returns the number of elements needed to hold size bytes.
+func (g *slabGroup) slotsFor(size uintptr) int {
+	return int((size + g.elem.Size() - 1) / g.elem.Size())
+}
+
+// grow appends a new slab of the given number of elements, returning its size in bytes.
+func (g *slabGroup) grow(slots int) uintptr {
+	g.slabs = append(g.slabs, g.newSlab(slots))
+	asanPoison(g.slabs[len(g.slabs)-1].ptr, g.slabs[len(g.slabs)-1].size)
+	g.current = len(g.slabs) - 1
+	return g.slabs[g.current].size
+}
+
+// newSlab allocates a slab of the given number of elements, whose start is aligned as set by WithBufferAlignment.
+func (g *slabGroup) newSlab(slots int) safeSlab {
+	if g.align <= 1 {
+		return newSafeSlab(g.elem, slots)
+	}
+	// Only the POD slab group is aligned, whose elements are bytes.
+	s := newSafeSlab(g.elem, slots+int(g.align)-1)
+	s.ptr = unsafe.Add(s.ptr, (g.align-uintptr(s.ptr)%g.align)%g.align)
+	s.size = uintptr(slots)
+	return s
+}
+
+func newSafeSlab(elem reflect.Type, slots int) safeSlab {
+	sliceType := reflect.SliceOf(elem)
+	mem := reflect.New(sliceType).Elem()
+	mem.Set(reflect.MakeSlice(sliceType, slots, slots))
+	return safeSlab{
+		mem:  mem,
+		ptr:  mem.UnsafePointer(),
+		size: uintptr(slots) * elem.Size(),
+	}
+}
+
+// Reset satisfies the Arena interface. Unless memory is released, every slab group releases as many
+// of its newest slabs as decided by the shrink policy installed by WithShrinkPolicy.
+func (a *SafeArena) Reset(release bool) {
+	a.ResetWithStats(release)
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *SafeArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *SafeArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// ResetStats describes the outcome of resetting an arena.
+type ResetStats struct {
+	// BytesInUse is the number of bytes that were handed out since the previous Reset.
+	BytesInUse uint64
+
+	// SlabsReleased is the number of slabs released.
+	SlabsReleased int
+
+	// BytesReleased is the overall size of the slabs released.
+	BytesReleased uint64
+
+	// BytesRetained is the overall size of the slabs the arena retains.
+	BytesRetained uint64
+}
+
+// ResetWithStats resets the arena as Reset does, reporting how much memory was in use, released and retained.
+func (a *SafeArena) ResetWithStats(release bool) ResetStats {
+	defer traceReset().End()
+	defer raceReset(unsafe.Pointer(a))
+	a.hooks.run()
+	rs := ResetStats{BytesInUse: a.counters.bytesInUse}
+	a.counters.reset()
+	if release {
+		rs.SlabsReleased = len(a.pod.slabs) + len(a.pod.oversized)
+		for _, g := range a.typed {
+			rs.SlabsReleased += len(g.slabs) + len(g.oversized)
+		}
+		rs.BytesReleased = uint64(a.slabBytes)
+
+		a.pod.reclaimSlabs()
+		a.pod.releaseQuarantine(true, 0)
+		for _, g := range a.typed {
+			g.reclaimSlabs()
+			g.releaseQuarantine(true, 0)
+		}
+		a.pod.slabs, a.pod.current, a.pod.oversized = nil, 0, nil
+		a.slabBytes = 0
+		clear(a.typed)
+		clear(a.types)
+		a.lastType, a.last = nil, nil
+		return rs
+	}
+	for _, st := range a.types {
+		st.stats = TypeStats{}
+		clear(st.free)
+		st.free = st.free[:0]
+	}
+	bytes, slabs := a.pod.reset(nil, a.opts.shrink, a.counters.resets)
+	rs.BytesReleased, rs.SlabsReleased = uint64(bytes), slabs
+	for _, g := range a.typed {
+		bytes, slabs := g.reset(g.elem, a.opts.shrink, a.counters.resets)
+		rs.BytesReleased += uint64(bytes)
+		rs.SlabsReleased += slabs
+	}
+	a.slabBytes -= uintptr(rs.BytesReleased)
+	rs.BytesRetained = uint64(a.slabBytes)
+	return rs
+}
+
+// reset rewinds every slab of the group and releases the slabs the policy decides, as well as quarantines
+// the slabs in use if set to, returning the number of bytes and slabs released. The resets include this one.
+func (g *slabGroup) reset(t reflect.Type, policy ShrinkPolicy, resets uint64) (uintptr, int) {
+	var released uintptr
+	slabs := len(g.oversized)
+	g.releaseQuarantine(false, resets)
+	g.reclaimSlabs()
+	for i := range g.oversized {
+		released += g.oversized[i].size
+		g.oversized[i] = safeSlab{}
+	}
+	g.oversized = g.oversized[:0]
+
+	var used, capacity uintptr
+	g.sizes = g.sizes[:0]
+	kept := g.slabs[:0]
+	for i := range g.slabs {
+		s := &g.slabs[i]
+		if g.lazy {
+			s.dirty = max(s.dirty, s.offset)
+		} else if s.offset > 0 {
+			// Clearing typed slabs drops the references they hold, so that the GC can reclaim them.
+			// The slab length is temporarily shrunk in place, as slicing it would allocate.
+			end := uintptr(s.ptr) - uintptr(s.mem.UnsafePointer()) + s.offset // accounts for the alignment of the slab start
+			s.mem.SetLen(int((end + g.elem.Size() - 1) / g.elem.Size()))
+			s.mem.Clear()
+			s.mem.SetLen(s.mem.Cap())
+		}
+		used += s.offset
+		if g.quarantine > 0 && s.offset > 0 {
+			// The group grows fresh slabs in place of the quarantined ones.
+			s.offset = 0
+			asanPoison(s.ptr, s.size)
+			g.quarantined = append(g.quarantined, quarantinedSlab{slab: *s, until: resets + uint64(g.quarantine)})
+			released += s.size
+			slabs++
+			continue
+		}
+		capacity += s.size
+		s.offset = 0
+		g.sizes = append(g.sizes, uint64(s.size))
+		kept = append(kept, *s)
+	}
+	clear(g.slabs[len(kept):])
+	g.slabs = kept
+	g.current = 0
+	if len(g.slabs) == 0 {
+		return released, slabs
+	}
+
+	n := policy(SlabUsage{Type: t, SlabSizes: g.sizes, BytesInUse: uint64(used), BytesAllocated: uint64(capacity)})
+	n = min(max(n, 0), len(g.slabs))
+
+	for i := len(g.slabs) - n; i < len(g.slabs); i++ {
+		released += g.slabs[i].size
+		g.slabs[i] = safeSlab{}
+	}
+	g.slabs = g.slabs[:len(g.slabs)-n]
+	if ASanEnabled {
+		for _, s := range g.slabs {
+			asanPoison(s.ptr, s.size)
+		}
+	}
+	return released, slabs + n
+}
+
+// releaseQuarantine releases the slabs whose quarantine is over after the given number of resets,
+// or all of them if all is set.
+func (g *slabGroup) releaseQuarantine(all bool, resets uint64) {
+	n := 0
+	for _, q := range g.quarantined {
+		if all || q.until <= resets {
+			asanUnpoison(q.slab.ptr, q.slab.size)
+			continue
+		}
+		g.quarantined[n] = q
+		n++
+	}
+	clear(g.quarantined[n:])
+	g.quarantined = g.quarantined[:n]
+}
+
+// reclaimSlabs prepares the memory handed out from every slab of the group to be either rewound or released,
+// reporting it to the race detector, filling it with PoisonByte if set to, and marking the slabs as addressable
+// for AddressSanitizer.
+func (g *slabGroup) reclaimSlabs() {
+	g.reclaim(g.slabs)
+	g.reclaim(g.oversized)
+}
+
+func (g *slabGroup) reclaim(slabs []safeSlab) {
+	for _, s := range slabs {
+		raceReclaim(s.ptr, s.offset)
+		if g.poison {
+			poisonMemory(s.ptr, s.offset)
+		}
+		asanUnpoison(s.ptr, s.size)
+	}
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *SafeArena) Stats() Stats {
+	s := a.counters.stats()
+	s.BytesAllocated = uint64(a.slabBytes)
+	for _, g := range a.groups() {
+		s.Buffers += len(g.slabs) + len(g.oversized)
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *SafeArena) Owns(ptr unsafe.Pointer) bool {
+	if a.pod.owns(ptr) {
+		return true
+	}
+	for _, g := range a.typed {
+		if g.owns(ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *slabGroup) owns(ptr unsafe.Pointer) bool {
+	for _, s := range g.slabs {
+		if within(s.ptr, s.size, ptr) {
+			return true
+		}
+	}
+	for _, s := range g.oversized {
+		if within(s.ptr, s.size, ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeStats returns the allocation statistics of every type allocated since the last Reset.
+// Raw allocations requested through Alloc are not accounted.
+func (a *SafeArena) TypeStats() map[reflect.Type]TypeStats {
+	stats := make(map[reflect.Type]TypeStats, len(a.types))
+	for t, st := range a.types {
+		if st.stats.Objects > 0 {
+			stats[t] = st.stats
+		}
+	}
+	return stats
+}
+
+// Dump satisfies the Dumper interface, writing a human-readable description of every slab group of the arena
+// to w, along with the types each one serves and the usage of its slabs, for debugging leaks and sizing problems.
+func (a *SafeArena) Dump(w io.Writer) error {
+	served := make(map[*slabGroup][]string)
+	for t, st := range a.types {
+		served[st.group] = append(served[st.group], t.String())
+	}
+	groups := a.groups()
+	sort.Slice(groups[1:], func(i, j int) bool {
+		return groups[1+i].elem.String() < groups[1+j].elem.String()
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SafeArena: %d slab groups, %d/%d bytes in use\n", len(groups), a.counters.bytesInUse, a.slabBytes)
+	for _, g := range groups {
+		var used, capacity uintptr
+		for _, s := range g.slabs {
+			used += s.offset
+			capacity += s.size
+		}
+		for _, s := range g.oversized {
+			used += s.offset
+			capacity += s.size
+		}
+		if g == a.pod {
+			fmt.Fprintf(&b, "POD group")
+		} else {
+			fmt.Fprintf(&b, "typed group %s", g.elem)
+		}
+		fmt.Fprintf(&b, ": %d slabs, %d oversized, %d/%d bytes used\n", len(g.slabs), len(g.oversized), used, capacity)
+
+		if types := served[g]; len(types) > 0 {
+			sort.Strings(types)
+			fmt.Fprintf(&b, "  types: %s\n", strings.Join(types, ", "))
+		}
+		for i, s := range g.slabs {
+			current := ""
+			if i == g.current {
+				current = " (current)"
+			}
+			fmt.Fprintf(&b, "  slab %d: offset %d/%d%s\n", i, s.offset, s.size, current)
+		}
+		for i, s := range g.oversized {
+			fmt.Fprintf(&b, "  oversized slab %d: offset %d/%d\n", i, s.offset, s.size)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// groups returns every slab group of the arena, starting with the POD one.
+func (a *SafeArena) groups() []*slabGroup {
+	groups := make([]*slabGroup, 0, len(a.typed)+1)
+	groups = append(groups, a.pod)
+	for _, g := range a.typed {
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func (a *SafeArena) resetCount() uint64 {
+	return a.counters.resets
+}
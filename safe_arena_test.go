@@ -0,0 +1,619 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type safeTestPOD struct {
+	id     uint64
+	coords [3]float64
+	inner  struct {
+		flags [4]bool
+	}
+}
+
+type safeTestNode struct {
+	name string
+	next *safeTestNode
+	tags []string
+}
+
+func TestSafeArenaRoutesPointerFreeTypesToPOD(t *testing.T) {
+	arena := NewSafeArena(1024)
+
+	_ = New[safeTestPOD](arena)
+	_ = New[[8]int32](arena)
+	_ = MakeSlice[allocTestStruct](arena, 4, 4)
+	require.Empty(t, arena.typed)
+	require.True(t, arena.pod.owns(unsafe.Pointer(New[safeTestPOD](arena))))
+
+	_ = New[safeTestNode](arena)
+	_ = New[[2]*int](arena)
+	require.Len(t, arena.typed, 2)
+	require.Contains(t, arena.typed, shapeOf(reflect.TypeOf(safeTestNode{})))
+}
+
+func TestSafeArenaSharesGroupsByShape(t *testing.T) {
+	type pair struct {
+		key   string
+		value *int
+	}
+	type other struct {
+		name  []byte // a slice header is a pointer followed by two integers
+		count int
+		next  *pair
+	}
+	type iface struct {
+		v any
+		n int
+	}
+
+	arena := NewSafeArena(1024, WithInitialTypedSlots(4))
+
+	// A string header is a pointer followed by an integer
+	_ = New[pair](arena)
+	_ = New[struct {
+		p *byte
+		n int
+		q map[int]int
+	}](arena)
+	require.Len(t, arena.typed, 1)
+
+	_ = New[other](arena)
+	_ = New[struct {
+		p       unsafe.Pointer
+		a, b, c int
+		d       chan int
+	}](arena)
+	require.Len(t, arena.typed, 2)
+
+	// Interfaces span two pointers
+	_ = New[iface](arena)
+	_ = New[[3]*int](arena)
+	require.Len(t, arena.typed, 4)
+	require.Equal(t, string([]byte{0b011}), shapeOf(reflect.TypeOf(iface{})).ptrMask)
+	require.Equal(t, string([]byte{0b111}), shapeOf(reflect.TypeOf([3]*int{})).ptrMask)
+
+	// Untraced pointers do not share the shape of the integers sharing their layout
+	type untraced struct {
+		p *int
+		q unsafe.Pointer `nuke:"nogc"`
+	}
+	type integer struct {
+		p *int
+		q uintptr
+	}
+	_ = New[untraced](arena)
+	_ = New[integer](arena)
+	require.Len(t, arena.typed, 6)
+	require.Equal(t, shapeOf(reflect.TypeOf(integer{})).ptrMask, shapeOf(reflect.TypeOf(untraced{})).ptrMask)
+	require.Equal(t, string([]byte{0b10}), shapeOf(reflect.TypeOf([1]untraced{})).untracedMask)
+
+	// Values of a type sharing a slab group typed after another one survive a GC
+	type shared struct {
+		p       unsafe.Pointer
+		a, b, c int
+		d       chan int
+	}
+
+	var values []*shared
+	for i := 0; i < 20; i++ {
+		v := New[shared](arena)
+		v.p = unsafe.Pointer(&pair{key: strconv.Itoa(i)})
+		v.d = make(chan int, 1)
+		v.d <- i
+		values = append(values, v)
+	}
+	require.Equal(t, reflect.TypeOf(other{}), arena.types[reflect.TypeOf(shared{})].group.elem)
+
+	runtime.GC()
+	for i, v := range values {
+		require.Equal(t, strconv.Itoa(i), (*pair)(v.p).key)
+		require.Equal(t, i, <-v.d)
+	}
+}
+
+func TestSafeArenaCachesClassification(t *testing.T) {
+	arena := NewSafeArena(1024)
+
+	_ = New[safeTestPOD](arena)
+	_ = New[safeTestNode](arena)
+	_ = New[safeTestPOD](arena)
+	require.Same(t, arena.pod, arena.types[reflect.TypeOf(safeTestPOD{})].group)
+	require.Same(t, arena.typed[shapeOf(reflect.TypeOf(safeTestNode{}))], arena.types[reflect.TypeOf(safeTestNode{})].group)
+	require.Equal(t, reflect.TypeOf(safeTestPOD{}), arena.lastType)
+
+	// Types keep their classification across resets, unless memory is released
+	arena.Reset(false)
+	require.Len(t, arena.types, 2)
+	arena.Reset(true)
+	require.Empty(t, arena.types)
+	require.Nil(t, arena.last)
+
+	_ = New[safeTestNode](arena)
+	require.Len(t, arena.typed, 1)
+}
+
+func TestSafeArenaTypeStats(t *testing.T) {
+	arena := NewSafeArena(1024)
+
+	_ = New[safeTestNode](arena)
+	_ = MakeSlice[safeTestNode](arena, 3, 3)
+	_ = MakeSlice[int32](arena, 0, 10)
+	_ = arena.Alloc(100, 1)
+
+	nodeSize := uint64(reflect.TypeOf(safeTestNode{}).Size())
+	require.Equal(t, map[reflect.Type]TypeStats{
+		reflect.TypeOf(safeTestNode{}): {Objects: 4, Bytes: 4 * nodeSize},
+		reflect.TypeOf(int32(0)):       {Objects: 10, Bytes: 40},
+	}, arena.TypeStats())
+
+	arena.Reset(false)
+	require.Empty(t, arena.TypeStats())
+}
+
+func TestSafeArenaDump(t *testing.T) {
+	type other struct {
+		name string
+		next *safeTestNode
+		tags []string
+	}
+	arena := NewSafeArena(64, WithInitialTypedSlots(2), WithOversizedThreshold(1024))
+
+	_ = MakeSlice[byte](arena, 60, 60)
+	_ = MakeSlice[byte](arena, 8, 8)
+	_ = MakeSlice[byte](arena, 2000, 2000)
+	_ = New[safeTestNode](arena)
+	_ = New[other](arena)
+	_ = New[*int](arena)
+
+	var b strings.Builder
+	require.NoError(t, arena.Dump(&b))
+	require.Equal(t, `SafeArena: 3 slab groups, 2172/2304 bytes in use
+POD group: 2 slabs, 1 oversized, 2068/2192 bytes used
+  types: uint8
+  slab 0: offset 60/64
+  slab 1: offset 8/128 (current)
+  oversized slab 0: offset 2000/2000
+typed group *int: 1 slabs, 0 oversized, 8/16 bytes used
+  types: *int
+  slab 0: offset 8/16 (current)
+typed group nuke.safeTestNode: 1 slabs, 0 oversized, 96/96 bytes used
+  types: nuke.other, nuke.safeTestNode
+  slab 0: offset 96/96 (current)
+`, b.String())
+}
+
+func TestSafeArenaPreallocate(t *testing.T) {
+	nodeType := reflect.TypeOf(safeTestNode{})
+	arena := NewSafeArena(1024, WithInitialTypedSlots(8), WithPreallocatedTypes(nodeType, reflect.TypeOf(safeTestPOD{})))
+
+	require.Len(t, arena.pod.slabs, 1)
+	g := arena.types[nodeType].group
+	require.Len(t, g.slabs, 1)
+	require.Equal(t, 8*nodeType.Size(), g.slabs[0].size)
+	require.Equal(t, uint64(1024+8*nodeType.Size()), arena.Stats().BytesAllocated)
+
+	n := New[safeTestNode](arena)
+	require.True(t, g.owns(unsafe.Pointer(n)))
+	require.Len(t, g.slabs, 1)
+
+	// Preallocated slab groups are dropped along with memory, unless preallocated again
+	arena.Reset(true)
+	require.Empty(t, arena.typed)
+	arena.Preallocate(nodeType)
+	require.Len(t, arena.typed, 1)
+	require.Empty(t, arena.pod.slabs)
+}
+
+func TestSafeArenaOfSize(t *testing.T) {
+	arena := NewSafeArenaOfSize(64*1024, WithPreallocatedTypes(reflect.TypeOf(safeTestNode{}), reflect.TypeOf([2]*int{})))
+
+	require.Equal(t, 32*1024, arena.pod.slots)
+	nodeGroup := arena.types[reflect.TypeOf(safeTestNode{})].group
+	require.Equal(t, 4*1024/int(unsafe.Sizeof(safeTestNode{})), nodeGroup.slots)
+	require.Equal(t, 4*1024/16, arena.types[reflect.TypeOf([2]*int{})].group.slots)
+
+	// Types larger than a typed slab get a single slot
+	arena = NewSafeArenaOfSize(128)
+	_ = New[[4]*int](arena)
+	require.Equal(t, 1, arena.types[reflect.TypeOf([4]*int{})].group.slots)
+
+	// Explicit options take precedence
+	arena = NewSafeArenaOfSize(64*1024, WithInitialTypedSlots(3))
+	_ = New[safeTestNode](arena)
+	require.Equal(t, 3, arena.types[reflect.TypeOf(safeTestNode{})].group.slots)
+}
+
+func TestSafeArenaKeepsReferencesAlive(t *testing.T) {
+	arena := NewSafeArena(1024, WithInitialTypedSlots(4))
+
+	var head *safeTestNode
+	for i := 0; i < 100; i++ {
+		n := New[safeTestNode](arena)
+		n.name = strconv.Itoa(i) // heap allocated, only referenced from the arena
+		n.tags = []string{n.name}
+		n.next = head
+		head = n
+	}
+	runtime.GC()
+
+	for i := 99; i >= 0; i-- {
+		require.Equal(t, strconv.Itoa(i), head.name)
+		require.Equal(t, []string{head.name}, head.tags)
+		head = head.next
+	}
+	require.Nil(t, head)
+}
+
+func TestSafeArenaGrowth(t *testing.T) {
+	arena := NewSafeArena(64, WithInitialTypedSlots(2))
+
+	nodeType := reflect.TypeOf(safeTestNode{})
+	for i := 0; i < 7; i++ {
+		_ = New[safeTestNode](arena)
+	}
+	g := arena.types[nodeType].group
+	require.Len(t, g.slabs, 3) // 2 + 4 + 8 slots
+	require.Equal(t, 8*nodeType.Size(), g.slabs[2].size)
+
+	// Slices larger than the doubled slab get a slab of their own size
+	s := MakeSlice[safeTestNode](arena, 100, 100)
+	require.Len(t, g.slabs, 4)
+	require.True(t, g.owns(unsafe.Pointer(&s[99])))
+
+	b := MakeSlice[byte](arena, 1000, 1000)
+	require.True(t, arena.pod.owns(unsafe.Pointer(&b[999])))
+
+	stats := arena.Stats()
+	require.Equal(t, 5, stats.Buffers)
+}
+
+func TestSafeArenaMaxBytes(t *testing.T) {
+	arena := NewSafeArena(64, WithMaxBytes(256))
+
+	// Growth is clamped to the remaining budget
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 100, 100)
+	require.Equal(t, uint64(192), arena.Stats().BytesAllocated) // 64 + 128
+	_ = MakeSlice[byte](arena, 50, 50)
+	require.Equal(t, uint64(256), arena.Stats().BytesAllocated) // 64 + 128 + 64
+
+	// Allocations beyond the budget trigger the exhaustion policy, falling back to the heap by default
+	b := MakeSlice[byte](arena, 32, 32)
+	require.False(t, arena.pod.owns(unsafe.Pointer(&b[0])))
+	require.Equal(t, uint64(1), arena.Stats().HeapFallbacks)
+	require.NotNil(t, New[safeTestNode](arena))
+
+	arena = NewSafeArena(64, WithMaxBytes(64), WithOnExhausted(func(e Exhaustion) ExhaustedAction {
+		if e.Type == reflect.TypeOf(safeTestNode{}) {
+			return ExhaustedReturnNil
+		}
+		return ExhaustedPanic
+	}))
+	require.Nil(t, MakeSlice[safeTestNode](arena, 2, 2))
+	requirePanicsWithErrorIs(t, ErrArenaExhausted, func() { _ = MakeSlice[byte](arena, 65, 65) })
+	require.Empty(t, arena.TypeStats())
+
+	arena = NewSafeArena(64, WithMaxBytes(64), WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedGrow }))
+	_ = MakeSlice[byte](arena, 100, 100)
+	require.Equal(t, uint64(100), arena.Stats().BytesAllocated)
+}
+
+func TestSafeArenaKeepsCurrentSlabOnFailure(t *testing.T) {
+	arena := NewSafeArena(1024, WithMaxBytes(1024))
+	_ = MakeSlice[byte](arena, 100, 100)
+
+	// The allocation falls back to the heap without skipping the slab that still has room
+	b := MakeSlice[byte](arena, 4096, 4096)
+	require.False(t, arena.pod.owns(unsafe.Pointer(&b[0])))
+	require.True(t, arena.pod.owns(unsafe.Pointer(New[int64](arena))))
+	require.Equal(t, uint64(1), arena.Stats().HeapFallbacks)
+}
+
+func TestSafeArenaOversizedAllocations(t *testing.T) {
+	arena := NewSafeArena(1024, WithInitialTypedSlots(4), WithOversizedThreshold(512))
+
+	small := MakeSlice[byte](arena, 512, 512)
+	large := MakeSlice[byte](arena, 4096, 4096)
+	require.Len(t, arena.pod.slabs, 1)
+	require.Len(t, arena.pod.oversized, 1)
+	require.True(t, arena.pod.owns(unsafe.Pointer(&small[0])))
+	require.True(t, within(arena.pod.oversized[0].ptr, arena.pod.oversized[0].size, unsafe.Pointer(&large[0])))
+
+	nodes := MakeSlice[safeTestNode](arena, 100, 100)
+	g := arena.types[reflect.TypeOf(safeTestNode{})].group
+	require.Empty(t, g.slabs)
+	require.Len(t, g.oversized, 1)
+	require.Equal(t, 100*unsafe.Sizeof(nodes[0]), g.oversized[0].size)
+
+	stats := arena.Stats()
+	require.Equal(t, 3, stats.Buffers)
+	require.Equal(t, uint64(1024+4096)+uint64(g.oversized[0].size), stats.BytesAllocated)
+
+	// Oversized slabs are dropped on Reset
+	arena.Reset(false)
+	require.Empty(t, arena.pod.oversized)
+	require.Empty(t, g.oversized)
+	require.Equal(t, uint64(1024), arena.Stats().BytesAllocated)
+}
+
+func TestSafeArenaFreeLists(t *testing.T) {
+	arena := NewSafeArena(1024, WithFreeLists())
+
+	n1 := New[safeTestNode](arena)
+	n1.name = "nuke"
+	n1.next = n1
+	p1 := New[safeTestPOD](arena)
+	p1.id = 1
+
+	Free(arena, n1)
+	Free(arena, p1)
+	require.Equal(t, safeTestNode{}, *n1) // freed values are zeroed right away
+
+	// Freed slots are reused before bumping, per type
+	require.Same(t, p1, New[safeTestPOD](arena))
+	require.Same(t, n1, New[safeTestNode](arena))
+	require.NotSame(t, n1, New[safeTestNode](arena))
+	require.Equal(t, uint64(3), arena.TypeStats()[reflect.TypeOf(safeTestNode{})].Objects)
+
+	// Slices of several values never take them from free lists
+	Free(arena, n1)
+	s := MakeSlice[safeTestNode](arena, 2, 2)
+	require.NotSame(t, n1, &s[0])
+	require.NotSame(t, n1, &s[1])
+
+	// Free lists are dropped on Reset
+	arena.Reset(false)
+	require.Empty(t, arena.types[reflect.TypeOf(safeTestNode{})].free)
+
+	// Free is a no-op unless free lists are enabled, as well as for other arenas
+	arena = NewSafeArena(1024)
+	n1 = New[safeTestNode](arena)
+	n1.name = "nuke"
+	Free(arena, n1)
+	require.Equal(t, "nuke", n1.name)
+	require.NotSame(t, n1, New[safeTestNode](arena))
+	Free(NewMonotonicArena(1024, 1), New[int](nil))
+
+	// Concurrent arenas forward to the underlying arena
+	concurrent := NewConcurrentArena(NewSafeArena(1024, WithFreeLists()))
+	n1 = New[safeTestNode](concurrent)
+	Free(concurrent, n1)
+	require.Same(t, n1, New[safeTestNode](concurrent))
+}
+
+func TestSafeArenaLazyZeroing(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewSafeArena(256, WithLazyZeroing())
+
+	b := MakeSlice[byte](arena, 100, 100)
+	for i := range b {
+		b[i] = 0xff
+	}
+	n := New[safeTestNode](arena)
+	n.name = "nuke"
+	arena.Reset(false)
+
+	// POD memory is left untouched by Reset, unlike typed memory
+	require.Equal(t, byte(0xff), b[99])
+	require.Equal(t, uintptr(100), arena.pod.slabs[0].dirty)
+	require.Empty(t, n.name)
+
+	// Memory is zeroed as it is handed out again
+	s := MakeSlice[uint32](arena, 10, 10)
+	require.Equal(t, make([]uint32, 10), s)
+	require.Equal(t, byte(0xff), b[40])
+	require.Equal(t, make([]byte, 60), MakeSlice[byte](arena, 60, 60))
+	require.Zero(t, arena.pod.slabs[0].dirty)
+	require.Equal(t, byte(0), b[99])
+}
+
+func TestSafeArenaPoisonOnReset(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewSafeArena(256, WithPoisonOnReset())
+
+	b := MakeSlice[byte](arena, 100, 100)
+	n := New[safeTestNode](arena)
+	n.name = "nuke"
+	arena.Reset(false)
+
+	// Only POD memory is poisoned, typed memory is cleared for the GC's sake
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 100), b)
+	require.Empty(t, n.name)
+	require.Equal(t, make([]uint32, 10), MakeSlice[uint32](arena, 10, 10))
+	require.Equal(t, byte(PoisonByte), b[40])
+
+	b = MakeSlice[byte](arena, 1000, 1000) // oversized
+	arena.Reset(true)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 1000), b)
+}
+
+func TestSafeArenaQuarantine(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewSafeArena(256, WithQuarantine(1), WithPoisonOnReset())
+
+	b := MakeSlice[byte](arena, 100, 100)
+	n := New[safeTestNode](arena)
+	n.name = "nuke"
+	rs := arena.ResetWithStats(false)
+	require.Equal(t, 2, rs.SlabsReleased)
+	require.Zero(t, rs.BytesRetained)
+	require.Len(t, arena.pod.quarantined, 1)
+
+	// Typed slabs are cleared before being quarantined
+	require.Empty(t, n.name)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 100), b)
+	require.False(t, arena.Owns(unsafe.Pointer(&b[0])))
+	require.Equal(t, make([]byte, 100), MakeSlice[byte](arena, 100, 100))
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 100), b)
+
+	arena.Reset(false)
+	require.Len(t, arena.pod.quarantined, 1) // the slab in use since the last reset
+	arena.Reset(false)
+	require.Empty(t, arena.pod.quarantined)
+
+	_ = New[safeTestNode](arena)
+	arena.Reset(true)
+	for _, g := range arena.groups() {
+		require.Empty(t, g.quarantined)
+	}
+}
+
+func TestSafeArenaBufferAlignment(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewSafeArena(100, WithBufferAlignment(64), WithOversizedThreshold(1000))
+
+	b := MakeSlice[byte](arena, 100, 100)
+	require.Zero(t, uintptr(unsafe.Pointer(&b[0]))%64)
+	b2 := MakeSlice[byte](arena, 100, 100) // grows a slab
+	require.Zero(t, uintptr(unsafe.Pointer(&b2[0]))%64)
+	b3 := MakeSlice[byte](arena, 2000, 2000) // oversized
+	require.Zero(t, uintptr(unsafe.Pointer(&b3[0]))%64)
+	require.Equal(t, uint64(2200), arena.Stats().BytesInUse)
+
+	for i := range b {
+		b[i], b2[i] = 0xff, 0xff
+	}
+	arena.Reset(false)
+	require.Equal(t, make([]byte, 100), b)
+	require.Equal(t, make([]byte, 100), b2)
+}
+
+func TestSafeArenaReset(t *testing.T) {
+	arena := NewSafeArena(64, WithInitialTypedSlots(2))
+
+	n := New[safeTestNode](arena)
+	n.name = "nuke"
+	*New[int](arena) = 42
+	arena.Reset(false)
+
+	// Memory is zeroed and reused
+	require.Same(t, n, New[safeTestNode](arena))
+	require.Equal(t, safeTestNode{}, *n)
+	require.Equal(t, 0, *New[int](arena))
+
+	arena.Reset(true)
+	require.Empty(t, arena.typed)
+	require.Empty(t, arena.pod.slabs)
+}
+
+func TestSafeArenaShrinksOnReset(t *testing.T) {
+	arena := NewSafeArena(64)
+
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 128, 128)
+	require.Len(t, arena.pod.slabs, 2)
+
+	// Usage above a quarter of the capacity retains every slab
+	arena.Reset(false)
+	require.Len(t, arena.pod.slabs, 2)
+
+	_ = MakeSlice[byte](arena, 32, 32)
+	arena.Reset(false)
+	require.Len(t, arena.pod.slabs, 1)
+}
+
+func TestSafeArenaResetWithStats(t *testing.T) {
+	arena := NewSafeArena(64, WithInitialTypedSlots(1), WithOversizedThreshold(1024))
+
+	_ = MakeSlice[byte](arena, 60, 60)
+	_ = MakeSlice[byte](arena, 2000, 2000) // oversized
+	_ = New[safeTestNode](arena)
+	_ = New[safeTestNode](arena)
+
+	nodeSize := uint64(unsafe.Sizeof(safeTestNode{}))
+	require.Equal(t, ResetStats{
+		BytesInUse:    60 + 2000 + 2*nodeSize,
+		SlabsReleased: 1,
+		BytesReleased: 2000,
+		BytesRetained: 64 + 3*nodeSize, // 1 + 2 nodes
+	}, arena.ResetWithStats(false))
+
+	// The typed group used less than a quarter of its capacity
+	require.Equal(t, ResetStats{
+		SlabsReleased: 1,
+		BytesReleased: 2 * nodeSize,
+		BytesRetained: 64 + nodeSize,
+	}, arena.ResetWithStats(false))
+
+	_ = New[int](arena)
+	require.Equal(t, ResetStats{
+		BytesInUse:    8,
+		SlabsReleased: 2,
+		BytesReleased: 64 + nodeSize,
+	}, arena.ResetWithStats(true))
+}
+
+func TestSafeArenaShrinkPolicy(t *testing.T) {
+	var usages []SlabUsage
+	arena := NewSafeArena(64, WithShrinkPolicy(func(u SlabUsage) int {
+		u.SlabSizes = append([]uint64(nil), u.SlabSizes...)
+		usages = append(usages, u)
+		return len(u.SlabSizes) // release everything
+	}))
+
+	_ = MakeSlice[byte](arena, 64, 64)
+	_ = MakeSlice[byte](arena, 16, 16)
+	arena.Reset(false)
+	require.Equal(t, []SlabUsage{{SlabSizes: []uint64{64, 128}, BytesInUse: 80, BytesAllocated: 192}}, usages)
+	require.Empty(t, arena.pod.slabs)
+	require.Zero(t, arena.Stats().BytesAllocated)
+
+	// Release up to two slabs, retaining at least 1KB
+	policy := ShrinkBelowUsage(0.5, 2, 1024)
+	require.Equal(t, 0, policy(SlabUsage{SlabSizes: []uint64{512, 1024, 2048}, BytesInUse: 2000, BytesAllocated: 3584}))
+	require.Equal(t, 2, policy(SlabUsage{SlabSizes: []uint64{1024, 2048, 4096}, BytesInUse: 10, BytesAllocated: 7168}))
+	require.Equal(t, 1, policy(SlabUsage{SlabSizes: []uint64{512, 1024, 2048}, BytesInUse: 10, BytesAllocated: 3584}))
+	require.Equal(t, 1, policy(SlabUsage{SlabSizes: []uint64{2048, 4096, 8192}, BytesInUse: 5000, BytesAllocated: 14336}))
+	require.Equal(t, 0, policy(SlabUsage{SlabSizes: []uint64{4096}, BytesInUse: 0, BytesAllocated: 4096}))
+}
+
+func BenchmarkSafeArenaNewObject(b *testing.B) {
+	safeArena := NewSafeArena(2 * 1024 * 1024)
+
+	a := newArenaAllocator[int](safeArena)
+	for _, objectCount := range []int{100, 1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("%d", objectCount), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < objectCount; j++ {
+					_ = a.new()
+				}
+				a.(*arenaAllocator[int]).a.Reset(false)
+			}
+		})
+	}
+}
+
+func BenchmarkSafeArenaNewPointerObject(b *testing.B) {
+	safeArena := NewSafeArena(2*1024*1024, WithInitialTypedSlots(1024))
+
+	a := newArenaAllocator[*int](safeArena)
+	for _, objectCount := range []int{100, 1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("%d", objectCount), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < objectCount; j++ {
+					_ = a.new()
+				}
+				a.(*arenaAllocator[*int]).a.Reset(false)
+			}
+		})
+	}
+}
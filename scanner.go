@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+const (
+	// defaultScanBufferSize is the default size of the buffers a Scanner reads into.
+	defaultScanBufferSize = 4096
+
+	// maxConsecutiveEmptyReads is the number of reads returning no data nor error a Scanner tolerates.
+	maxConsecutiveEmptyReads = 100
+)
+
+// Scanner reads tokens from an io.Reader in the same manner as bufio.Scanner does, except that both its
+// read buffers and the returned tokens are allocated from an arena. As read buffers are never overwritten,
+// tokens remain valid until the arena is reset, rather than being invalidated by the next call to Scan.
+type Scanner struct {
+	a            Arena
+	r            io.Reader
+	split        bufio.SplitFunc
+	bufSize      int
+	maxTokenSize int
+
+	buf        []byte
+	start, end int
+	token      []byte
+	empties    int
+	err        error
+	done       bool
+}
+
+// NewScanner returns a new Scanner reading from r and allocating from the provided arena.
+// The split function defaults to bufio.ScanLines.
+func NewScanner(a Arena, r io.Reader) *Scanner {
+	return &Scanner{
+		a:            a,
+		r:            r,
+		split:        bufio.ScanLines,
+		bufSize:      defaultScanBufferSize,
+		maxTokenSize: bufio.MaxScanTokenSize,
+	}
+}
+
+// Split sets the split function of the Scanner. It must be called before the first call to Scan.
+func (s *Scanner) Split(split bufio.SplitFunc) {
+	s.split = split
+}
+
+// Buffer sets the size of the buffers allocated from the arena to read into, along with the maximum size
+// of a token. It must be called before the first call to Scan.
+func (s *Scanner) Buffer(size, maxTokenSize int) {
+	s.bufSize = size
+	s.maxTokenSize = maxTokenSize
+}
+
+// Scan advances the Scanner to the next token, which will then be available through the Bytes or Text
+// methods. It returns false when the scan stops, either by reaching the end of the input or an error.
+func (s *Scanner) Scan() bool {
+	if s.done {
+		return false
+	}
+	for {
+		if s.end > s.start || s.err != nil {
+			advance, token, err := s.split(s.buf[s.start:s.end], s.err != nil)
+			if err != nil {
+				if errors.Is(err, bufio.ErrFinalToken) {
+					s.token = token
+					s.done = true
+					return true
+				}
+				s.setErr(err)
+				return false
+			}
+			if advance < 0 {
+				s.setErr(bufio.ErrNegativeAdvance)
+				return false
+			}
+			if advance > s.end-s.start {
+				s.setErr(bufio.ErrAdvanceTooFar)
+				return false
+			}
+			s.start += advance
+			if token != nil {
+				s.token = token[:len(token):len(token)] // prevent appends from overwriting upcoming data
+				if advance > 0 {
+					s.empties = 0
+				} else if s.empties++; s.empties > maxConsecutiveEmptyReads {
+					panic("nuke: too many empty tokens without progressing")
+				}
+				return true
+			}
+		}
+		if s.err != nil {
+			s.token = nil
+			s.done = true
+			return false
+		}
+		if s.end == len(s.buf) {
+			if !s.grow() {
+				return false
+			}
+		}
+		s.read()
+	}
+}
+
+// grow allocates a new read buffer from the arena, carrying over the pending data of the current one.
+// The current buffer is left untouched, as previously returned tokens may point into it.
+func (s *Scanner) grow() bool {
+	pending := s.end - s.start
+	size := s.bufSize
+	if pending >= size {
+		if pending >= s.maxTokenSize {
+			s.setErr(bufio.ErrTooLong)
+			return false
+		}
+		size = min(2*pending, s.maxTokenSize)
+	}
+	buf := MakeSlice[byte](s.a, size, size)
+	if buf == nil {
+		buf = make([]byte, size) // the arena is exhausted
+	}
+	copy(buf, s.buf[s.start:s.end])
+	s.buf, s.start, s.end = buf, 0, pending
+	return true
+}
+
+func (s *Scanner) read() {
+	for i := 0; i < maxConsecutiveEmptyReads; i++ {
+		n, err := s.r.Read(s.buf[s.end:])
+		if n < 0 || n > len(s.buf)-s.end {
+			s.setErr(errors.New("nuke: reader returned invalid count"))
+			return
+		}
+		s.end += n
+		if err != nil {
+			s.setErr(err)
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	s.setErr(io.ErrNoProgress)
+}
+
+func (s *Scanner) setErr(err error) {
+	if s.err == nil || s.err == io.EOF {
+		s.err = err
+	}
+}
+
+// Bytes returns the most recent token generated by a call to Scan.
+// The token is allocated from the arena and remains valid until the arena is reset.
+func (s *Scanner) Bytes() []byte {
+	return s.token
+}
+
+// Text returns the most recent token generated by a call to Scan as a string sharing memory with it.
+// The string is allocated from the arena and remains valid until the arena is reset.
+func (s *Scanner) Text() string {
+	return SealString(s.token)
+}
+
+// Err returns the first non-EOF error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScannerLines(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+
+	input := "first line\nsecond\r\n\nthe last line has no newline"
+	s := NewScanner(arena, iotest.OneByteReader(strings.NewReader(input)))
+	s.Buffer(8, 64) // force tokens to straddle read buffers
+
+	var tokens [][]byte
+	var texts []string
+	for s.Scan() {
+		tokens = append(tokens, s.Bytes())
+		texts = append(texts, s.Text())
+	}
+	require.NoError(t, s.Err())
+
+	// Tokens remain valid after subsequent calls to Scan
+	expected := []string{"first line", "second", "", "the last line has no newline"}
+	require.Equal(t, expected, texts)
+	for i, token := range tokens {
+		require.Equal(t, expected[i], string(token))
+		if len(token) > 0 {
+			require.True(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(token))))
+		}
+	}
+	require.False(t, s.Scan())
+}
+
+func TestScannerWords(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+
+	s := NewScanner(arena, strings.NewReader("  nuke   arena\tscanner \n"))
+	s.Split(bufio.ScanWords)
+
+	var words []string
+	for s.Scan() {
+		words = append(words, s.Text())
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, []string{"nuke", "arena", "scanner"}, words)
+}
+
+func TestScannerExhausted(t *testing.T) {
+	arena := NewMonotonicArena(16, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+
+	// Read buffers are allocated on the heap once the arena is exhausted.
+	s := NewScanner(arena, strings.NewReader("first line\nsecond line\n"))
+	s.Buffer(8, 64)
+	var texts []string
+	for s.Scan() {
+		texts = append(texts, s.Text())
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, []string{"first line", "second line"}, texts)
+}
+
+func TestScannerTokenTooLong(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+
+	s := NewScanner(arena, strings.NewReader("short\n"+strings.Repeat("x", 100)+"\n"))
+	s.Buffer(16, 32)
+
+	require.True(t, s.Scan())
+	require.Equal(t, "short", s.Text())
+	require.False(t, s.Scan())
+	require.ErrorIs(t, s.Err(), bufio.ErrTooLong)
+}
+
+func TestScannerErrors(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+
+	errRead := errors.New("read error")
+	s := NewScanner(arena, iotest.TimeoutReader(strings.NewReader("ok\nmore data")))
+	s.Buffer(4, 64)
+	require.True(t, s.Scan())
+	require.Equal(t, "ok", s.Text())
+	require.True(t, s.Scan()) // pending data is flushed as the final token, as bufio.Scanner does
+	require.Equal(t, "m", s.Text())
+	require.False(t, s.Scan())
+	require.ErrorIs(t, s.Err(), iotest.ErrTimeout)
+
+	s = NewScanner(arena, iotest.ErrReader(errRead))
+	require.False(t, s.Scan())
+	require.ErrorIs(t, s.Err(), errRead)
+
+	s = NewScanner(arena, strings.NewReader("data"))
+	s.Split(func([]byte, bool) (int, []byte, error) { return -1, nil, nil })
+	require.False(t, s.Scan())
+	require.ErrorIs(t, s.Err(), bufio.ErrNegativeAdvance)
+
+	s = NewScanner(arena, strings.NewReader("a,b,STOP,c"))
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := strings.IndexByte(string(data), ','); i >= 0 {
+			if string(data[:i]) == "STOP" {
+				return i + 1, data[:i], bufio.ErrFinalToken
+			}
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	})
+	var tokens []string
+	for s.Scan() {
+		tokens = append(tokens, s.Text())
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, []string{"a", "b", "STOP"}, tokens)
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sealer is an optional interface implemented by arenas able to make their memory read-only, such as those
+// created by NewMmapArena and NewOffHeapArena, so that accidental writes to data meant to be immutable,
+// such as an index shared across requests, fault immediately instead of silently corrupting it.
+type Sealer interface {
+	// Seal makes the memory of the arena read-only. Allocating from a sealed arena panics with ErrArenaSealed.
+	Seal() error
+
+	// Unseal makes the memory of a sealed arena writable again.
+	Unseal() error
+}
+
+// Seal satisfies the Sealer interface. It returns an error wrapping errors.ErrUnsupported unless the buffers
+// of the arena are mapped with mmap. Resetting a sealed arena unseals it.
+func (a *monotonicArena) Seal() error {
+	if !a.mapped || !MmapSupported {
+		return fmt.Errorf("nuke: sealing requires mapped buffers: %w", errors.ErrUnsupported)
+	}
+	if err := a.protect(true); err != nil {
+		return errors.Join(err, a.protect(false))
+	}
+	a.sealed = true
+	return nil
+}
+
+// Unseal satisfies the Sealer interface.
+func (a *monotonicArena) Unseal() error {
+	if !a.sealed {
+		return nil
+	}
+	if err := a.protect(false); err != nil {
+		return err
+	}
+	a.sealed = false
+	return nil
+}
+
+// protect makes the mapped buffers of the arena read-only, or writable again.
+func (a *monotonicArena) protect(readOnly bool) error {
+	for _, bufs := range [][]*monotonicBuffer{a.buffers, a.oversized} {
+		for _, s := range bufs {
+			if s.base == nil {
+				continue
+			}
+			if err := protectBuffer(s.base, s.size+s.padding(), readOnly); err != nil {
+				return fmt.Errorf("nuke: unable to protect %d bytes: %w", s.size, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *monotonicArena) checkUnsealed() {
+	if a.sealed {
+		panic(fmt.Errorf("%w: unable to allocate from a sealed arena", ErrArenaSealed))
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// SessionArena is an arena that groups allocations into time-bucketed regions, so that stale regions
+// can be reclaimed without resetting the whole arena. It fits long-lived sessions that accumulate state
+// continuously but only need to keep recent history around.
+//
+// A SessionArena is not safe to be accessed concurrently from multiple goroutines.
+type SessionArena struct {
+	bucketDuration time.Duration
+	bufferSize     int
+	opts           options
+	now            func() time.Time
+
+	regions  []sessionRegion // sorted from oldest to newest
+	spare    []*monotonicArena
+	counters arenaCounters
+	hooks    resetHooks
+}
+
+type sessionRegion struct {
+	start time.Time
+	arena *monotonicArena
+}
+
+// NewSessionArena creates a new session arena whose regions span bucketDuration each and start with
+// a buffer of bufferSize bytes. Unless a different policy is installed by WithOnExhausted, regions grow
+// as needed to satisfy every allocation.
+func NewSessionArena(bucketDuration time.Duration, bufferSize int, opts ...Option) *SessionArena {
+	o := newOptions(opts)
+	if o.onExhausted == nil {
+		o.onExhausted = func(Exhaustion) ExhaustedAction { return ExhaustedGrow }
+	}
+	return &SessionArena{
+		bucketDuration: bucketDuration,
+		bufferSize:     bufferSize,
+		opts:           o,
+		now:            time.Now,
+	}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *SessionArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	r := a.currentRegion()
+	defer a.track(r, r.counters)
+	return r.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *SessionArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	r := a.currentRegion()
+	defer a.track(r, r.counters)
+	return r.AllocType(t, n)
+}
+
+// track accounts for the allocations a region served since its counters were last observed.
+func (a *SessionArena) track(r *monotonicArena, before arenaCounters) {
+	a.counters.allocated(r.counters.bytesInUse - before.bytesInUse)
+	a.counters.heapFallbacks += r.counters.heapFallbacks - before.heapFallbacks
+	a.counters.pointerAllocs += r.counters.pointerAllocs - before.pointerAllocs
+}
+
+// Reset satisfies the Arena interface.
+func (a *SessionArena) Reset(release bool) {
+	a.hooks.run()
+	a.counters.reset()
+	if release {
+		a.regions = nil
+		a.spare = nil
+		return
+	}
+	for _, r := range a.regions {
+		r.arena.Reset(false)
+		a.spare = append(a.spare, r.arena)
+	}
+	clear(a.regions)
+	a.regions = a.regions[:0]
+}
+
+// OnReset satisfies the ResetNotifier interface. Callbacks are only invoked by Reset, not by ExpireOlderThan.
+func (a *SessionArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *SessionArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// ExpireOlderThan releases the memory of every region whose allocations are all older than d.
+// After invoking this method any pointer previously allocated from an expired region becomes immediately invalid.
+func (a *SessionArena) ExpireOlderThan(d time.Duration) {
+	deadline := a.now().Add(-d)
+
+	var expired int
+	for _, r := range a.regions {
+		if r.start.Add(a.bucketDuration).After(deadline) {
+			break
+		}
+		a.counters.bytesInUse -= r.arena.counters.bytesInUse
+		expired++
+	}
+	n := copy(a.regions, a.regions[expired:])
+	clear(a.regions[n:]) // let expired regions be garbage collected
+	a.regions = a.regions[:n]
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *SessionArena) Stats() Stats {
+	s := a.counters.stats()
+	for _, r := range a.regions {
+		rs := r.arena.Stats()
+		s.BytesAllocated += rs.BytesAllocated
+		s.Buffers += rs.Buffers
+	}
+	for _, arena := range a.spare {
+		rs := arena.Stats()
+		s.BytesAllocated += rs.BytesAllocated
+		s.Buffers += rs.Buffers
+	}
+	return s
+}
+
+// Owns satisfies the Owner interface. The memory of expired sessions is not owned by the arena anymore.
+func (a *SessionArena) Owns(ptr unsafe.Pointer) bool {
+	for _, r := range a.regions {
+		if r.arena.Owns(ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+// currentRegion returns the region allocations should be served from, opening a new one
+// if the newest region's time bucket has elapsed.
+func (a *SessionArena) currentRegion() *monotonicArena {
+	now := a.now()
+	if n := len(a.regions); n > 0 {
+		if r := a.regions[n-1]; now.Before(r.start.Add(a.bucketDuration)) {
+			return r.arena
+		}
+	}
+	var arena *monotonicArena
+	if n := len(a.spare); n > 0 {
+		arena = a.spare[n-1]
+		a.spare[n-1] = nil
+		a.spare = a.spare[:n-1]
+	} else {
+		arena = newMonotonicArena(a.bufferSize, 1, a.opts)
+	}
+	a.regions = append(a.regions, sessionRegion{start: now, arena: arena})
+	return arena
+}
+
+func (a *SessionArena) resetCount() uint64 {
+	return a.counters.resets
+}
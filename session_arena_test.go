@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionArenaBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	arena := NewSessionArena(time.Minute, 1024)
+	arena.now = func() time.Time { return now }
+
+	p0 := New[int](arena)
+	now = now.Add(30 * time.Second)
+	p1 := New[int](arena)
+	require.Len(t, arena.regions, 1)
+
+	now = now.Add(40 * time.Second)
+	p2 := New[int](arena)
+	require.Len(t, arena.regions, 2)
+
+	require.True(t, Owns(arena, unsafe.Pointer(p0)))
+	require.True(t, Owns(arena, unsafe.Pointer(p1)))
+	require.True(t, Owns(arena, unsafe.Pointer(p2)))
+
+	// The first region still holds allocations younger than 45 seconds
+	arena.ExpireOlderThan(45 * time.Second)
+	require.Len(t, arena.regions, 2)
+
+	// The first region ended 10 seconds ago
+	arena.ExpireOlderThan(10 * time.Second)
+	require.Len(t, arena.regions, 1)
+	require.False(t, Owns(arena, unsafe.Pointer(p0)))
+	require.True(t, Owns(arena, unsafe.Pointer(p2)))
+
+	now = now.Add(time.Hour)
+	arena.ExpireOlderThan(time.Minute)
+	require.Empty(t, arena.regions)
+}
+
+func TestSessionArenaGrowsRegions(t *testing.T) {
+	arena := NewSessionArena(time.Hour, 64)
+
+	s := MakeSlice[byte](arena, 1024, 1024)
+	require.True(t, Owns(arena, unsafe.Pointer(&s[1023])))
+	require.Len(t, arena.regions, 1)
+}
+
+func TestSessionArenaReset(t *testing.T) {
+	now := time.Unix(0, 0)
+	arena := NewSessionArena(time.Minute, 1024)
+	arena.now = func() time.Time { return now }
+
+	*New[int](arena) = 1
+	now = now.Add(time.Minute)
+	*New[int](arena) = 2
+
+	// Regions memory is kept around for reuse
+	arena.Reset(false)
+	require.Empty(t, arena.regions)
+	require.Len(t, arena.spare, 2)
+
+	require.Equal(t, 0, *New[int](arena))
+	require.Len(t, arena.spare, 1)
+
+	arena.Reset(true)
+	require.Empty(t, arena.regions)
+	require.Empty(t, arena.spare)
+}
+
+func TestSessionArenaStats(t *testing.T) {
+	now := time.Unix(0, 0)
+	arena := NewSessionArena(time.Minute, 64)
+	arena.now = func() time.Time { return now }
+
+	_ = MakeSlice[byte](arena, 32, 32)
+	now = now.Add(time.Minute)
+	_ = MakeSlice[byte](arena, 16, 16)
+
+	require.Equal(t, Stats{
+		BytesAllocated: 128,
+		BytesInUse:     48,
+		Buffers:        2,
+		HighWaterMark:  48,
+	}, arena.Stats())
+
+	arena.ExpireOlderThan(0)
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		BytesInUse:     16,
+		Buffers:        1,
+		HighWaterMark:  48,
+	}, arena.Stats())
+
+	arena.Reset(false)
+	require.Equal(t, Stats{
+		BytesAllocated: 64,
+		Buffers:        1,
+		HighWaterMark:  48,
+		Resets:         1,
+	}, arena.Stats())
+}
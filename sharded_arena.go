@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// shardedArena spreads allocations across a set of independently locked arenas, falling over to another shard
+// whenever the preferred one is contended rather than queuing on its lock.
+type shardedArena struct {
+	shards []lockedArena
+	next   atomic.Uint32
+
+	// mtx guards the operations spanning the whole arena.
+	mtx           sync.Mutex
+	highWaterMark uint64
+	resets        atomic.Uint64
+	hooks         resetHooks
+	meter         contentionMeter
+}
+
+type lockedArena struct {
+	mtx    sync.Mutex
+	a      Arena
+	allocs atomic.Uint64
+}
+
+// NewShardedArena returns an arena that is safe to be accessed concurrently from multiple goroutines, made of
+// as many shards as requested, each one being an arena returned by newArena. Rather than queuing on a single lock
+// as NewConcurrentArena does, allocations try to lock the shards in turn, only waiting for one when all of them
+// are contended.
+func NewShardedArena(shards int, newArena func() Arena) Arena {
+	a := &shardedArena{shards: make([]lockedArena, max(shards, 1))}
+	for i := range a.shards {
+		a.shards[i].a = newArena()
+	}
+	return a
+}
+
+// Alloc satisfies the Arena interface.
+func (a *shardedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	s := a.shard()
+	defer s.mtx.Unlock()
+	return s.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *shardedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	s := a.shard()
+	defer s.mtx.Unlock()
+	if ta, ok := s.a.(TypedArena); ok {
+		return ta.AllocType(t, n)
+	}
+	return s.a.Alloc(t.Size()*uintptr(n), uintptr(t.Align())), true
+}
+
+// shard returns a locked shard, preferring those not being used by other goroutines.
+func (a *shardedArena) shard() *lockedArena {
+	first := int(a.next.Add(1))
+	for i := 0; i < len(a.shards); i++ {
+		if s := &a.shards[(first+i)%len(a.shards)]; s.mtx.TryLock() {
+			if i > 0 {
+				a.meter.fallovers.Add(1)
+			}
+			a.acquired(s)
+			return s
+		}
+	}
+	s := &a.shards[first%len(a.shards)]
+	start := time.Now()
+	s.mtx.Lock()
+	a.meter.waited(start)
+	a.acquired(s)
+	return s
+}
+
+func (a *shardedArena) acquired(s *lockedArena) {
+	a.meter.acquisitions.Add(1)
+	s.allocs.Add(1)
+}
+
+// ConcurrencyStats satisfies the ConcurrencyStatsProvider interface.
+func (a *shardedArena) ConcurrencyStats() ConcurrencyStats {
+	s := a.meter.stats()
+	s.ShardAllocations = make([]uint64, len(a.shards))
+	for i := range a.shards {
+		s.ShardAllocations[i] = a.shards[i].allocs.Load()
+	}
+	return s
+}
+
+// Reset satisfies the Arena interface.
+func (a *shardedArena) Reset(release bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.run()
+	a.highWaterMark = max(a.highWaterMark, a.stats().BytesInUse)
+	a.each(func(sa Arena) { sa.Reset(release) })
+	a.resets.Add(1)
+}
+
+// OnReset satisfies the ResetNotifier interface.
+// Callbacks are invoked while holding the arena lock, hence they must not access the arena.
+func (a *shardedArena) OnReset(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.add(f)
+}
+
+func (a *shardedArena) watchResets(f func()) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface, aggregating the statistics of the shards implementing it.
+// The high water mark is sampled whenever the arena is reset or its statistics are queried.
+func (a *shardedArena) Stats() Stats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	s := a.stats()
+	a.highWaterMark = max(a.highWaterMark, s.BytesInUse)
+	s.HighWaterMark = a.highWaterMark
+	s.Resets = a.resets.Load()
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *shardedArena) Owns(ptr unsafe.Pointer) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	var owned bool
+	a.each(func(sa Arena) { owned = owned || Owns(sa, ptr) })
+	return owned
+}
+
+func (a *shardedArena) stats() Stats {
+	var s Stats
+	a.each(func(sa Arena) {
+		if sp, ok := sa.(StatsProvider); ok {
+			ss := sp.Stats()
+			s.BytesAllocated += ss.BytesAllocated
+			s.BytesInUse += ss.BytesInUse
+			s.Buffers += ss.Buffers
+			s.HeapFallbacks += ss.HeapFallbacks
+			s.PointerAllocations += ss.PointerAllocations
+		}
+	})
+	return s
+}
+
+// each invokes f on every shard, holding its lock. The caller must hold mtx.
+func (a *shardedArena) each(f func(sa Arena)) {
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mtx.Lock()
+		f(s.a)
+		s.mtx.Unlock()
+	}
+}
+
+func (a *shardedArena) resetCount() uint64 {
+	return a.resets.Load()
+}
+
+func (a *shardedArena) concurrencySafe() bool {
+	return true
+}
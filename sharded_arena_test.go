@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedArenaFallsOverToUncontendedShards(t *testing.T) {
+	arena := NewShardedArena(2, func() Arena { return NewMonotonicArena(1024, 1) }).(*shardedArena)
+
+	// While a shard is busy, allocations are served from the other one.
+	arena.shards[0].mtx.Lock()
+	for i := 0; i < 4; i++ {
+		_ = New[int64](arena)
+	}
+	arena.shards[0].mtx.Unlock()
+
+	require.Equal(t, uint64(0), arena.shards[0].a.(StatsProvider).Stats().BytesInUse)
+	require.Equal(t, uint64(32), arena.shards[1].a.(StatsProvider).Stats().BytesInUse)
+}
+
+func TestShardedArenaStats(t *testing.T) {
+	arena := NewShardedArena(4, func() Arena { return NewMonotonicArena(1024, 1) })
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				*New[int64](arena) = int64(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := arena.(StatsProvider).Stats()
+	require.Equal(t, uint64(3200), s.BytesInUse)
+	require.Equal(t, 4, s.Buffers)
+
+	arena.Reset(false)
+	require.Equal(t, Stats{
+		BytesAllocated: 4096,
+		Buffers:        4,
+		HighWaterMark:  3200,
+		Resets:         1,
+	}, arena.(StatsProvider).Stats())
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+const (
+	// maxSizeClass is the size of the largest size class, beyond which allocations are served from
+	// dedicated buffers.
+	maxSizeClass = 32 * 1024
+
+	// spanAlignment is the alignment of the start of every span, which bounds the alignment size classes serve.
+	spanAlignment = 64
+)
+
+// sizeClasses holds the size of every size class, in increasing order. Classes are spaced by 16 bytes up to
+// 128 bytes, and by a quarter of the previous power of two beyond, which bounds the internal fragmentation
+// to 25%, as tcmalloc does.
+var sizeClasses = func() []uintptr {
+	classes := []uintptr{8}
+	for size := uintptr(16); size <= 128; size += 16 {
+		classes = append(classes, size)
+	}
+	for pow := uintptr(128); pow < maxSizeClass; pow *= 2 {
+		for step := uintptr(1); step <= 4; step++ {
+			classes = append(classes, pow+step*pow/4)
+		}
+	}
+	return classes
+}()
+
+// SizeClassArena is an arena rounding allocations up to a set of size classes, each of which is carved out of
+// spans of memory and keeps a free list of the blocks handed back by Free, so that subsequent allocations of the
+// same class reuse them. It fits long-lived arenas whose values churn rather than dying all at once. Allocations
+// larger than the biggest size class are served from dedicated buffers, which Free drops.
+//
+// Spans are backed by byte slices, which the GC does not scan, hence the arena is meant to hold POD types.
+// A SizeClassArena is not safe to be accessed concurrently from multiple goroutines.
+type SizeClassArena struct {
+	spanSize int
+	opts     options
+	counters arenaCounters
+	hooks    resetHooks
+
+	classes []sizeClass
+	spans   []sizeClassSpan
+	used    int // number of spans handed to size classes since the last reset
+	large   map[uintptr]largeBlock
+
+	allocatedBytes uintptr // overall size of the spans and dedicated buffers
+}
+
+// sizeClass carves blocks of a single size from the span it was last handed.
+type sizeClass struct {
+	size uintptr
+	free unsafe.Pointer // head of the free list, every free block holding a pointer to the next one
+	next unsafe.Pointer // next block to carve from the current span
+	left uintptr        // number of bytes of the current span left to carve
+}
+
+type sizeClassSpan struct {
+	buf  []byte
+	ptr  unsafe.Pointer // start of the span, aligned to spanAlignment within buf
+	size uintptr        // number of bytes from ptr to the end of buf
+}
+
+type largeBlock struct {
+	buf  []byte
+	size uintptr
+}
+
+// NewSizeClassArena returns a SizeClassArena whose size classes carve blocks from spans of spanSize bytes,
+// or of the size of a single block if larger. Spans are allocated on demand, and retained across non-releasing
+// resets. WithMaxBytes bounds the overall size of spans and dedicated buffers, beyond which allocations trigger
+// the exhaustion policy.
+func NewSizeClassArena(spanSize int, opts ...Option) *SizeClassArena {
+	a := &SizeClassArena{
+		spanSize: spanSize,
+		opts:     newOptions(opts),
+		classes:  make([]sizeClass, len(sizeClasses)),
+		large:    make(map[uintptr]largeBlock),
+	}
+	for i, size := range sizeClasses {
+		a.classes[i].size = size
+	}
+	return a
+}
+
+// Alloc satisfies the Arena interface.
+func (a *SizeClassArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	ptr, _ := a.alloc(size, alignment, nil)
+	return ptr
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *SizeClassArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if !a.opts.allowPointers(t, &a.counters) {
+		return nil, true
+	}
+	return a.alloc(t.Size()*uintptr(n), uintptr(t.Align()), t)
+}
+
+func (a *SizeClassArena) alloc(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if size == 0 {
+		return unsafe.Pointer(&zeroSizedBase), true
+	}
+	raceAlloc(unsafe.Pointer(a))
+	c := a.classOf(size)
+	if c == nil || c.size%alignment != 0 || alignment > spanAlignment {
+		return a.allocLarge(size, alignment, t)
+	}
+	if ptr := c.free; ptr != nil {
+		c.free = *(*unsafe.Pointer)(ptr)
+		clear(unsafe.Slice((*byte)(ptr), c.size))
+		a.counters.allocated(uint64(c.size))
+		return ptr, true
+	}
+	if c.left < c.size {
+		span, fallback := a.nextSpan(c.size, t)
+		if span == nil {
+			return nil, fallback
+		}
+		c.next, c.left = span.ptr, span.size/c.size*c.size
+	}
+	ptr := c.next
+	c.next, c.left = unsafe.Add(c.next, c.size), c.left-c.size
+	a.counters.allocated(uint64(c.size))
+	return ptr, true
+}
+
+// classOf returns the smallest size class fitting size bytes, or nil if there is none.
+func (a *SizeClassArena) classOf(size uintptr) *sizeClass {
+	if size > maxSizeClass {
+		return nil
+	}
+	return &a.classes[sort.Search(len(sizeClasses), func(i int) bool { return sizeClasses[i] >= size })]
+}
+
+// nextSpan returns a span able to hold blocks of the given size, reusing those retained by previous resets,
+// or nil if the exhaustion policy decides otherwise, along with whether to fall back to the heap.
+func (a *SizeClassArena) nextSpan(blockSize uintptr, t reflect.Type) (*sizeClassSpan, bool) {
+	size := max(uintptr(a.spanSize), blockSize)
+	for ; a.used < len(a.spans); a.used++ {
+		if s := &a.spans[a.used]; s.size >= blockSize {
+			a.used++
+			return s, false
+		}
+	}
+	if ok, fallback := a.grow(size+spanAlignment-1, blockSize, spanAlignment, t); !ok {
+		return nil, fallback
+	}
+	buf := make([]byte, size+spanAlignment-1)
+	ptr := unsafe.Pointer(unsafe.SliceData(buf))
+	off := (spanAlignment - uintptr(ptr)%spanAlignment) % spanAlignment
+	a.spans = append(a.spans, sizeClassSpan{buf: buf, ptr: unsafe.Add(ptr, off), size: uintptr(len(buf)) - off})
+	a.used = len(a.spans)
+	a.allocatedBytes += uintptr(len(buf))
+	traceGrowth(uintptr(len(buf)))
+	return &a.spans[a.used-1], false
+}
+
+// allocLarge serves an allocation not fitting any size class from a dedicated buffer.
+func (a *SizeClassArena) allocLarge(size, alignment uintptr, t reflect.Type) (unsafe.Pointer, bool) {
+	if ok, fallback := a.grow(size+alignment-1, size, alignment, t); !ok {
+		return nil, fallback
+	}
+	buf := make([]byte, size+alignment-1)
+	ptr := unsafe.Pointer(unsafe.SliceData(buf))
+	ptr = unsafe.Add(ptr, (alignment-uintptr(ptr)%alignment)%alignment)
+	a.large[uintptr(ptr)] = largeBlock{buf: buf, size: size}
+	a.allocatedBytes += uintptr(len(buf))
+	a.counters.allocated(uint64(size))
+	traceGrowth(uintptr(len(buf)))
+	return ptr, true
+}
+
+// grow reports whether n more bytes can be allocated for the arena, applying the exhaustion policy to the
+// allocation of size bytes otherwise, in which case it also reports whether the allocation falls back to the heap.
+func (a *SizeClassArena) grow(n, size, alignment uintptr, t reflect.Type) (ok, fallback bool) {
+	if a.opts.maxBytes <= 0 || a.allocatedBytes+n <= uintptr(a.opts.maxBytes) {
+		return true, false
+	}
+	switch a.opts.exhausted(Exhaustion{Size: size, Alignment: alignment, Type: t}) {
+	case ExhaustedGrow:
+		return true, false
+
+	case ExhaustedPanic:
+		panic(fmt.Errorf("%w: unable to allocate %d bytes", ErrArenaExhausted, size))
+
+	case ExhaustedReturnNil:
+		return false, false
+
+	default:
+		a.counters.heapFallbacks++
+		traceFallback(size)
+		return false, true
+	}
+}
+
+// Free hands the size bytes ptr points to, which must have been allocated from the arena with the same size
+// since its last Reset, back to the arena, so that subsequent allocations of the same size class reuse them.
+// After invoking this method ptr becomes immediately invalid. Passing a nil pointer, or one the arena did not
+// allocate, such as the result of a heap fallback, is a no-op.
+func (a *SizeClassArena) Free(ptr unsafe.Pointer, size uintptr) {
+	if ptr == nil || size == 0 {
+		return
+	}
+	if b, ok := a.large[uintptr(ptr)]; ok {
+		delete(a.large, uintptr(ptr))
+		a.allocatedBytes -= uintptr(len(b.buf))
+		a.counters.bytesInUse -= uint64(b.size)
+		return
+	}
+	c := a.classOf(size)
+	if c == nil || !a.Owns(ptr) {
+		return
+	}
+	*(*unsafe.Pointer)(ptr) = c.free
+	c.free = ptr
+	a.counters.bytesInUse -= uint64(c.size)
+}
+
+func (a *SizeClassArena) free(t reflect.Type, ptr unsafe.Pointer) {
+	a.Free(ptr, t.Size())
+}
+
+// Reset satisfies the Arena interface. Spans are zeroed out and retained unless releasing memory,
+// whereas dedicated buffers are always released.
+func (a *SizeClassArena) Reset(release bool) {
+	defer traceReset().End()
+	a.hooks.run()
+	for i := range a.classes {
+		c := &a.classes[i]
+		c.free, c.next, c.left = nil, nil, 0
+	}
+	for _, s := range a.spans[:a.used] {
+		raceReclaim(unsafe.Pointer(unsafe.SliceData(s.buf)), uintptr(len(s.buf)))
+		if !release {
+			clear(s.buf)
+		}
+	}
+	if release {
+		a.spans = nil
+		a.allocatedBytes = 0
+	}
+	for p, b := range a.large {
+		if !release {
+			a.allocatedBytes -= uintptr(len(b.buf))
+		}
+		delete(a.large, p)
+	}
+	a.used = 0
+	a.counters.reset()
+	raceReset(unsafe.Pointer(a))
+}
+
+// OnReset satisfies the ResetNotifier interface.
+func (a *SizeClassArena) OnReset(f func()) {
+	a.hooks.add(f)
+}
+
+func (a *SizeClassArena) watchResets(f func()) {
+	a.hooks.watch(f)
+}
+
+// Stats satisfies the StatsProvider interface. Bytes in use account for the size classes allocations are
+// rounded up to.
+func (a *SizeClassArena) Stats() Stats {
+	s := a.counters.stats()
+	s.BytesAllocated = uint64(a.allocatedBytes)
+	s.Buffers = len(a.spans) + len(a.large)
+	return s
+}
+
+// Owns satisfies the Owner interface.
+func (a *SizeClassArena) Owns(ptr unsafe.Pointer) bool {
+	for _, s := range a.spans {
+		if within(unsafe.Pointer(unsafe.SliceData(s.buf)), uintptr(len(s.buf)), ptr) {
+			return true
+		}
+	}
+	for _, b := range a.large {
+		if within(unsafe.Pointer(unsafe.SliceData(b.buf)), uintptr(len(b.buf)), ptr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *SizeClassArena) resetCount() uint64 {
+	return a.counters.resets
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeClasses(t *testing.T) {
+	require.Equal(t, []uintptr{8, 16, 32, 48, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}, sizeClasses[:14])
+	require.Equal(t, uintptr(maxSizeClass), sizeClasses[len(sizeClasses)-1])
+
+	arena := NewSizeClassArena(4096)
+	require.Equal(t, uintptr(8), arena.classOf(1).size)
+	require.Equal(t, uintptr(48), arena.classOf(33).size)
+	require.Equal(t, uintptr(320), arena.classOf(257).size)
+	require.Nil(t, arena.classOf(maxSizeClass+1))
+}
+
+func TestSizeClassArenaFree(t *testing.T) {
+	arena := NewSizeClassArena(4096)
+
+	a := MakeSlice[byte](arena, 40, 40)
+	b := MakeSlice[byte](arena, 48, 48)
+	require.Equal(t, unsafe.Add(unsafe.Pointer(&a[0]), 48), unsafe.Pointer(&b[0])) // rounded up to the same class
+	c := New[[4]uint64](arena)
+	require.Equal(t, uint64(48+48+32), arena.Stats().BytesInUse)
+
+	// Freed blocks are reused, last in first out, by allocations of the same size class.
+	copy(a, "deadbeef")
+	arena.Free(unsafe.Pointer(&b[0]), 48)
+	arena.Free(unsafe.Pointer(&a[0]), 40)
+	require.Equal(t, uint64(32), arena.Stats().BytesInUse)
+	require.Same(t, &a[0], &MakeSlice[byte](arena, 33, 33)[0])
+	require.Equal(t, make([]byte, 40), a)
+	require.Same(t, &b[0], &MakeSlice[byte](arena, 48, 48)[0])
+	require.NotSame(t, c, New[[4]uint64](arena))
+
+	Free(arena, c)
+	require.Same(t, c, New[[4]uint64](arena))
+
+	// Memory the arena did not allocate is left alone.
+	var x [8]byte
+	arena.Free(unsafe.Pointer(&x), 8)
+	arena.Free(nil, 8)
+	require.NotSame(t, c, New[[4]uint64](arena)) // the free list of the class is empty
+	require.Equal(t, 2, arena.Stats().Buffers)   // a span per size class
+}
+
+func TestSizeClassArenaLargeAllocations(t *testing.T) {
+	arena := NewSizeClassArena(4096)
+
+	s := MakeSlice[byte](arena, maxSizeClass+1, maxSizeClass+1)
+	p := arena.Alloc(64, 128) // stricter alignment than spans guarantee
+	require.Zero(t, uintptr(p)%128)
+	require.True(t, Owns(arena, unsafe.Pointer(&s[maxSizeClass])))
+	require.Equal(t, 2, arena.Stats().Buffers)
+	require.Equal(t, uint64(maxSizeClass+1+64), arena.Stats().BytesInUse)
+
+	arena.Free(unsafe.Pointer(&s[0]), uintptr(len(s)))
+	require.False(t, Owns(arena, unsafe.Pointer(&s[0])))
+	require.Equal(t, uint64(64), arena.Stats().BytesInUse)
+	require.Equal(t, uint64(64+127), arena.Stats().BytesAllocated)
+
+	arena.Reset(false)
+	require.Equal(t, Stats{Resets: 1, HighWaterMark: maxSizeClass + 1 + 64}, arena.Stats())
+}
+
+func TestSizeClassArenaReset(t *testing.T) {
+	arena := NewSizeClassArena(256, WithMaxBytes(1024))
+
+	p := New[[16]uint64](arena)
+	p[15] = 1
+	arena.Free(unsafe.Pointer(p), unsafe.Sizeof(*p))
+	_ = New[uint64](arena) // carves a span of its own
+	require.Equal(t, 2, arena.Stats().Buffers)
+
+	// Spans are zeroed out and retained, and free lists are dropped.
+	arena.Reset(false)
+	require.Zero(t, p[15])
+	require.Equal(t, 2, arena.Stats().Buffers)
+	q := New[uint64](arena)
+	require.Equal(t, unsafe.Pointer(p), unsafe.Pointer(q))
+
+	// Exhausting the budget falls back to the heap.
+	for i := 0; i < 4; i++ {
+		_ = MakeSlice[byte](arena, 200, 200)
+	}
+	require.Equal(t, uint64(2), arena.Stats().HeapFallbacks)
+
+	arena.Reset(true)
+	require.Equal(t, 0, arena.Stats().Buffers)
+	require.Zero(t, arena.Stats().BytesAllocated)
+}
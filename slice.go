@@ -16,20 +16,7 @@ func SliceAppend[T any](a Arena, s []T, data ...T) []T {
 }
 
 func growSlice[T any](a Arena, s []T, dataLen int) []T {
-	newLen := len(s) + dataLen
-	newCap := cap(s)
-
-	if newCap > 0 {
-		for newLen > newCap {
-			if newCap < growThreshold {
-				newCap *= 2
-			} else {
-				newCap += newCap / 4
-			}
-		}
-	} else {
-		newCap = dataLen
-	}
+	newCap := growCap(cap(s), len(s)+dataLen, dataLen)
 	if newCap == cap(s) {
 		return s
 	}
@@ -37,3 +24,19 @@ func growSlice[T any](a Arena, s []T, dataLen int) []T {
 	copy(s2, s)
 	return s2
 }
+
+// growCap returns the capacity a slice of the given capacity grows to in order to fit newLen elements, dataLen of
+// which are being appended.
+func growCap(newCap, newLen, dataLen int) int {
+	if newCap == 0 {
+		return dataLen
+	}
+	for newLen > newCap {
+		if newCap < growThreshold {
+			newCap *= 2
+		} else {
+			newCap += newCap / 4
+		}
+	}
+	return newCap
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// SnapshotArena is a monotonic arena bump allocating from a temporary file mapped in memory, which can fork
+// copy-on-write snapshots of its data: every snapshot maps the file privately, so that speculative computations
+// can mutate a view of a large POD structure without affecting the arena nor other snapshots, and throw it away
+// cheaply, as only the pages written to are copied. Snapshots require the data of the arena to be immutable, hence
+// taking one seals the arena, which remains sealed until every snapshot has been released.
+//
+// The arena never touches the Go heap: it does not grow, and exhausting it makes New and MakeSlice return nil.
+// Allocating types containing pointers panics with ErrPointerType regardless of WithPointerPolicy, as the garbage
+// collector does not scan the file. A SnapshotArena is not safe to be accessed concurrently from multiple
+// goroutines.
+type SnapshotArena struct {
+	*monotonicArena
+	f         *os.File
+	data      []byte
+	snapshots int // number of live snapshots
+	closed    bool
+}
+
+// Snapshot is a copy-on-write view of the data of a SnapshotArena as of the time it was taken.
+// Pointers into the arena are translated into pointers into the snapshot by means of Translate.
+type Snapshot struct {
+	a    *SnapshotArena
+	data []byte
+}
+
+// NewSnapshotArena returns a SnapshotArena able to allocate size bytes, rounded up to the page size, from an
+// unlinked file created in os.TempDir. Close must be invoked before discarding the arena. Mapping files is only
+// supported on the platforms reported by MmapSupported, except Windows, elsewhere errors.ErrUnsupported is returned.
+func NewSnapshotArena(size int, opts ...Option) (*SnapshotArena, error) {
+	size = (size + pageSize - 1) / pageSize * pageSize
+	f, err := os.CreateTemp("", "nuke-snapshot-*")
+	if err != nil {
+		return nil, err
+	}
+	// The file lives on for as long as it is open or mapped.
+	if err := errors.Join(os.Remove(f.Name()), f.Truncate(int64(size))); err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+	data, err := mmapFile(f, size)
+	if err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+	a := newFixedArena(unsafe.Pointer(unsafe.SliceData(data)), size,
+		newOptions(append(slices.Clip(opts), WithPointerPolicy(PointerPanic))))
+	a.external = true
+	return &SnapshotArena{monotonicArena: a, f: f, data: data}, nil
+}
+
+// Alloc satisfies the Arena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *SnapshotArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.checkOpen()
+	return a.monotonicArena.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface. It panics with ErrArenaClosed once the arena is closed.
+func (a *SnapshotArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.checkOpen()
+	return a.monotonicArena.AllocType(t, n)
+}
+
+// Reset satisfies the Arena interface, unsealing the arena. It panics with ErrLiveSnapshots if snapshots of the
+// arena have not been released, and with ErrArenaClosed once the arena is closed.
+func (a *SnapshotArena) Reset(release bool) {
+	a.checkOpen()
+	if err := a.Unseal(); err != nil {
+		panic(err)
+	}
+	a.monotonicArena.Reset(release)
+}
+
+// Seal satisfies the Sealer interface.
+func (a *SnapshotArena) Seal() error {
+	a.checkOpen()
+	if a.sealed {
+		return nil
+	}
+	if err := protectBuffer(unsafe.Pointer(unsafe.SliceData(a.data)), uintptr(len(a.data)), true); err != nil {
+		return fmt.Errorf("nuke: unable to protect %d bytes: %w", len(a.data), err)
+	}
+	a.sealed = true
+	return nil
+}
+
+// Unseal satisfies the Sealer interface. It returns ErrLiveSnapshots if snapshots of the arena have not been
+// released.
+func (a *SnapshotArena) Unseal() error {
+	a.checkOpen()
+	if !a.sealed {
+		return nil
+	}
+	if a.snapshots > 0 {
+		return fmt.Errorf("%w: %d snapshots have not been released", ErrLiveSnapshots, a.snapshots)
+	}
+	if err := protectBuffer(unsafe.Pointer(unsafe.SliceData(a.data)), uintptr(len(a.data)), false); err != nil {
+		return fmt.Errorf("nuke: unable to protect %d bytes: %w", len(a.data), err)
+	}
+	a.sealed = false
+	return nil
+}
+
+// Snapshot seals the arena and returns a copy-on-write snapshot of its data, which must be released once
+// discarded.
+func (a *SnapshotArena) Snapshot() (*Snapshot, error) {
+	if err := a.Seal(); err != nil {
+		return nil, err
+	}
+	data, err := mmapFileCopy(a.f, len(a.data))
+	if err != nil {
+		return nil, err
+	}
+	a.snapshots++
+	return &Snapshot{a: a, data: data}, nil
+}
+
+// Close unmaps and closes the file backing the arena, invalidating the memory allocated from it, whereas live
+// snapshots remain valid until released. Further use of the arena panics, and closing it again returns
+// ErrArenaClosed.
+func (a *SnapshotArena) Close() error {
+	if a.closed {
+		return ErrArenaClosed
+	}
+	a.closed = true
+	return errors.Join(munmapFile(a.data), a.f.Close())
+}
+
+func (a *SnapshotArena) checkOpen() {
+	if a.closed {
+		panic(fmt.Errorf("%w: %T used after Close", ErrArenaClosed, a))
+	}
+}
+
+// Translate returns the pointer into the snapshot matching ptr, which must point into the arena.
+func (s *Snapshot) Translate(ptr unsafe.Pointer) unsafe.Pointer {
+	s.checkLive()
+	base := unsafe.Pointer(unsafe.SliceData(s.a.data))
+	if !within(base, uintptr(len(s.a.data)), ptr) {
+		panic(fmt.Sprintf("nuke: %p does not point into the snapshotted arena", ptr))
+	}
+	return unsafe.Pointer(&s.data[uintptr(ptr)-uintptr(base)])
+}
+
+// Release unmaps the snapshot, discarding the changes made to it, and invalidating the pointers into it.
+// The arena is unsealed, by means of Unseal or Reset, once every snapshot has been released. Releasing
+// a snapshot again is a no-op.
+func (s *Snapshot) Release() error {
+	if s.data == nil {
+		return nil
+	}
+	err := munmapFile(s.data)
+	s.data = nil
+	s.a.snapshots--
+	return err
+}
+
+func (s *Snapshot) checkLive() {
+	if s.data == nil {
+		panic("nuke: snapshot used after Release")
+	}
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotArena(t *testing.T) {
+	if !MmapSupported || pageSize == 1 {
+		t.Skip("mapping files is not supported")
+	}
+	arena, err := NewSnapshotArena(4096)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer func() { require.NoError(t, arena.Close()) }()
+
+	s := MakeSlice[uint64](arena, 256, 256)
+	for i := range s {
+		s[i] = uint64(i)
+	}
+
+	snap1, err := arena.Snapshot()
+	require.NoError(t, err)
+	snap2, err := arena.Snapshot()
+	require.NoError(t, err)
+	require.PanicsWithError(t, "nuke: arena sealed: unable to allocate from a sealed arena", func() {
+		_ = New[int](arena)
+	})
+
+	v1 := unsafe.Slice((*uint64)(snap1.Translate(unsafe.Pointer(&s[0]))), len(s))
+	v2 := unsafe.Slice((*uint64)(snap2.Translate(unsafe.Pointer(&s[0]))), len(s))
+	require.Equal(t, s, v1)
+	v1[7] = 100
+	require.Equal(t, uint64(100), v1[7])
+	require.Equal(t, uint64(7), v2[7])
+	require.Equal(t, uint64(7), s[7])
+
+	require.ErrorIs(t, arena.Unseal(), ErrLiveSnapshots)
+	require.Panics(t, func() { arena.Reset(false) })
+	require.NoError(t, snap1.Release())
+	require.NoError(t, snap1.Release())
+	require.Panics(t, func() { snap1.Translate(unsafe.Pointer(&s[0])) })
+	require.NoError(t, snap2.Release())
+
+	require.NoError(t, arena.Unseal())
+	*New[int](arena) = 1
+	arena.Reset(false)
+	require.Zero(t, s[7])
+}
+
+func TestSnapshotArenaPointerPolicy(t *testing.T) {
+	if !MmapSupported || pageSize == 1 {
+		t.Skip("mapping files is not supported")
+	}
+	arena, err := NewSnapshotArena(4096, WithPointerPolicy(PointerAllow))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer func() { require.NoError(t, arena.Close()) }()
+	requirePanicsWithErrorIs(t, ErrPointerType, func() { New[*int](arena) })
+}
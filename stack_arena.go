@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "unsafe"
+
+// StackArena is a monotonic arena whose allocations follow a stack discipline: Push returns a marker
+// recording the current top of the arena, and Pop frees every allocation made since then, which gives
+// recursive algorithms, such as tree traversals or expression evaluation, nested lifetimes.
+//
+// A StackArena is not safe to be accessed concurrently from multiple goroutines.
+type StackArena struct {
+	*monotonicArena
+}
+
+// Marker records the top of a StackArena at the time Push was invoked.
+type Marker struct {
+	buffer     int
+	offset     uintptr
+	oversized  int
+	bytesInUse uint64
+	resets     uint64
+}
+
+// NewStackArena creates a new stack arena starting with a buffer of bufferSize bytes. Unless a different
+// policy is installed by WithOnExhausted, the arena grows as needed to satisfy every allocation.
+func NewStackArena(bufferSize int, opts ...Option) *StackArena {
+	o := newOptions(opts)
+	if o.onExhausted == nil {
+		o.onExhausted = func(Exhaustion) ExhaustedAction { return ExhaustedGrow }
+	}
+	return &StackArena{monotonicArena: newMonotonicArena(bufferSize, 1, o)}
+}
+
+// Push returns a marker recording the current top of the arena.
+func (a *StackArena) Push() Marker {
+	return Marker{
+		buffer:     a.current,
+		offset:     a.buffers[a.current].offset,
+		oversized:  len(a.oversized),
+		bytesInUse: a.counters.bytesInUse,
+		resets:     a.counters.resets,
+	}
+}
+
+// Pop frees every allocation made since the marker was returned by Push, zeroing out their memory and
+// releasing the buffers dedicated to oversized allocations.
+// Markers pushed after m become invalid, and Pop panics if m was already popped or the arena was reset since.
+// After invoking this method any pointer allocated since the marker becomes immediately invalid.
+func (a *StackArena) Pop(m Marker) {
+	if m.resets != a.counters.resets || m.buffer > a.current || m.oversized > len(a.oversized) ||
+		(m.buffer == a.current && m.offset > a.buffers[m.buffer].offset) {
+		panic("nuke: invalid stack arena marker")
+	}
+	for i := m.buffer + 1; i <= a.current; i++ {
+		a.buffers[i].reset(false)
+	}
+	a.buffers[m.buffer].rewind(m.offset)
+	for i, s := range a.oversized[m.oversized:] {
+		s.reset(true)
+		a.size -= s.size
+		a.oversized[m.oversized+i] = nil
+	}
+	a.oversized = a.oversized[:m.oversized]
+	a.current = m.buffer
+	a.counters.bytesInUse = m.bytesInUse
+	raceReset(unsafe.Pointer(a.monotonicArena))
+}
+
+// rewind frees the memory allocated from the buffer beyond the given offset.
+func (s *monotonicBuffer) rewind(offset uintptr) {
+	if s.offset <= offset {
+		return
+	}
+	raceReclaim(unsafe.Add(s.ptr, offset), s.offset-offset)
+	if s.poison {
+		poisonMemory(unsafe.Add(s.ptr, offset), s.offset-offset)
+	}
+	if s.lazy {
+		s.dirty = max(s.dirty, s.offset)
+	} else {
+		clear(unsafe.Slice((*byte)(unsafe.Add(s.ptr, offset)), s.offset-offset))
+	}
+	s.asanPoison(offset, s.offset-offset)
+	s.offset = offset
+}
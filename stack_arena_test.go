@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackArenaPushPop(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewStackArena(64)
+
+	a := New[int64](arena)
+	*a = 1
+
+	outer := arena.Push()
+	b := New[int64](arena)
+	*b = 2
+
+	inner := arena.Push()
+	s := MakeSlice[int64](arena, 16, 16) // grows a buffer
+	s[15] = 3
+	require.Len(t, arena.buffers, 2)
+	require.Equal(t, uint64(144), arena.Stats().BytesInUse)
+
+	arena.Pop(inner)
+	require.Zero(t, s[15])
+	require.Equal(t, int64(2), *b)
+	require.Equal(t, uint64(16), arena.Stats().BytesInUse)
+
+	arena.Pop(outer)
+	require.Zero(t, *b)
+	require.Equal(t, int64(1), *a)
+	require.Equal(t, uint64(8), arena.Stats().BytesInUse)
+
+	// Memory freed by Pop is reused.
+	require.Equal(t, b, New[int64](arena))
+}
+
+func TestStackArenaInvalidMarker(t *testing.T) {
+	arena := NewStackArena(64)
+
+	outer := arena.Push()
+	_ = New[int64](arena)
+	inner := arena.Push()
+	_ = New[int64](arena)
+
+	arena.Pop(outer)
+	require.Panics(t, func() { arena.Pop(inner) })
+
+	m := arena.Push()
+	arena.Reset(false)
+	require.Panics(t, func() { arena.Pop(m) })
+}
+
+func TestStackArenaPopReleasesOversized(t *testing.T) {
+	arena := NewStackArena(64, WithOversizedThreshold(64))
+	_ = New[int64](arena)
+	for i := 0; i < 4; i++ {
+		m := arena.Push()
+		_ = MakeSlice[byte](arena, 1000, 1000)
+		require.Len(t, arena.oversized, 1)
+		arena.Pop(m)
+	}
+	require.Empty(t, arena.oversized)
+	require.Equal(t, 1, arena.Stats().Buffers)
+	require.Equal(t, uint64(64), arena.Stats().BytesAllocated)
+}
+
+func TestStackArenaLazyZeroing(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewStackArena(64, WithLazyZeroing())
+
+	m := arena.Push()
+	s := MakeSlice[byte](arena, 32, 32)
+	for i := range s {
+		s[i] = 0xff
+	}
+	arena.Pop(m)
+	require.Equal(t, byte(0xff), s[0])
+	require.Equal(t, make([]byte, 32), MakeSlice[byte](arena, 32, 32))
+}
+
+func TestStackArenaPoisonOnPop(t *testing.T) {
+	skipUnderASan(t)
+
+	arena := NewStackArena(64, WithPoisonOnReset())
+
+	m := arena.Push()
+	s := MakeSlice[byte](arena, 32, 32)
+	arena.Pop(m)
+	require.Equal(t, bytes.Repeat([]byte{PoisonByte}, 32), s)
+	require.Equal(t, make([]byte, 32), MakeSlice[byte](arena, 32, 32))
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "io"
+
+// StatsProvider is implemented by arenas able to report usage statistics.
+type StatsProvider interface {
+	// Stats returns a snapshot of the arena usage statistics.
+	Stats() Stats
+}
+
+// Dumper is implemented by arenas able to describe their memory layout, for debugging purposes.
+type Dumper interface {
+	// Dump writes a human-readable description of the memory layout of the arena to w.
+	Dump(w io.Writer) error
+}
+
+// Stats holds arena usage statistics.
+type Stats struct {
+	// BytesAllocated is the number of bytes the arena currently holds to serve allocations from.
+	BytesAllocated uint64
+
+	// BytesInUse is the number of bytes handed out since the last Reset, including alignment padding.
+	BytesInUse uint64
+
+	// Buffers is the number of buffers backing the arena.
+	Buffers int
+
+	// HighWaterMark is the maximum value BytesInUse has ever reached.
+	HighWaterMark uint64
+
+	// HeapFallbacks is the number of allocations that fell back to the heap
+	// because the arena could not satisfy them.
+	HeapFallbacks uint64
+
+	// PointerAllocations is the number of allocations of types containing pointers served from arena memory
+	// the GC does not scan, which the PointerAllow policy permits.
+	PointerAllocations uint64
+
+	// Resets is the number of times the arena has been reset.
+	Resets uint64
+}
+
+// arenaCounters holds the statistics counters maintained by the arena implementations.
+type arenaCounters struct {
+	bytesInUse    uint64
+	highWaterMark uint64
+	heapFallbacks uint64
+	pointerAllocs uint64
+	resets        uint64
+}
+
+func (c *arenaCounters) allocated(n uint64) {
+	c.bytesInUse += n
+	c.highWaterMark = max(c.highWaterMark, c.bytesInUse)
+}
+
+func (c *arenaCounters) reset() {
+	c.bytesInUse = 0
+	c.resets++
+}
+
+func (c *arenaCounters) stats() Stats {
+	return Stats{
+		BytesInUse:         c.bytesInUse,
+		HighWaterMark:      c.highWaterMark,
+		HeapFallbacks:      c.heapFallbacks,
+		PointerAllocations: c.pointerAllocs,
+		Resets:             c.resets,
+	}
+}
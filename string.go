@@ -0,0 +1,14 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "unsafe"
+
+// SealString returns a string sharing memory with b, without copying it.
+// Along with MakeSlice, it allows to build arena strings in two steps: allocate a byte slice of
+// the exact size from the arena, fill it, and finally seal it into an immutable string.
+// The bytes must not be modified after invoking this method, and the returned string
+// becomes invalid as soon as the arena the bytes were allocated from is reset.
+func SealString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
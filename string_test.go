@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealString(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+
+	b := MakeSlice[byte](arena, 4, 4)
+	copy(b, "nuke")
+
+	s := SealString(b)
+	require.Equal(t, "nuke", s)
+	require.Equal(t, unsafe.SliceData(b), unsafe.StringData(s))
+
+	require.Equal(t, "", SealString(nil))
+	require.Equal(t, "", SealString(MakeSlice[byte](arena, 0, 0)))
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"hash/maphash"
+	"unsafe"
+)
+
+// SymbolTable maps strings to dense uint32 IDs, assigned in insertion order starting from zero, as compilers and
+// log parsers need for identifiers and field names. The bytes of the strings, as well as the bucket arrays, are
+// allocated from an arena, hence the table must not be used anymore once the arena is reset. The arena must
+// allow allocating strings, which hold pointers, unless it is nil, in which case the heap is used. The bytes of
+// the symbols the arena cannot serve are allocated on the heap, and kept reachable by the table.
+// A SymbolTable is not safe to be accessed concurrently from multiple goroutines.
+type SymbolTable struct {
+	a       Arena
+	seed    maphash.Seed
+	buckets []uint32 // ID plus one of the symbol every bucket holds, or zero if empty
+	symbols *Vector[string]
+	hashes  *Vector[uint64] // hash of every symbol, so that growing does not rehash
+	heap    heapRoots       // bytes of the symbols allocated on the heap
+}
+
+// NewSymbolTable returns an empty table allocating from a, sized to hold capacity symbols without growing.
+func NewSymbolTable(a Arena, capacity int) *SymbolTable {
+	n := 8
+	for n*3/4 < capacity {
+		n *= 2
+	}
+	return &SymbolTable{
+		a:       a,
+		seed:    maphash.MakeSeed(),
+		buckets: makeBuckets(a, n),
+		symbols: NewVector[string](a, capacity),
+		hashes:  NewVector[uint64](a, capacity),
+	}
+}
+
+// Len returns the number of symbols of the table.
+func (t *SymbolTable) Len() int {
+	return t.symbols.Len()
+}
+
+// Intern returns the ID of s, adding it to the table if missing, in which case its bytes are copied to the arena.
+func (t *SymbolTable) Intern(s string) uint32 {
+	h := maphash.String(t.seed, s)
+	if id, ok := t.lookup(h, s); ok {
+		return id
+	}
+	return t.add(h, copySymbol(t, s))
+}
+
+// InternBytes is like Intern, but avoids converting b to a string when it is already in the table.
+func (t *SymbolTable) InternBytes(b []byte) uint32 {
+	h := maphash.Bytes(t.seed, b)
+	if id, ok := t.lookup(h, string(b)); ok {
+		return id
+	}
+	return t.add(h, copySymbol(t, b))
+}
+
+// Lookup returns the ID of s, reporting false if it is not in the table.
+func (t *SymbolTable) Lookup(s string) (uint32, bool) {
+	return t.lookup(maphash.String(t.seed, s), s)
+}
+
+// Symbol returns the string whose ID is id. It panics if id was not returned by the table.
+func (t *SymbolTable) Symbol(id uint32) string {
+	return t.symbols.At(int(id))
+}
+
+func (t *SymbolTable) lookup(h uint64, s string) (uint32, bool) {
+	mask := uint64(len(t.buckets) - 1)
+	for i := h & mask; ; i = (i + 1) & mask {
+		e := t.buckets[i]
+		if e == 0 {
+			return 0, false
+		}
+		if id := e - 1; t.hashes.At(int(id)) == h && t.symbols.At(int(id)) == s {
+			return id, true
+		}
+	}
+}
+
+func (t *SymbolTable) add(h uint64, s string) uint32 {
+	id := uint32(t.symbols.Len())
+	t.symbols.Append(s)
+	t.hashes.Append(h)
+	if (int(id)+1)*4 > len(t.buckets)*3 {
+		t.grow()
+	} else {
+		t.insert(h, id)
+	}
+	return id
+}
+
+func (t *SymbolTable) insert(h uint64, id uint32) {
+	mask := uint64(len(t.buckets) - 1)
+	i := h & mask
+	for t.buckets[i] != 0 {
+		i = (i + 1) & mask
+	}
+	t.buckets[i] = id + 1
+}
+
+// grow doubles the number of buckets, inserting every symbol again.
+func (t *SymbolTable) grow() {
+	n := 2 * len(t.buckets)
+	t.buckets = makeBuckets(t.a, n)
+	for id, h := range t.hashes.Slice() {
+		t.insert(h, uint32(id))
+	}
+}
+
+// makeBuckets allocates n empty buckets from the arena, or from the heap if it is exhausted.
+func makeBuckets(a Arena, n int) []uint32 {
+	if b := MakeSlice[uint32](a, n, n); b != nil {
+		return b
+	}
+	return make([]uint32, n)
+}
+
+// copySymbol copies the bytes of a symbol to the arena, or to the heap if it is exhausted, in which case the table
+// keeps them reachable, as the header of the symbol is stored in arena memory.
+func copySymbol[S string | []byte](t *SymbolTable, s S) string {
+	b := MakeSlice[byte](t.a, len(s), len(s))
+	if b == nil {
+		b = make([]byte, len(s)) // the arena is exhausted
+	}
+	if len(b) > 0 {
+		t.heap.keep(t.a, unsafe.Pointer(unsafe.SliceData(b)))
+	}
+	copy(b, s)
+	return SealString(b)
+}
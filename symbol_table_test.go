@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolTable(t *testing.T) {
+	arena := NewMonotonicArena(64*1024, 1)
+	st := NewSymbolTable(arena, 0)
+
+	for i := 0; i < 100; i++ {
+		require.Equal(t, uint32(i), st.Intern("sym"+strconv.Itoa(i)))
+	}
+	require.Equal(t, 100, st.Len())
+	require.Equal(t, uint32(42), st.Intern("sym42"))
+	require.Equal(t, uint32(7), st.InternBytes([]byte("sym7")))
+	require.Equal(t, uint32(100), st.InternBytes([]byte("")))
+	require.Equal(t, 101, st.Len())
+
+	id, ok := st.Lookup("sym99")
+	require.True(t, ok)
+	require.Equal(t, uint32(99), id)
+	_, ok = st.Lookup("missing")
+	require.False(t, ok)
+
+	s := st.Symbol(42)
+	require.Equal(t, "sym42", s)
+	require.True(t, Owns(arena, unsafe.Pointer(unsafe.StringData(s))))
+}
+
+func TestSymbolTableInternBytesDoesNotAllocate(t *testing.T) {
+	st := NewSymbolTable(NewMonotonicArena(64*1024, 1), 16)
+	b := []byte("field")
+	st.InternBytes(b)
+	require.Zero(t, testing.AllocsPerRun(100, func() {
+		st.InternBytes(b)
+	}))
+}
+
+func TestSymbolTableExhausted(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	st := NewSymbolTable(arena, 16)
+
+	// The symbols and buckets are allocated on the heap once the arena is exhausted.
+	for i := 0; i < 32; i++ {
+		require.Equal(t, uint32(i), st.Intern("sym"+strconv.Itoa(i)))
+	}
+	require.Equal(t, uint32(32), st.InternBytes([]byte("bytes")))
+	require.Equal(t, "sym31", st.Symbol(31))
+	require.Equal(t, "bytes", st.Symbol(32))
+}
+
+func TestSymbolTableHeapSymbolsSurviveGC(t *testing.T) {
+	arena := NewMonotonicArena(256, 1)
+	st := NewSymbolTable(arena, 4)
+	symbols := make([]string, 4)
+	for i := range symbols {
+		symbols[i] = strings.Repeat(strconv.Itoa(i), 35)
+		st.Intern(strings.Clone(symbols[i]))
+	}
+
+	// The bytes allocated on the heap once the arena is exhausted are only referenced from arena memory.
+	churnHeap()
+	for i, s := range symbols {
+		require.Equal(t, s, st.Symbol(uint32(i)))
+	}
+}
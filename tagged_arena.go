@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// taggedArena allocates from a shared arena on behalf of a subsystem, accounting for its allocations.
+type taggedArena struct {
+	a   Arena
+	tag string
+
+	bytesInUse    atomic.Uint64
+	highWaterMark atomic.Uint64
+	resets        atomic.Uint64 // resets of the shared arena the usage has been accounted since
+	ownResets     atomic.Uint64 // resets made through the tagged arena
+}
+
+// Tagged returns an arena allocating from a on behalf of the subsystem identified by tag, so that the usage of a
+// single arena shared by several subsystems can be broken down by subsystem. The statistics of the tagged arena
+// only report the bytes allocated through it, along with their high water mark, and the shared arena resets.
+// Profiled arenas label the allocations made through the tagged arena with its tag. Resetting the tagged arena
+// resets the shared one, which is safe for concurrent use if a is.
+func Tagged(a Arena, tag string) Arena {
+	return &taggedArena{a: a, tag: tag}
+}
+
+// Alloc satisfies the Arena interface.
+func (a *taggedArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	a.allocated(size)
+	if ta, ok := a.a.(tagAllocator); ok {
+		ptr, _ := ta.allocTagged(nil, size, alignment, 1, a.tag)
+		return ptr
+	}
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *taggedArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	a.allocated(t.Size() * uintptr(n))
+	if ta, ok := a.a.(tagAllocator); ok {
+		return ta.allocTagged(t, t.Size(), uintptr(t.Align()), n, a.tag)
+	}
+	return allocType(a.a, t, n)
+}
+
+func (a *taggedArena) allocated(size uintptr) {
+	a.sync()
+	n := a.bytesInUse.Add(uint64(size))
+	for hwm := a.highWaterMark.Load(); n > hwm && !a.highWaterMark.CompareAndSwap(hwm, n); {
+		hwm = a.highWaterMark.Load()
+	}
+}
+
+// sync discards the usage accounted before the shared arena was last reset.
+func (a *taggedArena) sync() {
+	rc, ok := a.a.(resetCounter)
+	if !ok {
+		return
+	}
+	if resets := rc.resetCount(); a.resets.Load() != resets && a.resets.Swap(resets) != resets {
+		a.bytesInUse.Store(0)
+	}
+}
+
+// Reset satisfies the Arena interface.
+func (a *taggedArena) Reset(release bool) {
+	a.a.Reset(release)
+	a.ownResets.Add(1)
+	a.bytesInUse.Store(0)
+}
+
+// Stats satisfies the StatsProvider interface.
+func (a *taggedArena) Stats() Stats {
+	a.sync()
+	return Stats{
+		BytesInUse:    a.bytesInUse.Load(),
+		HighWaterMark: a.highWaterMark.Load(),
+		Resets:        statsOf(a.a).Resets,
+	}
+}
+
+// Owns satisfies the Owner interface, reporting whether ptr points into the shared arena,
+// regardless of the subsystem it was allocated on behalf of.
+func (a *taggedArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *taggedArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.ownResets)
+}
+
+func (a *taggedArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
+
+// tagAllocator is implemented by arenas labeling allocations with the tag of the tagged arena they come through.
+type tagAllocator interface {
+	// allocTagged allocates n contiguous values of the given size and alignment, whose type t is nil
+	// for untyped allocations.
+	allocTagged(t reflect.Type, size, alignment uintptr, n int, tag string) (unsafe.Pointer, bool)
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaggedArenaStats(t *testing.T) {
+	shared := NewMonotonicArena(1024, 1)
+	parser, encoder := Tagged(shared, "parser"), Tagged(shared, "encoder")
+
+	_ = New[allocTestStruct](parser)
+	_ = MakeSlice[byte](parser, 10, 10)
+	_ = encoder.Alloc(100, 1)
+	require.Equal(t, Stats{BytesInUse: 42, HighWaterMark: 42}, parser.(StatsProvider).Stats())
+	require.Equal(t, Stats{BytesInUse: 100, HighWaterMark: 100}, encoder.(StatsProvider).Stats())
+	require.Equal(t, uint64(142), shared.(StatsProvider).Stats().BytesInUse)
+
+	// Resetting the shared arena resets the usage of every tag.
+	shared.Reset(false)
+	_ = New[int64](parser)
+	require.Equal(t, Stats{BytesInUse: 8, HighWaterMark: 42, Resets: 1}, parser.(StatsProvider).Stats())
+	require.Equal(t, Stats{HighWaterMark: 100, Resets: 1}, encoder.(StatsProvider).Stats())
+
+	encoder.Reset(false)
+	require.Equal(t, uint64(2), shared.(StatsProvider).Stats().Resets)
+	require.Equal(t, uint64(0), parser.(StatsProvider).Stats().BytesInUse)
+}
+
+func TestTaggedArenaProfile(t *testing.T) {
+	arena := NewProfiledArena(NewMonotonicArena(1024, 1), 1)
+	_ = New[allocTestStruct](Tagged(arena, "parser"))
+	_ = Tagged(arena, "encoder").Alloc(16, 1)
+	_ = New[int](arena)
+
+	tags := make(map[string]int)
+	for k := range arena.samples {
+		tags[k.tag]++
+		if k.tag != "" {
+			// Stacks start at the caller of the tagged arena.
+			fn, _ := runtimeFuncName(k.stack[0])
+			require.NotContains(t, fn, "taggedArena")
+		}
+	}
+	require.Equal(t, map[string]int{"parser": 1, "encoder": 1, "": 1}, tags)
+
+	var buf bytes.Buffer
+	require.NoError(t, arena.WriteProfile(&buf))
+	zr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	p, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Contains(t, string(p), "parser")
+	require.Contains(t, string(p), "encoder")
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ThresholdArena wraps an arena, sending the allocations larger than a threshold straight to the heap, where
+// they are cheap, rather than wasting the space of the buffers of the arena, which stays tuned for small objects.
+// Delegated allocations are counted, so that the threshold can be tuned. A ThresholdArena is safe to be accessed
+// concurrently from multiple goroutines as long as the wrapped arena is.
+type ThresholdArena struct {
+	a         Arena
+	threshold uintptr
+
+	delegated      atomic.Uint64
+	delegatedBytes atomic.Uint64
+	resets         atomic.Uint64
+}
+
+// NewThresholdArena returns an arena wrapping a, which serves the allocations of more than threshold bytes
+// from the heap.
+func NewThresholdArena(a Arena, threshold int) *ThresholdArena {
+	return &ThresholdArena{a: a, threshold: uintptr(threshold)}
+}
+
+// Alloc satisfies the Arena interface. It returns nil for allocations above the threshold.
+func (a *ThresholdArena) Alloc(size, alignment uintptr) unsafe.Pointer {
+	if a.delegate(size) {
+		return nil
+	}
+	return a.a.Alloc(size, alignment)
+}
+
+// AllocType satisfies the TypedArena interface.
+func (a *ThresholdArena) AllocType(t reflect.Type, n int) (unsafe.Pointer, bool) {
+	if a.delegate(t.Size() * uintptr(n)) {
+		return nil, true
+	}
+	return allocType(a.a, t, n)
+}
+
+// delegate reports whether an allocation of size bytes is sent to the heap, counting it if so.
+func (a *ThresholdArena) delegate(size uintptr) bool {
+	if size <= a.threshold {
+		return false
+	}
+	a.delegated.Add(1)
+	a.delegatedBytes.Add(uint64(size))
+	traceFallback(size)
+	return true
+}
+
+// Reset satisfies the Arena interface.
+func (a *ThresholdArena) Reset(release bool) {
+	a.a.Reset(release)
+	a.resets.Add(1)
+}
+
+// Stats satisfies the StatsProvider interface, reporting the statistics of the wrapped arena.
+// Delegated allocations are reported by Delegations rather than as heap fallbacks.
+func (a *ThresholdArena) Stats() Stats {
+	return statsOf(a.a)
+}
+
+// Delegations returns the number of allocations sent to the heap, along with their overall size.
+func (a *ThresholdArena) Delegations() (allocations, bytes uint64) {
+	return a.delegated.Load(), a.delegatedBytes.Load()
+}
+
+// Owns satisfies the Owner interface.
+func (a *ThresholdArena) Owns(ptr unsafe.Pointer) bool {
+	return Owns(a.a, ptr)
+}
+
+func (a *ThresholdArena) resetCount() uint64 {
+	return wrappedResetCount(a.a, &a.resets)
+}
+
+func (a *ThresholdArena) concurrencySafe() bool {
+	return isConcurrencySafe(a.a)
+}
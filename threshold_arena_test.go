@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdArena(t *testing.T) {
+	arena := NewThresholdArena(NewMonotonicArena(1024, 1), 64)
+
+	small := MakeSlice[byte](arena, 64, 64)
+	large := MakeSlice[byte](arena, 65, 65)
+	i := New[[16]int64](arena)
+	require.True(t, Owns(arena, unsafe.Pointer(&small[0])))
+	require.False(t, Owns(arena, unsafe.Pointer(&large[0])))
+	require.False(t, Owns(arena, unsafe.Pointer(i)))
+	require.Nil(t, arena.Alloc(100, 1))
+
+	allocations, bytes := arena.Delegations()
+	require.Equal(t, uint64(3), allocations)
+	require.Equal(t, uint64(65+128+100), bytes)
+	require.Equal(t, Stats{BytesAllocated: 1024, BytesInUse: 64, Buffers: 1, HighWaterMark: 64}, arena.Stats())
+
+	arena.Reset(false)
+	require.Equal(t, uint64(1), arena.Stats().Resets)
+}
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"context"
+	"reflect"
+	"runtime/trace"
+	"strconv"
+)
+
+// Arenas log the following events to the execution trace while one is being collected, so that their behavior
+// shows up alongside GC and scheduler events, whereas resets are traced as regions named after traceResetRegion.
+const (
+	traceGrowCategory     = "nuke.grow"
+	traceFallbackCategory = "nuke.fallback"
+	traceResetRegion      = "nuke.Reset"
+)
+
+// traceGrowth logs an arena acquiring size bytes of additional memory.
+func traceGrowth(size uintptr) {
+	if trace.IsEnabled() {
+		trace.Log(context.Background(), traceGrowCategory, strconv.FormatUint(uint64(size), 10)+" bytes")
+	}
+}
+
+// traceFallback logs an allocation of size bytes falling back to the heap.
+func traceFallback(size uintptr) {
+	if trace.IsEnabled() {
+		trace.Log(context.Background(), traceFallbackCategory, strconv.FormatUint(uint64(size), 10)+" bytes")
+	}
+}
+
+// tracePointerFallback logs an allocation of a type containing pointers falling back to the heap.
+func tracePointerFallback(t reflect.Type) {
+	if trace.IsEnabled() {
+		trace.Log(context.Background(), traceFallbackCategory, "pointer type "+t.String())
+	}
+}
+
+// traceReset starts the region spanning a Reset, which must be ended once done.
+func traceReset() *trace.Region {
+	return trace.StartRegion(context.Background(), traceResetRegion)
+}
@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"bytes"
+	"runtime/trace"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, trace.Start(&buf))
+
+	arena := NewMonotonicArena(64, 1, WithGrowOnDemand(), WithMaxBuffers(2))
+	_ = MakeSlice[byte](arena, 100, 100)
+	_ = MakeSlice[byte](arena, 100, 100)
+	arena.Reset(false)
+
+	safe := NewSafeArena(64, WithPointerPolicy(PointerFallback))
+	_ = MakeSlice[byte](safe, 100, 100)
+	safe.Reset(false)
+
+	trace.Stop()
+	for _, s := range []string{traceGrowCategory, traceFallbackCategory, traceResetRegion, "100 bytes"} {
+		require.Contains(t, buf.String(), s)
+	}
+}
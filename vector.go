@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import "unsafe"
+
+// extender is implemented by arenas able to grow their last allocation in place.
+type extender interface {
+	extend(ptr unsafe.Pointer, oldSize, newSize uintptr) bool
+}
+
+// Vector is a growable array of values of type T allocated from an arena, which handles its own growth rather
+// than having callers keep track of the slices returned by SliceAppend. Whenever the elements are the last
+// allocation of a monotonic arena, which has room for more, the vector grows in place instead of copying them.
+// As with any memory allocated from the arena, the elements become invalid once it is reset.
+type Vector[T any] struct {
+	a Arena
+	s []T
+}
+
+// NewVector returns an empty vector allocating from a, with room for capacity elements. If the arena is nil,
+// the elements are allocated on the heap.
+func NewVector[T any](a Arena, capacity int) *Vector[T] {
+	v := &Vector[T]{a: a}
+	if capacity > 0 {
+		v.s = MakeSlice[T](a, 0, capacity)
+	}
+	return v
+}
+
+// Append appends values to the vector, growing it if needed.
+func (v *Vector[T]) Append(values ...T) {
+	if n := len(v.s) + len(values); n > cap(v.s) {
+		v.grow(n, len(values))
+	}
+	v.s = append(v.s, values...)
+}
+
+func (v *Vector[T]) grow(newLen, dataLen int) {
+	newCap := growCap(cap(v.s), newLen, dataLen)
+	if e, ok := v.a.(extender); ok && cap(v.s) > 0 {
+		var zero T
+		size := unsafe.Sizeof(zero)
+		ptr := unsafe.Pointer(unsafe.SliceData(v.s))
+		if e.extend(ptr, uintptr(cap(v.s))*size, uintptr(newCap)*size) {
+			v.s = unsafe.Slice((*T)(ptr), newCap)[:len(v.s)]
+			return
+		}
+	}
+	s := MakeSlice[T](v.a, len(v.s), newCap)
+	if s == nil {
+		s = make([]T, len(v.s), newCap) // the arena is exhausted
+	}
+	copy(s, v.s)
+	v.s = s
+}
+
+// Len returns the number of elements of the vector.
+func (v *Vector[T]) Len() int {
+	return len(v.s)
+}
+
+// Cap returns the number of elements the vector has room for before growing.
+func (v *Vector[T]) Cap() int {
+	return cap(v.s)
+}
+
+// At returns the element at index i. It panics if i is out of range.
+func (v *Vector[T]) At(i int) T {
+	return v.s[i]
+}
+
+// Set sets the element at index i. It panics if i is out of range.
+func (v *Vector[T]) Set(i int, value T) {
+	v.s[i] = value
+}
+
+// Slice returns a view of the elements of the vector, which remains valid until it grows.
+func (v *Vector[T]) Slice() []T {
+	return v.s
+}
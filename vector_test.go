@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package nuke
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVector(t *testing.T) {
+	arena := NewMonotonicArena(1024, 1)
+	v := NewVector[int64](arena, 2)
+	base := unsafe.SliceData(v.Slice())
+
+	for i := int64(0); i < 16; i++ {
+		v.Append(i)
+	}
+	require.Equal(t, 16, v.Len())
+	require.Equal(t, 16, v.Cap())
+	require.Equal(t, int64(7), v.At(7))
+	v.Set(7, 70)
+	require.Equal(t, int64(70), v.Slice()[7])
+
+	// The vector grew in place, as it was the last allocation.
+	require.Same(t, base, unsafe.SliceData(v.Slice()))
+	require.Equal(t, uint64(16*8), statsOf(arena).BytesInUse)
+
+	// Once another allocation follows it, the vector is copied.
+	_ = New[byte](arena)
+	v.Append(16)
+	require.NotSame(t, base, unsafe.SliceData(v.Slice()))
+	require.True(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(v.Slice()))))
+	require.Equal(t, int64(70), v.At(7))
+	require.Equal(t, int64(16), v.At(16))
+}
+
+func TestVectorHeap(t *testing.T) {
+	v := NewVector[string](nil, 0)
+	v.Append("a", "b", "c")
+	require.Equal(t, []string{"a", "b", "c"}, v.Slice())
+}
+
+func TestVectorExhaustedArena(t *testing.T) {
+	arena := NewMonotonicArena(64, 1, WithOnExhausted(func(Exhaustion) ExhaustedAction { return ExhaustedReturnNil }))
+	v := NewVector[int64](arena, 8)
+	_ = New[byte](arena)
+	v.Append(make([]int64, 9)...)
+	require.Equal(t, 9, v.Len())
+	require.False(t, Owns(arena, unsafe.Pointer(unsafe.SliceData(v.Slice()))))
+}